@@ -1,3 +0,0 @@
-module text.com
-
-go 1.23.0
@@ -1,74 +1,61 @@
 package main
 
 import (
-	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
 	"fmt"
 	"os"
-	"strings"
+
+	"music/pkg/lyrics"
 )
 
 func main() {
-	// Открываем файл input.txt для чтения
-	file, err := os.Open("input.txt")
+	input := flag.String("in", "input.txt", "Файл с текстом песни, куплеты разделены пустыми строками")
+	output := flag.String("out", "output.txt", "Файл для отформатированных куплетов")
+	format := flag.String("format", "sections", "Формат результата: sections - JSON-массив разделов со строками, verses - строки JSON для массива verses")
+	keepLineBreaks := flag.Bool("keep-line-breaks", false, "Сохранять переводы строк внутри куплетов в формате verses")
+	trimRepeats := flag.Bool("trim-repeats", false, "Убирать пометки о повторе вроде (х3) в конце строк")
+	flag.Parse()
+
+	// Читаем текст песни целиком
+	text, err := os.ReadFile(*input)
 	if err != nil {
-		fmt.Printf("Ошибка при открытии файла: %v\n", err)
-		return
-	}
-	defer file.Close() // Закрываем файл после завершения работы
-
-	// Создаем новый сканер для чтения из файла
-	scanner := bufio.NewScanner(file)
-
-	var textBuilder strings.Builder
-	for scanner.Scan() {
-		line := scanner.Text()
-		textBuilder.WriteString(line + "\n") // Добавляем строку к тексту с переводом строки
-	}
-
-	// Проверяем на ошибки при чтении файла
-	if err := scanner.Err(); err != nil {
 		fmt.Printf("Ошибка при чтении файла: %v\n", err)
 		return
 	}
 
-	// Получаем текст из Builder
-	text := textBuilder.String()
-
-	// Разбиваем текст на куплеты, используя двойной перевод строки
-	verses := strings.Split(text, "\n\n")
-
-	// Формируем массив строк для вывода
-	var formatted []string
-	for _, verse := range verses {
-		// Удаляем лишние пробелы и добавляем к массиву, если не пусто
-		trimmed := strings.TrimSpace(verse)
-		if trimmed != "" {
-			// Удаляем все переводы строк внутри куплета
-			noLineBreaks := strings.ReplaceAll(trimmed, "\n", " ")
-			formatted = append(formatted, fmt.Sprintf("\"%s\"", noLineBreaks))
+	var result []byte
+	switch *format {
+	case "sections":
+		// Разбиваем текст на разделы со строками и записываем их JSON-массивом для поля sections
+		sections := lyrics.Parse(string(text))
+		if *trimRepeats {
+			sections = lyrics.DropRepeats(sections)
 		}
-	}
-
-	// Объединяем в нужный формат с запятыми
-	result := strings.Join(formatted, ", ")
-
-	// Определяем имя файла для сохранения
-	fileName := "output.txt"
-
-	// Создаем файл или перезаписываем его, если он уже существует
-	file, err = os.Create(fileName)
-	if err != nil {
-		fmt.Printf("Ошибка при создании файла: %v\n", err)
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(sections); err != nil {
+			fmt.Printf("Ошибка при записи разделов: %v\n", err)
+			return
+		}
+		result = buf.Bytes()
+	case "verses":
+		// Разбиваем текст на куплеты и записываем их строками JSON через запятую
+		verses := lyrics.Split(string(text), lyrics.Options{KeepLineBreaks: *keepLineBreaks, TrimRepeats: *trimRepeats})
+		result = []byte(lyrics.Quote(verses))
+	default:
+		fmt.Printf("Неизвестный формат %q, ожидается sections или verses\n", *format)
 		return
 	}
-	defer file.Close() // Закрываем файл после завершения работы
 
-	// Записываем результат в файл
-	_, err = file.WriteString(result)
-	if err != nil {
+	// Создаем файл или перезаписываем его, если он уже существует
+	if err := os.WriteFile(*output, result, 0o644); err != nil {
 		fmt.Printf("Ошибка при записи в файл: %v\n", err)
 		return
 	}
 
-	fmt.Printf("Результат сохранён в файл: %s\n", fileName)
+	fmt.Printf("Результат сохранён в файл: %s\n", *output)
 }
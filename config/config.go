@@ -2,63 +2,337 @@ package config
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
 	"os"
+	"path/filepath"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"music/pkg/logger"
 
+	"github.com/BurntSushi/toml"
 	"github.com/joho/godotenv"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"gopkg.in/yaml.v3"
 )
 
-const (
-	defaultPort         = "8080"
-	defaultReadTimeout  = 10
-	defaultWriteTimeout = 10
-)
+// ConfigFileEnv - переменная окружения с путём к файлу настроек; флаг -config имеет приоритет
+const ConfigFileEnv = "CONFIG_FILE"
+
+// Config - все настройки приложения. Источники применяются по возрастанию приоритета:
+// значения по умолчанию, файл YAML или TOML, переменные окружения, флаги командной строки.
+type Config struct {
+	Server      ServerConfig
+	Database    DatabaseConfig
+	Log         LogConfig
+	SongDetails SongDetailsConfig
+	Trash       TrashConfig
+}
+
+// ServerConfig описывает HTTP-сервер
+type ServerConfig struct {
+	Port               string
+	ReadTimeout        time.Duration // Время на чтение запроса целиком; 0 - без ограничения
+	ReadHeaderTimeout  time.Duration // Время на чтение заголовков запроса
+	WriteTimeout       time.Duration // Время на запись ответа; 0 - без ограничения
+	IdleTimeout        time.Duration // Сколько держать простаивающее keep-alive соединение
+	ShutdownTimeout    time.Duration // Время на завершение начатых запросов при остановке
+	ReadinessDrain     time.Duration // Сколько после сигнала обслуживать запросы с /readyz 503; входит в ShutdownTimeout
+	HealthCheckTimeout time.Duration // Время, которое /readyz даёт каждой проверке
+	DebugEndpoints     bool          // Открывать /debug/db; выключено, потому что маршрут доступен без авторизации
+}
+
+// DatabaseConfig описывает подключение к Postgres
+type DatabaseConfig struct {
+	Host            string
+	Port            string
+	User            string
+	Password        Secret
+	Name            string
+	SSLMode         string        // Режим SSL libpq: disable, allow, prefer, require, verify-ca или verify-full
+	MaxOpenConns    int           // Наибольшее число открытых соединений; 0 - без ограничения
+	MaxIdleConns    int           // Наибольшее число простаивающих соединений в пуле
+	ConnMaxLifetime time.Duration // Через сколько соединение закрывается и открывается заново; 0 - без ограничения
+	ConnMaxIdleTime time.Duration // Через сколько закрывается простаивающее соединение; 0 - без ограничения
+	ConnectMaxWait  time.Duration // Сколько при старте ждать доступности базы; 0 - одна попытка
+	ConnectBackoff  time.Duration // Пауза перед первым повтором подключения; каждая следующая вдвое дольше
+}
+
+// LogConfig описывает журналирование
+type LogConfig struct {
+	Level  string // debug, info, warn или error
+	Format string // json или console
+}
+
+// SongDetailsConfig описывает настройки клиента внешнего API с подробностями о песнях
+type SongDetailsConfig struct {
+	BaseURL string        // Базовый адрес API, пустая строка отключает обогащение
+	Timeout time.Duration // Таймаут одного запроса
+	Retries int           // Количество повторных попыток при ошибке
+}
+
+// TrashConfig описывает хранение удалённых записей в корзине
+type TrashConfig struct {
+	Retention     time.Duration // Сколько запись лежит в корзине до удаления навсегда; 0 - хранить бессрочно
+	SweepInterval time.Duration // Как часто проверять корзину
+}
+
+// Secret - строка, которая не попадает в логи и вывод настроек
+type Secret string
 
+// String скрывает значение секрета
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return "******"
+}
+
+// GoString скрывает значение секрета и в выводе %#v
+func (s Secret) GoString() string {
+	return strconv.Quote(s.String())
+}
+
+// Value возвращает настоящее значение секрета
+func (s Secret) Value() string {
+	return string(s)
+}
+
+// Default возвращает настройки по умолчанию
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Port:               "8080",
+			ReadTimeout:        10 * time.Second,
+			ReadHeaderTimeout:  5 * time.Second,
+			WriteTimeout:       10 * time.Second,
+			IdleTimeout:        60 * time.Second,
+			ShutdownTimeout:    8 * time.Second, // Меньше 10 секунд, которые Docker ждёт после SIGTERM перед SIGKILL
+			ReadinessDrain:     2 * time.Second, // Время, за которое балансировщик замечает 503 на /readyz
+			HealthCheckTimeout: 2 * time.Second,
+		},
+		Database: DatabaseConfig{
+			Host:            "localhost",
+			Port:            "5432",
+			SSLMode:         "disable",
+			MaxOpenConns:    10,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+			ConnectMaxWait:  30 * time.Second,
+			ConnectBackoff:  500 * time.Millisecond,
+		},
+		Log: LogConfig{
+			Level:  "debug",
+			Format: "json",
+		},
+		SongDetails: SongDetailsConfig{
+			Timeout: 5 * time.Second,
+			Retries: 2,
+		},
+		Trash: TrashConfig{
+			Retention:     30 * 24 * time.Hour,
+			SweepInterval: time.Hour,
+		},
+	}
+}
+
+// LoadEnv загружает переменные окружения из файла .env, если он есть
 func LoadEnv() {
 	if err := godotenv.Load(); err != nil {
 		logger.Warn(context.Background(), "No .env file found", err)
 	}
 }
 
-// getDurationFromEnv получает значение из переменной окружения и возвращает его как Duration
-func getDurationFromEnv(envKey string, defaultValue int) time.Duration {
-	envValue := os.Getenv(envKey)
-	if envValue != "" {
-		if value, err := strconv.Atoi(envValue); err == nil {
-			return time.Duration(value) * time.Second
+// Load собирает настройки из всех источников и проверяет их. args - аргументы командной строки
+// без имени программы; аргументы после флагов (например, подкоманда) возвращаются вторым значением.
+func Load(args []string) (*Config, []string, error) {
+	flags := flag.NewFlagSet("music", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	configFile := flags.String("config", os.Getenv(ConfigFileEnv), "Файл настроек YAML или TOML (переменная "+ConfigFileEnv+")")
+	values := make(map[string]*string, len(options))
+	for _, opt := range options {
+		values[opt.key] = flags.String(opt.flagName(), "", opt.usage+" (переменная "+opt.env+")")
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, nil, fmt.Errorf("command line: %w", err)
+	}
+
+	cfg := Default()
+	var errs []error
+	if *configFile != "" {
+		errs = append(errs, cfg.applyFile(*configFile)...)
+	}
+	for _, opt := range options {
+		if value, ok := os.LookupEnv(opt.env); ok && value != "" {
+			if err := opt.set(&cfg, strings.TrimSpace(value)); err != nil {
+				errs = append(errs, fmt.Errorf("env %s: %w", opt.env, err))
+			}
+		}
+	}
+	flags.Visit(func(f *flag.Flag) {
+		for _, opt := range options {
+			if opt.flagName() == f.Name {
+				if err := opt.set(&cfg, strings.TrimSpace(*values[opt.key])); err != nil {
+					errs = append(errs, fmt.Errorf("flag -%s: %w", f.Name, err))
+				}
+			}
+		}
+	})
+	if len(errs) == 0 {
+		errs = cfg.validate()
+	}
+	if len(errs) > 0 {
+		return nil, nil, fmt.Errorf("invalid configuration:\n%w", errors.Join(errs...))
+	}
+	return &cfg, flags.Args(), nil
+}
+
+// Usage записывает в w описание флагов, которые понимает Load
+func Usage(w io.Writer) {
+	fmt.Fprintf(w, "  -config string\n    \tФайл настроек YAML или TOML (переменная %s)\n", ConfigFileEnv)
+	for _, opt := range options {
+		fmt.Fprintf(w, "  -%s\n    \t%s (переменная %s, ключ файла %s)\n", opt.flagName(), opt.usage, opt.env, opt.key)
+	}
+}
+
+// applyFile применяет значения из файла YAML или TOML. Файл состоит из разделов с ключами,
+// как в выводе String: server.port записывается как port внутри раздела server.
+func (c *Config) applyFile(path string) []error {
+	var unmarshal func(content []byte, v any) error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		unmarshal = yaml.Unmarshal
+	case ".toml":
+		unmarshal = toml.Unmarshal
+	default:
+		return []error{fmt.Errorf("config file %s: only YAML (.yaml, .yml) and TOML (.toml) files are supported", path)}
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return []error{fmt.Errorf("config file: %w", err)}
+	}
+
+	var sections map[string]map[string]any
+	if err := unmarshal(content, &sections); err != nil {
+		return []error{fmt.Errorf("config file %s: %w", path, err)}
+	}
+
+	var errs []error
+	for section, values := range sections {
+		for name, value := range values {
+			key := section + "." + name
+			opt, ok := optionByKey(key)
+			if !ok {
+				errs = append(errs, fmt.Errorf("config file %s: unknown key %s", path, key))
+				continue
+			}
+			if value == nil {
+				continue
+			}
+			if err := opt.set(c, strings.TrimSpace(fmt.Sprint(value))); err != nil {
+				errs = append(errs, fmt.Errorf("config file %s: %s: %w", path, key, err))
+			}
 		}
 	}
-	return time.Duration(defaultValue) * time.Second
+	// Порядок ключей в map случаен, а ошибки удобнее читать в одном и том же порядке
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+	return errs
 }
 
-func GetServerConfig() (string, time.Duration, time.Duration) {
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = defaultPort
+// validate проверяет настройки целиком и возвращает все найденные ошибки
+func (c *Config) validate() []error {
+	var errs []error
+	check := func(ok bool, key, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: "+format, append([]any{key}, args...)...))
+		}
 	}
 
-	readTimeout := getDurationFromEnv("READ_TIMEOUT", defaultReadTimeout)
-	writeTimeout := getDurationFromEnv("WRITE_TIMEOUT", defaultWriteTimeout)
+	port, err := strconv.Atoi(c.Server.Port)
+	check(err == nil && port > 0 && port <= 65535, "server.port", "must be a TCP port between 1 and 65535, got %q", c.Server.Port)
+	check(c.Server.ReadTimeout >= 0, "server.read_timeout", "must not be negative")
+	check(c.Server.ReadHeaderTimeout > 0, "server.read_header_timeout", "must be positive")
+	check(c.Server.WriteTimeout >= 0, "server.write_timeout", "must not be negative")
+	check(c.Server.IdleTimeout >= 0, "server.idle_timeout", "must not be negative")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout", "must be positive")
+	check(c.Server.ReadinessDrain >= 0 && c.Server.ReadinessDrain < c.Server.ShutdownTimeout,
+		"server.readiness_drain", "must not be negative and must be less than server.shutdown_timeout")
+	check(c.Server.HealthCheckTimeout > 0, "server.health_check_timeout", "must be positive")
+
+	check(c.Database.Host != "", "db.host", "is required")
+	port, err = strconv.Atoi(c.Database.Port)
+	check(err == nil && port > 0 && port <= 65535, "db.port", "must be a TCP port between 1 and 65535, got %q", c.Database.Port)
+	check(c.Database.User != "", "db.user", "is required")
+	check(c.Database.Name != "", "db.name", "is required")
+	check(oneOf(c.Database.SSLMode, "disable", "allow", "prefer", "require", "verify-ca", "verify-full"),
+		"db.sslmode", "must be one of disable, allow, prefer, require, verify-ca, verify-full, got %q", c.Database.SSLMode)
+	check(c.Database.MaxOpenConns >= 0, "db.max_open_conns", "must not be negative")
+	check(c.Database.MaxIdleConns >= 0, "db.max_idle_conns", "must not be negative")
+	check(c.Database.MaxOpenConns == 0 || c.Database.MaxIdleConns <= c.Database.MaxOpenConns,
+		"db.max_idle_conns", "must not exceed db.max_open_conns (%d)", c.Database.MaxOpenConns)
+	check(c.Database.ConnMaxLifetime >= 0, "db.conn_max_lifetime", "must not be negative")
+	check(c.Database.ConnMaxIdleTime >= 0, "db.conn_max_idle_time", "must not be negative")
+	check(c.Database.ConnectMaxWait >= 0, "db.connect_max_wait", "must not be negative")
+	check(c.Database.ConnectBackoff > 0, "db.connect_backoff", "must be positive")
+
+	check(oneOf(c.Log.Level, "debug", "info", "warn", "error"), "log.level", "must be one of debug, info, warn, error, got %q", c.Log.Level)
+	check(oneOf(c.Log.Format, "json", "console"), "log.format", "must be json or console, got %q", c.Log.Format)
 
-	return port, readTimeout, writeTimeout
+	if c.SongDetails.BaseURL != "" {
+		u, err := url.Parse(c.SongDetails.BaseURL)
+		check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "",
+			"song_details.url", "must be an absolute http or https URL, got %q", c.SongDetails.BaseURL)
+	}
+	check(c.SongDetails.Timeout > 0, "song_details.timeout", "must be positive")
+	check(c.SongDetails.Retries >= 0, "song_details.retries", "must not be negative")
+
+	check(c.Trash.Retention >= 0, "trash.retention_days", "must not be negative")
+	check(c.Trash.SweepInterval > 0, "trash.sweep_interval", "must be positive")
+	return errs
+}
+
+// String выводит настройки построчно в виде ключ = значение; секреты скрыты
+func (c Config) String() string {
+	var b strings.Builder
+	for _, opt := range options {
+		fmt.Fprintf(&b, "%s = %s\n", opt.key, opt.get(&c))
+	}
+	return b.String()
 }
 
-// SetLogLevel устанавливает уровень логирования на основе переменной окружения
-func SetLogLevel() {
-	logLevel := os.Getenv("LOG_LEVEL")
-	if logLevel == "" {
-		logLevel = "debug"
+// DSN возвращает строку подключения libpq. Строка содержит пароль, её нельзя выводить в лог.
+func (d DatabaseConfig) DSN() string {
+	parts := []string{
+		"host=" + dsnValue(d.Host),
+		"port=" + dsnValue(d.Port),
+		"user=" + dsnValue(d.User),
+		"password=" + dsnValue(d.Password.Value()),
+		"dbname=" + dsnValue(d.Name),
+		"sslmode=" + dsnValue(d.SSLMode),
 	}
+	return strings.Join(parts, " ")
+}
 
+// dsnValue заключает значение в кавычки по правилам libpq, если в нём есть пробелы или спецсимволы
+func dsnValue(value string) string {
+	if value != "" && !strings.ContainsAny(value, " '\\\t\n") {
+		return value
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+// SetupLogger настраивает глобальный логгер по настройкам журналирования
+func SetupLogger(cfg LogConfig) {
 	var level zapcore.Level
-	switch logLevel {
-	case "debug":
-		level = zap.DebugLevel
+	switch cfg.Level {
 	case "info":
 		level = zap.InfoLevel
 	case "warn":
@@ -69,5 +343,18 @@ func SetLogLevel() {
 		level = zap.DebugLevel
 	}
 
+	if cfg.Format == "console" {
+		logger.SetLogger(logger.NewConsole(zap.NewAtomicLevelAt(level), os.Stdout))
+		return
+	}
 	logger.SetLogger(logger.New(zap.NewAtomicLevelAt(level)))
 }
+
+func oneOf(value string, allowed ...string) bool {
+	for _, candidate := range allowed {
+		if value == candidate {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,215 @@
+package config_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"music/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// clearEnv убирает влияние окружения, в котором запущены тесты
+func clearEnv(t *testing.T) {
+	t.Helper()
+	for _, name := range []string{config.ConfigFileEnv, "PORT", "READ_TIMEOUT", "DB_HOST", "DB_PORT", "DB_USER", "DB_PASSWORD",
+		"DB_NAME", "DB_SSLMODE", "DB_MAX_OPEN_CONNS", "DB_MAX_IDLE_CONNS", "LOG_LEVEL", "LOG_FORMAT", "SONG_DETAILS_URL",
+		"TRASH_RETENTION_DAYS", "TRASH_SWEEP_INTERVAL", "DEBUG_ENDPOINTS", "SHUTDOWN_TIMEOUT", "READINESS_DRAIN"} {
+		t.Setenv(name, "")
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	clearEnv(t)
+	path := writeFile(t, "music.yaml", `
+server:
+  port: 9000
+  read_timeout: 30s
+  write_timeout: 15
+db:
+  user: file-user
+  name: music_db
+  password: from-file
+  sslmode: require
+trash:
+  retention_days: 7
+`)
+	t.Setenv("DB_USER", "env-user")
+	t.Setenv("PORT", "9100")
+	t.Setenv("LOG_LEVEL", "info")
+
+	cfg, rest, err := config.Load([]string{"-config", path, "-server.port", "9200", "-db.max-open-conns=20", "migrate", "up"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"migrate", "up"}, rest)
+
+	// Флаг важнее окружения, окружение важнее файла, файл важнее значений по умолчанию
+	assert.Equal(t, "9200", cfg.Server.Port)
+	assert.Equal(t, "env-user", cfg.Database.User)
+	assert.Equal(t, 30*time.Second, cfg.Server.ReadTimeout)
+	assert.Equal(t, 15*time.Second, cfg.Server.WriteTimeout, "plain numbers are seconds")
+	assert.Equal(t, "require", cfg.Database.SSLMode)
+	assert.Equal(t, 20, cfg.Database.MaxOpenConns)
+	assert.Equal(t, "info", cfg.Log.Level)
+	assert.Equal(t, 7*24*time.Hour, cfg.Trash.Retention)
+	assert.Equal(t, "from-file", cfg.Database.Password.Value())
+
+	defaults := config.Default()
+	assert.False(t, defaults.Server.DebugEndpoints, "debug endpoints are off unless enabled")
+	assert.False(t, cfg.Server.DebugEndpoints)
+	assert.Equal(t, defaults.Server.IdleTimeout, cfg.Server.IdleTimeout)
+	assert.Equal(t, defaults.Database.Host, cfg.Database.Host)
+}
+
+func TestLoad_ConfigFileFromEnv(t *testing.T) {
+	clearEnv(t)
+	t.Setenv(config.ConfigFileEnv, writeFile(t, "music.yml", "server:\n  debug_endpoints: true\ndb:\n  user: u\n  name: n\n"))
+
+	cfg, _, err := config.Load(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "u", cfg.Database.User)
+	assert.True(t, cfg.Server.DebugEndpoints)
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		env     map[string]string
+		args    []string
+		wantErr []string
+	}{
+		{
+			name:    "Required database settings",
+			wantErr: []string{"db.user: is required", "db.name: is required"},
+		},
+		{
+			name: "Invalid values from every source",
+			file: "server:\n  port: http\n",
+			env:  map[string]string{"DB_USER": "u", "DB_NAME": "n", "DB_SSLMODE": "off", "LOG_LEVEL": "verbose"},
+			args: []string{"-db.max-idle-conns", "50"},
+			wantErr: []string{
+				`server.port: must be a TCP port between 1 and 65535, got "http"`,
+				`db.sslmode: must be one of disable, allow, prefer, require, verify-ca, verify-full, got "off"`,
+				`log.level: must be one of debug, info, warn, error, got "verbose"`,
+				"db.max_idle_conns: must not exceed db.max_open_conns (10)",
+			},
+		},
+		{
+			name: "Unparsable values name their source",
+			file: "server:\n  shutdown_timeout: soon\n",
+			env:  map[string]string{"DB_MAX_OPEN_CONNS": "many", "DEBUG_ENDPOINTS": "yes"},
+			args: []string{"-song-details.retries=x"},
+			wantErr: []string{"server.shutdown_timeout: expected a duration", "env DB_MAX_OPEN_CONNS: expected an integer",
+				"flag -song-details.retries: expected an integer", `env DEBUG_ENDPOINTS: expected true or false, got "yes"`},
+		},
+		{
+			name:    "Unknown file key",
+			file:    "db:\n  pasword: secret\n",
+			wantErr: []string{"unknown key db.pasword"},
+		},
+		{
+			name:    "Unknown flag",
+			args:    []string{"-db.pasword", "secret"},
+			wantErr: []string{"flag provided but not defined: -db.pasword"},
+		},
+		{
+			name:    "Song details URL",
+			env:     map[string]string{"DB_USER": "u", "DB_NAME": "n", "SONG_DETAILS_URL": "localhost:8080"},
+			wantErr: []string{`song_details.url: must be an absolute http or https URL, got "localhost:8080"`},
+		},
+		{
+			name:    "Readiness drain longer than shutdown timeout",
+			env:     map[string]string{"DB_USER": "u", "DB_NAME": "n", "SHUTDOWN_TIMEOUT": "5", "READINESS_DRAIN": "5s"},
+			wantErr: []string{"server.readiness_drain: must not be negative and must be less than server.shutdown_timeout"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			args := tt.args
+			if tt.file != "" {
+				args = append([]string{"-config", writeFile(t, "music.yaml", tt.file)}, args...)
+			}
+
+			_, _, err := config.Load(args)
+			require.Error(t, err)
+			for _, want := range tt.wantErr {
+				assert.Contains(t, err.Error(), want)
+			}
+		})
+	}
+}
+
+func TestLoad_TOMLFile(t *testing.T) {
+	clearEnv(t)
+	path := writeFile(t, "music.toml", `
+[server]
+port = 9000
+read_timeout = "30s"
+debug_endpoints = true
+
+[db]
+user = "u"
+name = "n"
+max_open_conns = 20
+
+[trash]
+retention_days = 7
+`)
+
+	cfg, _, err := config.Load([]string{"-config", path})
+	require.NoError(t, err)
+	assert.Equal(t, "9000", cfg.Server.Port)
+	assert.Equal(t, 30*time.Second, cfg.Server.ReadTimeout)
+	assert.True(t, cfg.Server.DebugEndpoints)
+	assert.Equal(t, "u", cfg.Database.User)
+	assert.Equal(t, 20, cfg.Database.MaxOpenConns)
+	assert.Equal(t, 7*24*time.Hour, cfg.Trash.Retention)
+
+	// Ошибки разбора и неизвестные ключи сообщаются так же, как для YAML
+	_, _, err = config.Load([]string{"-config", writeFile(t, "broken.toml", "[db\nuser = \"u\"\n")})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "broken.toml")
+	_, _, err = config.Load([]string{"-config", writeFile(t, "typo.toml", "[db]\npasword = \"secret\"\n")})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown key db.pasword")
+}
+
+func TestLoad_UnsupportedFileFormat(t *testing.T) {
+	clearEnv(t)
+	_, _, err := config.Load([]string{"-config", writeFile(t, "music.json", `{"db": {"user": "u"}}`)})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "only YAML (.yaml, .yml) and TOML (.toml) files are supported")
+}
+
+func TestConfig_RedactsSecrets(t *testing.T) {
+	cfg := config.Default()
+	cfg.Database.User = "music"
+	cfg.Database.Name = "music_db"
+	cfg.Database.Password = "s3cr3t pass'word"
+
+	for _, printed := range []string{cfg.String(), fmt.Sprint(cfg), fmt.Sprintf("%+v", cfg), fmt.Sprintf("%#v", cfg.Database)} {
+		assert.NotContains(t, printed, "s3cr3t")
+	}
+	assert.Contains(t, cfg.String(), "db.password = ******\n")
+	assert.Contains(t, cfg.String(), "db.user = music\n")
+	assert.Contains(t, cfg.String(), "server.read_timeout = 10s\n")
+
+	// В строке подключения пароль настоящий и экранирован по правилам libpq
+	assert.Equal(t, `host=localhost port=5432 user=music password='s3cr3t pass\'word' dbname=music_db sslmode=disable`, cfg.Database.DSN())
+}
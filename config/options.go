@@ -0,0 +1,142 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// option связывает поле Config с ключом файла, переменной окружения и флагом
+type option struct {
+	key   string // Ключ в файле и выводе String: раздел.имя
+	env   string
+	usage string
+	get   func(c *Config) string
+	set   func(c *Config, value string) error
+}
+
+// flagName - имя флага командной строки: ключ с дефисами вместо подчёркиваний
+func (o option) flagName() string {
+	return strings.ReplaceAll(o.key, "_", "-")
+}
+
+// options перечислены в порядке вывода настроек. Имена переменных окружения сохранены с тех пор,
+// когда настройки читались только из окружения.
+var options = []option{
+	stringOption("server.port", "PORT", "Порт HTTP-сервера", func(c *Config) *string { return &c.Server.Port }),
+	durationOption("server.read_timeout", "READ_TIMEOUT", "Время на чтение запроса", func(c *Config) *time.Duration { return &c.Server.ReadTimeout }),
+	durationOption("server.read_header_timeout", "READ_HEADER_TIMEOUT", "Время на чтение заголовков запроса", func(c *Config) *time.Duration { return &c.Server.ReadHeaderTimeout }),
+	durationOption("server.write_timeout", "WRITE_TIMEOUT", "Время на запись ответа", func(c *Config) *time.Duration { return &c.Server.WriteTimeout }),
+	durationOption("server.idle_timeout", "IDLE_TIMEOUT", "Время жизни простаивающего keep-alive соединения", func(c *Config) *time.Duration { return &c.Server.IdleTimeout }),
+	durationOption("server.shutdown_timeout", "SHUTDOWN_TIMEOUT", "Время на завершение запросов при остановке", func(c *Config) *time.Duration { return &c.Server.ShutdownTimeout }),
+	durationOption("server.readiness_drain", "READINESS_DRAIN", "Сколько после сигнала остановки принимать запросы, отвечая 503 на /readyz", func(c *Config) *time.Duration { return &c.Server.ReadinessDrain }),
+	durationOption("server.health_check_timeout", "HEALTH_CHECK_TIMEOUT", "Время на одну проверку /readyz", func(c *Config) *time.Duration { return &c.Server.HealthCheckTimeout }),
+	boolOption("server.debug_endpoints", "DEBUG_ENDPOINTS", "Открыть /debug/db со статистикой пула соединений", func(c *Config) *bool { return &c.Server.DebugEndpoints }),
+
+	stringOption("db.host", "DB_HOST", "Адрес Postgres", func(c *Config) *string { return &c.Database.Host }),
+	stringOption("db.port", "DB_PORT", "Порт Postgres", func(c *Config) *string { return &c.Database.Port }),
+	stringOption("db.user", "DB_USER", "Пользователь Postgres", func(c *Config) *string { return &c.Database.User }),
+	{
+		key: "db.password", env: "DB_PASSWORD", usage: "Пароль Postgres",
+		get: func(c *Config) string { return c.Database.Password.String() },
+		set: func(c *Config, value string) error { c.Database.Password = Secret(value); return nil },
+	},
+	stringOption("db.name", "DB_NAME", "Имя базы данных", func(c *Config) *string { return &c.Database.Name }),
+	stringOption("db.sslmode", "DB_SSLMODE", "Режим SSL: disable, allow, prefer, require, verify-ca, verify-full", func(c *Config) *string { return &c.Database.SSLMode }),
+	intOption("db.max_open_conns", "DB_MAX_OPEN_CONNS", "Наибольшее число открытых соединений, 0 - без ограничения", func(c *Config) *int { return &c.Database.MaxOpenConns }),
+	intOption("db.max_idle_conns", "DB_MAX_IDLE_CONNS", "Наибольшее число простаивающих соединений", func(c *Config) *int { return &c.Database.MaxIdleConns }),
+	durationOption("db.conn_max_lifetime", "DB_CONN_MAX_LIFETIME", "Время жизни соединения, 0 - без ограничения", func(c *Config) *time.Duration { return &c.Database.ConnMaxLifetime }),
+	durationOption("db.conn_max_idle_time", "DB_CONN_MAX_IDLE_TIME", "Время простоя соединения до закрытия, 0 - без ограничения", func(c *Config) *time.Duration { return &c.Database.ConnMaxIdleTime }),
+	durationOption("db.connect_max_wait", "DB_CONNECT_MAX_WAIT", "Сколько при старте ждать доступности базы, 0 - одна попытка", func(c *Config) *time.Duration { return &c.Database.ConnectMaxWait }),
+	durationOption("db.connect_backoff", "DB_CONNECT_BACKOFF", "Пауза перед первым повтором подключения", func(c *Config) *time.Duration { return &c.Database.ConnectBackoff }),
+
+	stringOption("log.level", "LOG_LEVEL", "Уровень логирования: debug, info, warn, error", func(c *Config) *string { return &c.Log.Level }),
+	stringOption("log.format", "LOG_FORMAT", "Формат логов: json или console", func(c *Config) *string { return &c.Log.Format }),
+
+	stringOption("song_details.url", "SONG_DETAILS_URL", "Адрес API подробностей о песнях; пусто - без обогащения", func(c *Config) *string { return &c.SongDetails.BaseURL }),
+	durationOption("song_details.timeout", "SONG_DETAILS_TIMEOUT", "Таймаут запроса к API подробностей", func(c *Config) *time.Duration { return &c.SongDetails.Timeout }),
+	intOption("song_details.retries", "SONG_DETAILS_RETRIES", "Количество повторов запроса к API подробностей", func(c *Config) *int { return &c.SongDetails.Retries }),
+
+	{
+		key: "trash.retention_days", env: "TRASH_RETENTION_DAYS", usage: "Срок хранения в корзине в днях, 0 - бессрочно",
+		get: func(c *Config) string { return strconv.Itoa(int(c.Trash.Retention / (24 * time.Hour))) },
+		set: func(c *Config, value string) error {
+			days, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("expected a number of days, got %q", value)
+			}
+			c.Trash.Retention = time.Duration(days) * 24 * time.Hour
+			return nil
+		},
+	},
+	durationOption("trash.sweep_interval", "TRASH_SWEEP_INTERVAL", "Интервал очистки корзины", func(c *Config) *time.Duration { return &c.Trash.SweepInterval }),
+}
+
+// optionByKey находит настройку по ключу файла
+func optionByKey(key string) (option, bool) {
+	for _, opt := range options {
+		if opt.key == key {
+			return opt, true
+		}
+	}
+	return option{}, false
+}
+
+func stringOption(key, env, usage string, field func(c *Config) *string) option {
+	return option{
+		key: key, env: env, usage: usage,
+		get: func(c *Config) string { return *field(c) },
+		set: func(c *Config, value string) error { *field(c) = value; return nil },
+	}
+}
+
+func intOption(key, env, usage string, field func(c *Config) *int) option {
+	return option{
+		key: key, env: env, usage: usage,
+		get: func(c *Config) string { return strconv.Itoa(*field(c)) },
+		set: func(c *Config, value string) error {
+			number, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("expected an integer, got %q", value)
+			}
+			*field(c) = number
+			return nil
+		},
+	}
+}
+
+func boolOption(key, env, usage string, field func(c *Config) *bool) option {
+	return option{
+		key: key, env: env, usage: usage,
+		get: func(c *Config) string { return strconv.FormatBool(*field(c)) },
+		set: func(c *Config, value string) error {
+			enabled, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("expected true or false, got %q", value)
+			}
+			*field(c) = enabled
+			return nil
+		},
+	}
+}
+
+// durationOption принимает длительность Go (1m30s) или целое число секунд, как в прежних переменных окружения
+func durationOption(key, env, usage string, field func(c *Config) *time.Duration) option {
+	return option{
+		key: key, env: env, usage: usage,
+		get: func(c *Config) string { return field(c).String() },
+		set: func(c *Config, value string) error {
+			if seconds, err := strconv.Atoi(value); err == nil {
+				*field(c) = time.Duration(seconds) * time.Second
+				return nil
+			}
+			duration, err := time.ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("expected a duration like 10s or a number of seconds, got %q", value)
+			}
+			*field(c) = duration
+			return nil
+		},
+	}
+}
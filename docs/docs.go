@@ -15,51 +15,24 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/info": {
+        "/albums": {
             "get": {
-                "description": "Returns general information about the API, including title and version.",
-                "consumes": [
-                    "application/json"
-                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "info"
-                ],
-                "summary": "Get API Information",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    }
-                }
-            }
-        },
-        "/songs": {
-            "get": {
-                "description": "Получение списка песен с поддержкой фильтрации и пагинации.",
-                "tags": [
-                    "songs"
+                    "albums"
                 ],
-                "summary": "Получить список песен",
+                "summary": "Получить список релизов",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Поле для фильтрации (song_name, artist_name, release_date)",
-                        "name": "field",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Значение для фильтрации",
-                        "name": "value",
+                        "type": "integer",
+                        "description": "ID основного исполнителя",
+                        "name": "artist_id",
                         "in": "query"
                     },
                     {
+                        "maximum": 100,
                         "type": "integer",
                         "description": "Количество записей на странице",
                         "name": "limit",
@@ -74,13 +47,13 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Успешное получение списка песен",
+                        "description": "Список релизов",
                         "schema": {
-                            "$ref": "#/definitions/models.SongsResponse"
+                            "$ref": "#/definitions/models.AlbumsResponse"
                         }
                     },
                     "400": {
-                        "description": "Неверное поле для фильтрации"
+                        "description": "Некорректный запрос"
                     },
                     "500": {
                         "description": "Ошибка на сервере"
@@ -88,7 +61,7 @@ const docTemplate = `{
                 }
             },
             "post": {
-                "description": "Добавляет новую песню к исполнителю. Если исполнитель не существует, он будет создан.",
+                "description": "Треки задаются номером диска и номером трека; одна песня может входить в несколько релизов.",
                 "consumes": [
                     "application/json"
                 ],
@@ -96,35 +69,29 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "songs"
+                    "albums"
                 ],
-                "summary": "Добавить новую песню",
+                "summary": "Создать релиз",
                 "parameters": [
                     {
-                        "description": "Информация о песне",
-                        "name": "song",
+                        "description": "Релиз",
+                        "name": "album",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.SongInput"
+                            "$ref": "#/definitions/models.AlbumInput"
                         }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "Успешно добавлена новая песня",
+                        "description": "Релиз создан",
                         "schema": {
-                            "$ref": "#/definitions/models.SongDetail"
+                            "$ref": "#/definitions/models.Album"
                         }
                     },
                     "400": {
-                        "description": "Неверный запрос",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
-                    "409": {
-                        "description": "Песня уже существует",
+                        "description": "Неверный запрос или ссылка на несуществующего исполнителя или песню",
                         "schema": {
                             "type": "string"
                         }
@@ -138,195 +105,3461 @@ const docTemplate = `{
                 }
             }
         },
-        "/songs/{songName}": {
+        "/albums/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "albums"
+                ],
+                "summary": "Получить релиз",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID релиза",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Релиз",
+                        "schema": {
+                            "$ref": "#/definitions/models.Album"
+                        }
+                    },
+                    "404": {
+                        "description": "Релиз не найден"
+                    }
+                }
+            },
             "put": {
-                "description": "Обновляет данные существующей песни по имени. Поля, которые не переданы, останутся без изменений.",
-                "summary": "Изменение данных песни",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "albums"
+                ],
+                "summary": "Изменить релиз",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Имя песни для обновления",
-                        "name": "songName",
+                        "type": "integer",
+                        "description": "ID релиза",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Обновленные данные песни. Все поля являются необязательными.",
-                        "name": "body",
+                        "description": "Релиз",
+                        "name": "album",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.SongUpdateResponse"
+                            "$ref": "#/definitions/models.AlbumInput"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Успешное обновление песни",
+                        "description": "Релиз изменён",
                         "schema": {
-                            "$ref": "#/definitions/models.SongUpdateResponse"
+                            "$ref": "#/definitions/models.Album"
                         }
                     },
                     "400": {
-                        "description": "Некорректный запрос"
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "type": "string"
+                        }
                     },
                     "404": {
-                        "description": "Песня не найдена"
-                    },
-                    "500": {
-                        "description": "Ошибка при обновлении песни"
+                        "description": "Релиз не найден",
+                        "schema": {
+                            "type": "string"
+                        }
                     }
                 }
             },
             "delete": {
-                "summary": "Удалить песню",
+                "tags": [
+                    "albums"
+                ],
+                "summary": "Удалить релиз",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Имя песни для удаления",
-                        "name": "songName",
+                        "type": "integer",
+                        "description": "ID релиза",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "204": {
-                        "description": "Успешное удаление"
+                        "description": "Релиз удалён"
                     },
                     "404": {
-                        "description": "Песня не найдена"
-                    },
-                    "500": {
-                        "description": "Ошибка при удалении песни"
+                        "description": "Релиз не найден"
                     }
                 }
             }
         },
-        "/songs/{songName}/lyrics": {
+        "/artists": {
             "get": {
-                "summary": "Получение текста песни с пагинацией по куплетам",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "artists"
+                ],
+                "summary": "Получить список исполнителей",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Имя песни для получения текста",
-                        "name": "songName",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
+                        "maximum": 100,
                         "type": "integer",
-                        "default": 1,
-                        "description": "Номер страницы куплетов",
-                        "name": "verse_page",
+                        "description": "Количество записей на странице",
+                        "name": "limit",
                         "in": "query"
                     },
                     {
                         "type": "integer",
-                        "default": 3,
-                        "description": "Количество куплетов на странице",
-                        "name": "verse_limit",
+                        "description": "Номер страницы",
+                        "name": "page",
                         "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Успешное получение текста песни",
+                        "description": "Список исполнителей",
                         "schema": {
-                            "$ref": "#/definitions/models.PaginatedLyricsRespons"
+                            "$ref": "#/definitions/models.ArtistsResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере"
+                    }
+                }
+            },
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "artists"
+                ],
+                "summary": "Создать исполнителя",
+                "parameters": [
+                    {
+                        "description": "Исполнитель",
+                        "name": "artist",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ArtistInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Исполнитель создан",
+                        "schema": {
+                            "$ref": "#/definitions/models.Artist"
                         }
                     },
                     "400": {
-                        "description": "Некорректный запрос"
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "type": "string"
+                        }
                     },
-                    "404": {
-                        "description": "Песня не найдена"
+                    "409": {
+                        "description": "Исполнитель уже существует",
+                        "schema": {
+                            "type": "string"
+                        }
                     },
                     "500": {
-                        "description": "Ошибка при получении текста песни"
-                    }
-                }
-            }
-        }
-    },
-    "definitions": {
-        "models.PaginatedLyricsRespons": {
-            "type": "object",
-            "properties": {
-                "song_name": {
-                    "description": "Название песни",
-                    "type": "string"
-                },
-                "total_verses": {
-                    "description": "Общее количество куплетов",
-                    "type": "integer"
-                },
-                "verse_limit": {
-                    "description": "Количество куплетов на странице",
-                    "type": "integer"
-                },
-                "verse_page": {
-                    "description": "Номер страницы куплетов",
-                    "type": "integer"
-                },
-                "verses": {
-                    "description": "Пагинированные куплеты",
-                    "type": "array",
-                    "items": {
-                        "type": "string"
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
                     }
                 }
             }
         },
-        "models.SongDetail": {
-            "type": "object",
-            "properties": {
-                "artistID": {
-                    "type": "integer"
-                },
-                "createdAt": {
-                    "type": "string"
-                },
-                "groupName": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "integer"
-                },
-                "releaseDate": {
-                    "type": "string"
-                },
-                "songName": {
-                    "type": "string"
-                },
-                "songURL": {
-                    "description": "Убедитесь, что это поле присутствует",
-                    "type": "string"
-                },
-                "text": {
-                    "type": "string"
+        "/artists/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "artists"
+                ],
+                "summary": "Получить исполнителя",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID исполнителя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Исполнитель",
+                        "schema": {
+                            "$ref": "#/definitions/models.Artist"
+                        }
+                    },
+                    "404": {
+                        "description": "Исполнитель не найден"
+                    }
                 }
-            }
-        },
-        "models.SongInput": {
+            },
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "artists"
+                ],
+                "summary": "Переименовать исполнителя",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID исполнителя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новое имя",
+                        "name": "artist",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ArtistInput"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag исполнителя; если исполнитель с тех пор изменился, возвращается 412",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Исполнитель переименован",
+                        "schema": {
+                            "$ref": "#/definitions/models.Artist"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Исполнитель не найден",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Исполнитель с таким именем уже существует",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "412": {
+                        "description": "Исполнитель изменился после получения ETag",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Исполнитель переносится в корзину. Без cascade=true исполнитель с песнями или релизами не удаляется и возвращается 409.\nС cascade=true его песни переносятся в корзину вместе с ним, а релизы скрываются и возвращаются при восстановлении исполнителя.",
+                "tags": [
+                    "artists"
+                ],
+                "summary": "Удалить исполнителя",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID исполнителя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Удалить вместе с песнями и релизами",
+                        "name": "cascade",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag исполнителя; если исполнитель с тех пор изменился, возвращается 412",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Исполнитель удалён"
+                    },
+                    "404": {
+                        "description": "Исполнитель не найден"
+                    },
+                    "409": {
+                        "description": "У исполнителя есть песни или релизы"
+                    },
+                    "412": {
+                        "description": "Исполнитель изменился после получения ETag"
+                    }
+                }
+            }
+        },
+        "/artists/{id}/songs": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "artists"
+                ],
+                "summary": "Получить песни исполнителя",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID исполнителя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "maximum": 100,
+                        "type": "integer",
+                        "description": "Количество записей на странице",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Номер страницы",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": true,
+                        "description": "Считать общее количество песен",
+                        "name": "count",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор next_cursor из предыдущего ответа; заменяет page",
+                        "name": "after",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Песни исполнителя",
+                        "schema": {
+                            "$ref": "#/definitions/models.SongsResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Исполнитель не найден"
+                    }
+                }
+            }
+        },
+        "/debug/db": {
+            "get": {
+                "description": "Счётчики ожиданий и закрытых соединений растут с момента запуска; по ним видно, хватает ли пулу соединений.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Статистика пула соединений",
+                "responses": {
+                    "200": {
+                        "description": "Состояние пула",
+                        "schema": {
+                            "$ref": "#/definitions/models.DBPoolStats"
+                        }
+                    }
+                }
+            }
+        },
+        "/export": {
+            "get": {
+                "description": "Записи отдаются потоком по мере чтения из базы. В CSV текст песни записывается в столбец text в виде JSON.",
+                "produces": [
+                    "application/json",
+                    "text/plain"
+                ],
+                "tags": [
+                    "catalog"
+                ],
+                "summary": "Выгрузить каталог",
+                "parameters": [
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "ndjson"
+                        ],
+                        "type": "string",
+                        "default": "json",
+                        "description": "Формат выгрузки",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.CatalogRecord"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неизвестный формат",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "Не проверяет зависимости: отвечает 200, пока процесс обслуживает HTTP, в том числе во время остановки.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Проверка живости",
+                "responses": {
+                    "200": {
+                        "description": "Процесс запущен",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/import": {
+            "post": {
+                "description": "Принимает выгрузку GET /export. Названия нормализуются так же, как при добавлении песни.\nНепустые поля записи заменяют поля сохранённой песни. Записи с ошибками пропускаются и попадают в отчёт.\nВсе изменения сохраняются в одной транзакции; при dry_run=true транзакция откатывается.",
+                "consumes": [
+                    "application/json",
+                    "text/plain"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "catalog"
+                ],
+                "summary": "Загрузить каталог",
+                "parameters": [
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "ndjson"
+                        ],
+                        "type": "string",
+                        "description": "Формат; по умолчанию определяется по Content-Type",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Только проверить и вернуть отчёт, ничего не сохраняя",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Записи каталога",
+                        "name": "catalog",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.CatalogRecord"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ImportReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Документ не разобран",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "413": {
+                        "description": "Слишком большой каталог",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "415": {
+                        "description": "Неизвестный формат",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при сохранении",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/info": {
+            "get": {
+                "description": "Returns general information about the API, including title and version.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "info"
+                ],
+                "summary": "Get API Information",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/playlists": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "playlists"
+                ],
+                "summary": "Получить список плейлистов",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Владелец плейлиста",
+                        "name": "owner",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "type": "integer",
+                        "description": "Количество записей на странице",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Номер страницы",
+                        "name": "page",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Список плейлистов",
+                        "schema": {
+                            "$ref": "#/definitions/models.PlaylistsResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере"
+                    }
+                }
+            },
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "playlists"
+                ],
+                "summary": "Создать плейлист",
+                "parameters": [
+                    {
+                        "description": "Плейлист",
+                        "name": "playlist",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PlaylistInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Плейлист создан",
+                        "schema": {
+                            "$ref": "#/definitions/models.Playlist"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/playlists/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "playlists"
+                ],
+                "summary": "Получить плейлист",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID плейлиста",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "maximum": 100,
+                        "type": "integer",
+                        "description": "Количество песен на странице",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Номер страницы",
+                        "name": "page",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Плейлист",
+                        "schema": {
+                            "$ref": "#/definitions/models.PlaylistResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Плейлист не найден"
+                    }
+                }
+            },
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "playlists"
+                ],
+                "summary": "Переименовать плейлист",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID плейлиста",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новое название",
+                        "name": "playlist",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PlaylistInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Плейлист переименован",
+                        "schema": {
+                            "$ref": "#/definitions/models.Playlist"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Плейлист не найден",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "tags": [
+                    "playlists"
+                ],
+                "summary": "Удалить плейлист",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID плейлиста",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Плейлист удалён"
+                    },
+                    "404": {
+                        "description": "Плейлист не найден"
+                    }
+                }
+            }
+        },
+        "/playlists/{id}/songs": {
+            "post": {
+                "description": "Песня вставляется на указанную позицию, последующие песни сдвигаются. Без позиции песня добавляется в конец.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "playlists"
+                ],
+                "summary": "Добавить песню в плейлист",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID плейлиста",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Песня и позиция",
+                        "name": "song",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PlaylistSongInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Песня добавлена",
+                        "schema": {
+                            "$ref": "#/definitions/models.PlaylistItem"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос или песня не существует",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Плейлист не найден",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Песня уже в плейлисте",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/playlists/{id}/songs/{songID}": {
+            "delete": {
+                "tags": [
+                    "playlists"
+                ],
+                "summary": "Убрать песню из плейлиста",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID плейлиста",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID песни",
+                        "name": "songID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Песня убрана"
+                    },
+                    "404": {
+                        "description": "Плейлист не найден или песни в нём нет"
+                    }
+                }
+            }
+        },
+        "/playlists/{id}/songs/{songID}/position": {
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "playlists"
+                ],
+                "summary": "Переместить песню в плейлисте",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID плейлиста",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID песни",
+                        "name": "songID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новая позиция, начиная с 1",
+                        "name": "position",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PlaylistSongInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Песня перемещена",
+                        "schema": {
+                            "$ref": "#/definitions/models.PlaylistItem"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Плейлист не найден или песни в нём нет",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Проверяет доступность базы данных и совпадение версии схемы с миграциями приложения.\nДля каждой проверки возвращается статус и длительность. Во время остановки проверки не выполняются.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Проверка готовности",
+                "responses": {
+                    "200": {
+                        "description": "Приложение готово",
+                        "schema": {
+                            "$ref": "#/definitions/health.Report"
+                        }
+                    },
+                    "503": {
+                        "description": "Проверка не прошла или приложение останавливается",
+                        "schema": {
+                            "$ref": "#/definitions/health.Report"
+                        }
+                    }
+                }
+            }
+        },
+        "/search": {
+            "get": {
+                "description": "Поиск учитывает русскую и английскую морфологию. Для каждой песни возвращается наиболее релевантный куплет с выделенными совпадениями.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Полнотекстовый поиск песен",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Поисковый запрос",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "maximum": 100,
+                        "type": "integer",
+                        "description": "Количество результатов на странице",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Номер страницы",
+                        "name": "page",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Результаты поиска",
+                        "schema": {
+                            "$ref": "#/definitions/models.SearchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Пустой запрос",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs": {
+            "get": {
+                "description": "Получение списка песен с поддержкой фильтрации, сортировки и пагинации. Все фильтры объединяются через AND.",
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Получить список песен",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID исполнителя",
+                        "name": "artist_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Подстрока имени исполнителя",
+                        "name": "artist",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Подстрока названия песни",
+                        "name": "title",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID релиза: без sort песни возвращаются в порядке треклиста",
+                        "name": "album_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Дата релиза не раньше (YYYY-MM-DD)",
+                        "name": "release_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Дата релиза не позже (YYYY-MM-DD)",
+                        "name": "release_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Добавлена не раньше (YYYY-MM-DD или RFC 3339)",
+                        "name": "created_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Добавлена не позже (YYYY-MM-DD включительно или RFC 3339)",
+                        "name": "created_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Только песни с текстом или без текста",
+                        "name": "has_lyrics",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "-release_date,title",
+                        "description": "Сортировка: release_date, title, artist, created_at через запятую; минус - по убыванию",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Устаревший фильтр: поле (song_name, artist_name, release_date)",
+                        "name": "field",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Устаревший фильтр: значение",
+                        "name": "value",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "type": "integer",
+                        "description": "Количество записей на странице",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Номер страницы",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": true,
+                        "description": "Считать общее количество песен",
+                        "name": "count",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор next_cursor из предыдущего ответа; заменяет page",
+                        "name": "after",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешное получение списка песен",
+                        "schema": {
+                            "$ref": "#/definitions/models.SongsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный параметр; в ответе указано его имя",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере"
+                    }
+                }
+            },
+            "post": {
+                "description": "Добавляет новую песню к исполнителю. Если исполнитель не существует, он будет создан.\nДата релиза, текст и ссылка запрашиваются во внешнем API; при его недоступности песня сохраняется со статусом обогащения pending.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Добавить новую песню",
+                "parameters": [
+                    {
+                        "description": "Информация о песне",
+                        "name": "song",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SongInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Успешно добавлена новая песня",
+                        "schema": {
+                            "$ref": "#/definitions/models.SongDetail"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Песня уже существует",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/batch": {
+            "post": {
+                "description": "Исполнители, которых ещё нет, создаются. Результат возвращается для каждой песни.\nПри atomic=true песни добавляются в одной транзакции: если хотя бы одна песня не прошла проверку\nили уже существует, не добавляется ни одна и возвращается 422. Иначе добавляются все корректные песни.\nВнешний API не вызывается, песни сохраняются со статусом обогащения pending.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Добавить пакет песен",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Добавить все песни или ни одной",
+                        "name": "atomic",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Песни; release_date в формате YYYY-MM-DD",
+                        "name": "songs",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.SongInput"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.BatchSongsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "413": {
+                        "description": "Слишком большой пакет",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "Атомарный пакет отклонён",
+                        "schema": {
+                            "$ref": "#/definitions/models.BatchSongsResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/by-name/{songName}": {
+            "get": {
+                "description": "Возвращает песню по ID или по названию. Если названию соответствует несколько песен, возвращается 409 со списком ID.\nНазвание из одних цифр /songs/{songName} принимает за ID, такие песни ищутся через /songs/by-name/{songName}.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Получить песню",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Название песни",
+                        "name": "songName",
+                        "in": "path"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Песня",
+                        "schema": {
+                            "$ref": "#/definitions/models.SongDetail"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена"
+                    },
+                    "409": {
+                        "description": "Название песни неоднозначно",
+                        "schema": {
+                            "$ref": "#/definitions/models.AmbiguousSongResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере"
+                    }
+                }
+            },
+            "put": {
+                "description": "Обновляет данные существующей песни по ID или по имени. Поля, которые не переданы, останутся без изменений.\nНовая версия песни возвращается в заголовке ETag.",
+                "summary": "Изменение данных песни",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Имя песни для обновления",
+                        "name": "songName",
+                        "in": "path"
+                    },
+                    {
+                        "description": "Обновленные данные песни. Все поля являются необязательными.",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SongUpdateResponse"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag песни; если песня с тех пор изменилась, возвращается 412",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешное обновление песни",
+                        "schema": {
+                            "$ref": "#/definitions/models.SongUpdateResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос"
+                    },
+                    "404": {
+                        "description": "Песня не найдена"
+                    },
+                    "409": {
+                        "description": "Название песни неоднозначно или песню одновременно изменил другой запрос",
+                        "schema": {
+                            "$ref": "#/definitions/models.AmbiguousSongResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Песня изменилась после получения ETag"
+                    },
+                    "500": {
+                        "description": "Ошибка при обновлении песни"
+                    }
+                }
+            },
+            "delete": {
+                "description": "Песня переносится в корзину вместе с текстом и убирается из релизов и плейлистов; при восстановлении возвращается на прежние позиции.\nЕё можно восстановить через POST /trash/songs/{id}/restore, пока она не удалена из корзины.",
+                "summary": "Удалить песню",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Имя песни для удаления",
+                        "name": "songName",
+                        "in": "path"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag песни; если песня с тех пор изменилась, возвращается 412",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Успешное удаление"
+                    },
+                    "404": {
+                        "description": "Песня не найдена"
+                    },
+                    "409": {
+                        "description": "Название песни неоднозначно",
+                        "schema": {
+                            "$ref": "#/definitions/models.AmbiguousSongResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Песня изменилась после получения ETag"
+                    },
+                    "500": {
+                        "description": "Ошибка при удалении песни"
+                    }
+                }
+            }
+        },
+        "/songs/by-name/{songName}/lyrics": {
+            "get": {
+                "summary": "Получение текста песни с пагинацией по разделам",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Имя песни для получения текста",
+                        "name": "songName",
+                        "in": "path"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Номер страницы разделов",
+                        "name": "verse_page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 3,
+                        "description": "Количество разделов на странице",
+                        "name": "verse_limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Выписать повторы полностью; без него передаётся число повторов",
+                        "name": "expand",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "sections",
+                            "legacy"
+                        ],
+                        "type": "string",
+                        "default": "sections",
+                        "description": "Формат ответа: разделы со строками или плоский список куплетов",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешное получение текста песни",
+                        "schema": {
+                            "$ref": "#/definitions/models.PaginatedLyricsRespons"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос"
+                    },
+                    "404": {
+                        "description": "Песня не найдена или у неё нет текста"
+                    },
+                    "409": {
+                        "description": "Название песни неоднозначно",
+                        "schema": {
+                            "$ref": "#/definitions/models.AmbiguousSongResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при получении текста песни"
+                    }
+                }
+            }
+        },
+        "/songs/{id}": {
+            "get": {
+                "description": "Возвращает песню по ID или по названию. Если названию соответствует несколько песен, возвращается 409 со списком ID.\nНазвание из одних цифр /songs/{songName} принимает за ID, такие песни ищутся через /songs/by-name/{songName}.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Получить песню",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Песня",
+                        "schema": {
+                            "$ref": "#/definitions/models.SongDetail"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена"
+                    },
+                    "409": {
+                        "description": "Название песни неоднозначно",
+                        "schema": {
+                            "$ref": "#/definitions/models.AmbiguousSongResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере"
+                    }
+                }
+            },
+            "put": {
+                "description": "Обновляет данные существующей песни по ID или по имени. Поля, которые не переданы, останутся без изменений.\nНовая версия песни возвращается в заголовке ETag.",
+                "summary": "Изменение данных песни",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID песни для обновления",
+                        "name": "id",
+                        "in": "path"
+                    },
+                    {
+                        "description": "Обновленные данные песни. Все поля являются необязательными.",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SongUpdateResponse"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag песни; если песня с тех пор изменилась, возвращается 412",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешное обновление песни",
+                        "schema": {
+                            "$ref": "#/definitions/models.SongUpdateResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос"
+                    },
+                    "404": {
+                        "description": "Песня не найдена"
+                    },
+                    "409": {
+                        "description": "Название песни неоднозначно или песню одновременно изменил другой запрос",
+                        "schema": {
+                            "$ref": "#/definitions/models.AmbiguousSongResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Песня изменилась после получения ETag"
+                    },
+                    "500": {
+                        "description": "Ошибка при обновлении песни"
+                    }
+                }
+            },
+            "delete": {
+                "description": "Песня переносится в корзину вместе с текстом и убирается из релизов и плейлистов; при восстановлении возвращается на прежние позиции.\nЕё можно восстановить через POST /trash/songs/{id}/restore, пока она не удалена из корзины.",
+                "summary": "Удалить песню",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID песни для удаления",
+                        "name": "id",
+                        "in": "path"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag песни; если песня с тех пор изменилась, возвращается 412",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Успешное удаление"
+                    },
+                    "404": {
+                        "description": "Песня не найдена"
+                    },
+                    "409": {
+                        "description": "Название песни неоднозначно",
+                        "schema": {
+                            "$ref": "#/definitions/models.AmbiguousSongResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Песня изменилась после получения ETag"
+                    },
+                    "500": {
+                        "description": "Ошибка при удалении песни"
+                    }
+                }
+            },
+            "patch": {
+                "description": "Отсутствующие в документе поля не меняются, null очищает поле, значение заменяет его.\nОчистить можно дату релиза, ссылку и текст; название и исполнитель обязательны. Текст заменяется целиком.\nПустой документ {} ничего не меняет. В ответе - песня целиком, новая версия - в заголовке ETag.",
+                "consumes": [
+                    "application/json",
+                    "application/merge-patch+json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Частично изменить песню",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Изменения песни",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SongPatch"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag песни; если песня с тех пор изменилась, возвращается 412",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Песня после изменения",
+                        "schema": {
+                            "$ref": "#/definitions/models.SongDetail"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный документ"
+                    },
+                    "404": {
+                        "description": "Песня или исполнитель не найдены"
+                    },
+                    "409": {
+                        "description": "У исполнителя уже есть песня с таким названием или песню одновременно изменил другой запрос"
+                    },
+                    "412": {
+                        "description": "Песня изменилась после получения ETag"
+                    },
+                    "415": {
+                        "description": "Неподдерживаемый тип содержимого"
+                    }
+                }
+            }
+        },
+        "/songs/{id}/lyrics": {
+            "get": {
+                "summary": "Получение текста песни с пагинацией по разделам",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID песни для получения текста",
+                        "name": "id",
+                        "in": "path"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Номер страницы разделов",
+                        "name": "verse_page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 3,
+                        "description": "Количество разделов на странице",
+                        "name": "verse_limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Выписать повторы полностью; без него передаётся число повторов",
+                        "name": "expand",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "sections",
+                            "legacy"
+                        ],
+                        "type": "string",
+                        "default": "sections",
+                        "description": "Формат ответа: разделы со строками или плоский список куплетов",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешное получение текста песни",
+                        "schema": {
+                            "$ref": "#/definitions/models.PaginatedLyricsRespons"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос"
+                    },
+                    "404": {
+                        "description": "Песня не найдена или у неё нет текста"
+                    },
+                    "409": {
+                        "description": "Название песни неоднозначно",
+                        "schema": {
+                            "$ref": "#/definitions/models.AmbiguousSongResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при получении текста песни"
+                    }
+                }
+            },
+            "post": {
+                "description": "Принимает текст в формате text/plain. Разделы отделяются пустыми строками или заголовками\nвроде [Припев], [Bridge] или \"Куплет 2:\", по которым определяется тип раздела.\nПометки вида (х3) в конце строки или на отдельной строке сохраняются как число повторов строки или куплета.",
+                "consumes": [
+                    "text/plain"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Загрузить текст песни",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "default": true,
+                        "description": "Сохранять строки внутри разделов; false склеивает их через пробел",
+                        "name": "keep_line_breaks",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Отбрасывать пометки о повторе вместо сохранения числа повторов",
+                        "name": "trim_repeats",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Текст песни",
+                        "name": "lyrics",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Сохранённый текст",
+                        "schema": {
+                            "$ref": "#/definitions/models.SongText"
+                        }
+                    },
+                    "400": {
+                        "description": "Пустой текст или неверные параметры",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "413": {
+                        "description": "Слишком большой текст",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "415": {
+                        "description": "Тело запроса не text/plain",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/lyrics/at": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Строка текста в заданный момент песни",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Момент песни: 83.5s, 1m23.5s, 83.5 (секунды) или 1:23.5",
+                        "name": "position",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 3,
+                        "description": "Количество следующих строк",
+                        "name": "next",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.LyricsAtResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена или у неё нет синхронизированного текста",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/lyrics/lrc": {
+            "get": {
+                "description": "Заголовки [ar:] и [ti:] заполняются из карточки песни, строки без меток времени записываются без них.",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Выгрузить текст песни в формате LRC",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Файл LRC",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена или у неё нет синхронизированного текста",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Принимает файл LRC: заголовки [ar:], [ti:], [offset:] и строки с одной или несколькими метками времени.\nРазделы отделяются пустыми строками, паузами без текста или заголовками вроде [Припев].",
+                "consumes": [
+                    "text/plain"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Загрузить текст песни в формате LRC",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Файл LRC",
+                        "name": "lrc",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Сохранённый текст",
+                        "schema": {
+                            "$ref": "#/definitions/models.SongText"
+                        }
+                    },
+                    "400": {
+                        "description": "Файл не разобран или в нём нет меток времени",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "413": {
+                        "description": "Слишком большой файл",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "415": {
+                        "description": "Тело запроса не text/plain",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/revisions": {
+            "get": {
+                "description": "Каждое изменение названия, исполнителя, даты релиза, ссылки или текста сохраняется отдельной правкой\nс моментом изменения и клиентом из заголовка X-Client-ID. Правки идут от новых к старым.\nУ песен, добавленных до появления истории, она начинается с первого изменения.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "revisions"
+                ],
+                "summary": "Получить историю правок песни",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "maximum": 100,
+                        "type": "integer",
+                        "description": "Количество записей на странице",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Номер страницы",
+                        "name": "page",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "История правок",
+                        "schema": {
+                            "$ref": "#/definitions/models.RevisionsResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена"
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере"
+                    }
+                }
+            }
+        },
+        "/songs/{id}/revisions/diff": {
+            "get": {
+                "description": "Возвращает поля, которые различаются в правках, и сравнение текстов по куплетам.\nПо умолчанию последняя правка сравнивается с предыдущей.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "revisions"
+                ],
+                "summary": "Сравнить правки песни",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Номер старой правки; по умолчанию предыдущая перед to",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Номер новой правки; по умолчанию последняя",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Различия между правками",
+                        "schema": {
+                            "$ref": "#/definitions/models.RevisionDiff"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный номер правки"
+                    },
+                    "404": {
+                        "description": "Песня или правка не найдена"
+                    }
+                }
+            }
+        },
+        "/songs/{id}/revisions/{rev}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "revisions"
+                ],
+                "summary": "Получить правку песни",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Номер правки",
+                        "name": "rev",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Правка песни",
+                        "schema": {
+                            "$ref": "#/definitions/models.SongRevisionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный номер правки"
+                    },
+                    "404": {
+                        "description": "Песня или правка не найдена"
+                    }
+                }
+            }
+        },
+        "/songs/{id}/revisions/{rev}/revert": {
+            "post": {
+                "description": "Название, исполнитель, дата релиза, ссылка и текст берутся из правки, а откат сохраняется новой правкой.\nЕсли исполнителя правки уже нет, он создаётся заново по имени.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "revisions"
+                ],
+                "summary": "Откатить песню к правке",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Номер правки",
+                        "name": "rev",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Песня после отката",
+                        "schema": {
+                            "$ref": "#/definitions/models.SongDetail"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный номер правки"
+                    },
+                    "404": {
+                        "description": "Песня или правка не найдена"
+                    },
+                    "409": {
+                        "description": "У исполнителя уже есть песня с таким названием"
+                    }
+                }
+            }
+        },
+        "/songs/{songName}": {
+            "get": {
+                "description": "Возвращает песню по ID или по названию. Если названию соответствует несколько песен, возвращается 409 со списком ID.\nНазвание из одних цифр /songs/{songName} принимает за ID, такие песни ищутся через /songs/by-name/{songName}.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Получить песню",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Название песни",
+                        "name": "songName",
+                        "in": "path"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Песня",
+                        "schema": {
+                            "$ref": "#/definitions/models.SongDetail"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена"
+                    },
+                    "409": {
+                        "description": "Название песни неоднозначно",
+                        "schema": {
+                            "$ref": "#/definitions/models.AmbiguousSongResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере"
+                    }
+                }
+            },
+            "put": {
+                "description": "Обновляет данные существующей песни по ID или по имени. Поля, которые не переданы, останутся без изменений.\nНовая версия песни возвращается в заголовке ETag.",
+                "summary": "Изменение данных песни",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Имя песни для обновления",
+                        "name": "songName",
+                        "in": "path"
+                    },
+                    {
+                        "description": "Обновленные данные песни. Все поля являются необязательными.",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SongUpdateResponse"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag песни; если песня с тех пор изменилась, возвращается 412",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешное обновление песни",
+                        "schema": {
+                            "$ref": "#/definitions/models.SongUpdateResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос"
+                    },
+                    "404": {
+                        "description": "Песня не найдена"
+                    },
+                    "409": {
+                        "description": "Название песни неоднозначно или песню одновременно изменил другой запрос",
+                        "schema": {
+                            "$ref": "#/definitions/models.AmbiguousSongResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Песня изменилась после получения ETag"
+                    },
+                    "500": {
+                        "description": "Ошибка при обновлении песни"
+                    }
+                }
+            },
+            "delete": {
+                "description": "Песня переносится в корзину вместе с текстом и убирается из релизов и плейлистов; при восстановлении возвращается на прежние позиции.\nЕё можно восстановить через POST /trash/songs/{id}/restore, пока она не удалена из корзины.",
+                "summary": "Удалить песню",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Имя песни для удаления",
+                        "name": "songName",
+                        "in": "path"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag песни; если песня с тех пор изменилась, возвращается 412",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Успешное удаление"
+                    },
+                    "404": {
+                        "description": "Песня не найдена"
+                    },
+                    "409": {
+                        "description": "Название песни неоднозначно",
+                        "schema": {
+                            "$ref": "#/definitions/models.AmbiguousSongResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Песня изменилась после получения ETag"
+                    },
+                    "500": {
+                        "description": "Ошибка при удалении песни"
+                    }
+                }
+            }
+        },
+        "/songs/{songName}/lyrics": {
+            "get": {
+                "summary": "Получение текста песни с пагинацией по разделам",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Имя песни для получения текста",
+                        "name": "songName",
+                        "in": "path"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Номер страницы разделов",
+                        "name": "verse_page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 3,
+                        "description": "Количество разделов на странице",
+                        "name": "verse_limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Выписать повторы полностью; без него передаётся число повторов",
+                        "name": "expand",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "sections",
+                            "legacy"
+                        ],
+                        "type": "string",
+                        "default": "sections",
+                        "description": "Формат ответа: разделы со строками или плоский список куплетов",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешное получение текста песни",
+                        "schema": {
+                            "$ref": "#/definitions/models.PaginatedLyricsRespons"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос"
+                    },
+                    "404": {
+                        "description": "Песня не найдена или у неё нет текста"
+                    },
+                    "409": {
+                        "description": "Название песни неоднозначно",
+                        "schema": {
+                            "$ref": "#/definitions/models.AmbiguousSongResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при получении текста песни"
+                    }
+                }
+            }
+        },
+        "/trash": {
+            "get": {
+                "description": "Удалённые песни и исполнители от недавно удалённых к давним.\nЗаписи, пролежавшие в корзине дольше срока хранения, удаляются навсегда автоматически.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "Получить содержимое корзины",
+                "parameters": [
+                    {
+                        "enum": [
+                            "song",
+                            "artist"
+                        ],
+                        "type": "string",
+                        "description": "Только песни или только исполнители",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "type": "integer",
+                        "description": "Количество записей на странице",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Номер страницы",
+                        "name": "page",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Содержимое корзины",
+                        "schema": {
+                            "$ref": "#/definitions/models.TrashResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неизвестный тип"
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере"
+                    }
+                }
+            },
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "Очистить корзину",
+                "responses": {
+                    "200": {
+                        "description": "Количество удалённых записей",
+                        "schema": {
+                            "$ref": "#/definitions/models.TrashPurgeResult"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере"
+                    }
+                }
+            }
+        },
+        "/trash/artists/{id}": {
+            "delete": {
+                "description": "Вместе с исполнителем навсегда удаляются все его песни из корзины.",
+                "tags": [
+                    "trash"
+                ],
+                "summary": "Удалить исполнителя из корзины",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID исполнителя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Исполнитель удалён навсегда"
+                    },
+                    "404": {
+                        "description": "Исполнителя нет в корзине"
+                    }
+                }
+            }
+        },
+        "/trash/artists/{id}/restore": {
+            "post": {
+                "description": "Вместе с исполнителем восстанавливаются песни, удалённые вместе с ним. Песни, удалённые раньше по отдельности, остаются в корзине.\nРелизы исполнителя возвращаются вместе с ним.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "Восстановить исполнителя",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID исполнителя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Восстановленный исполнитель",
+                        "schema": {
+                            "$ref": "#/definitions/models.Artist"
+                        }
+                    },
+                    "404": {
+                        "description": "Исполнителя нет в корзине"
+                    },
+                    "409": {
+                        "description": "Исполнитель с таким именем уже есть"
+                    }
+                }
+            }
+        },
+        "/trash/songs/{id}": {
+            "delete": {
+                "tags": [
+                    "trash"
+                ],
+                "summary": "Удалить песню из корзины",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Песня удалена навсегда"
+                    },
+                    "404": {
+                        "description": "Песни нет в корзине"
+                    }
+                }
+            }
+        },
+        "/trash/songs/{id}/restore": {
+            "post": {
+                "description": "Если исполнитель песни тоже в корзине, он восстанавливается вместе с ней.\nПесня возвращается в релизы и плейлисты на прежние позиции; занятый номер трека заменяется следующим свободным на диске.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "Восстановить песню",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Восстановленная песня",
+                        "schema": {
+                            "$ref": "#/definitions/models.SongDetail"
+                        }
+                    },
+                    "404": {
+                        "description": "Песни нет в корзине"
+                    },
+                    "409": {
+                        "description": "Песня с таким названием или исполнитель с таким именем уже есть"
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "health.CheckResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "description": "Причина неудачи",
+                    "type": "string"
+                },
+                "latency_ms": {
+                    "description": "Сколько длилась проверка, в миллисекундах",
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "health.Report": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/health.CheckResult"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "lyrics.Line": {
+            "type": "object",
+            "properties": {
+                "repeat": {
+                    "type": "integer"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "times_ms": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "lyrics.Section": {
+            "type": "object",
+            "properties": {
+                "label": {
+                    "type": "string"
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/lyrics.Line"
+                    }
+                },
+                "repeat": {
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "verse",
+                        "chorus",
+                        "bridge",
+                        "intro",
+                        "outro"
+                    ]
+                }
+            }
+        },
+        "lyrics.TimedLine": {
+            "type": "object",
+            "properties": {
+                "section": {
+                    "description": "Номер раздела, начиная с 0",
+                    "type": "integer"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "time_ms": {
+                    "description": "Момент начала в миллисекундах",
+                    "type": "integer"
+                }
+            }
+        },
+        "lyrics.VerseChange": {
+            "type": "object",
+            "properties": {
+                "new_index": {
+                    "type": "integer"
+                },
+                "old_index": {
+                    "type": "integer"
+                },
+                "op": {
+                    "type": "string",
+                    "enum": [
+                        "equal",
+                        "insert",
+                        "delete"
+                    ]
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Album": {
+            "type": "object",
+            "properties": {
+                "artist_id": {
+                    "description": "Основной исполнитель",
+                    "type": "integer"
+                },
+                "created_at": {
+                    "description": "Дата создания записи",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "Уникальный идентификатор релиза",
+                    "type": "integer"
+                },
+                "release_date": {
+                    "description": "Дата выхода",
+                    "type": "string"
+                },
+                "title": {
+                    "description": "Название релиза",
+                    "type": "string"
+                },
+                "tracks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AlbumTrack"
+                    }
+                },
+                "type": {
+                    "description": "album, single, ep или compilation",
+                    "type": "string"
+                }
+            }
+        },
+        "models.AlbumInput": {
+            "type": "object",
+            "properties": {
+                "artist_id": {
+                    "type": "integer"
+                },
+                "release_date": {
+                    "description": "Дата в формате 2006-01-02",
+                    "type": "string",
+                    "example": "2006-07-03"
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Black Holes and Revelations"
+                },
+                "tracks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AlbumTrackInput"
+                    }
+                },
+                "type": {
+                    "type": "string",
+                    "example": "album"
+                }
+            }
+        },
+        "models.AlbumTrack": {
+            "type": "object",
+            "properties": {
+                "disc_number": {
+                    "description": "Номер диска, начиная с 1",
+                    "type": "integer"
+                },
+                "song_id": {
+                    "type": "integer"
+                },
+                "track_number": {
+                    "description": "Номер трека на диске, начиная с 1",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.AlbumTrackInput": {
+            "type": "object",
+            "properties": {
+                "disc_number": {
+                    "description": "По умолчанию 1",
+                    "type": "integer"
+                },
+                "song_id": {
+                    "type": "integer"
+                },
+                "track_number": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.AlbumsResponse": {
+            "type": "object",
+            "properties": {
+                "albums": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Album"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "total_items": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.AmbiguousSongResponse": {
+            "type": "object",
+            "properties": {
+                "candidate_ids": {
+                    "description": "ID песен с таким названием",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "error": {
+                    "type": "string"
+                },
+                "song_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Artist": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "Дата создания записи",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "Уникальный идентификатор исполнителя",
+                    "type": "integer"
+                },
+                "name": {
+                    "description": "Имя исполнителя, уникальное среди не удалённых",
+                    "type": "string"
+                },
+                "version": {
+                    "description": "Растёт при каждом изменении; отдаётся в ETag",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ArtistInput": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "Исполнитель"
+                }
+            }
+        },
+        "models.ArtistsResponse": {
+            "type": "object",
+            "properties": {
+                "artists": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Artist"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "total_items": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.BatchSongResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "description": "Причина для conflict и invalid",
+                    "type": "string"
+                },
+                "index": {
+                    "description": "Номер песни в запросе, начиная с 0",
+                    "type": "integer"
+                },
+                "song": {
+                    "description": "Добавленная песня",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.SongDetail"
+                        }
+                    ]
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "created",
+                        "conflict",
+                        "invalid",
+                        "aborted"
+                    ]
+                }
+            }
+        },
+        "models.BatchSongsResponse": {
+            "type": "object",
+            "properties": {
+                "atomic": {
+                    "type": "boolean"
+                },
+                "conflicts": {
+                    "type": "integer"
+                },
+                "created": {
+                    "type": "integer"
+                },
+                "invalid": {
+                    "type": "integer"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.BatchSongResult"
+                    }
+                }
+            }
+        },
+        "models.CatalogRecord": {
+            "type": "object",
+            "properties": {
+                "artist": {
+                    "type": "string",
+                    "example": "Любэ"
+                },
+                "link": {
+                    "type": "string",
+                    "example": "http://example.com"
+                },
+                "release_date": {
+                    "description": "Дата релиза в формате YYYY-MM-DD",
+                    "type": "string",
+                    "format": "date",
+                    "example": "1994-01-01"
+                },
+                "song": {
+                    "type": "string",
+                    "example": "Конь"
+                },
+                "text": {
+                    "$ref": "#/definitions/models.SongText"
+                }
+            }
+        },
+        "models.DBPoolStats": {
+            "type": "object",
+            "properties": {
+                "idle": {
+                    "type": "integer"
+                },
+                "in_use": {
+                    "type": "integer"
+                },
+                "max_idle_closed": {
+                    "description": "Закрыто из-за ограничения простаивающих соединений",
+                    "type": "integer"
+                },
+                "max_idle_time_closed": {
+                    "description": "Закрыто из-за времени простоя",
+                    "type": "integer"
+                },
+                "max_lifetime_closed": {
+                    "description": "Закрыто из-за времени жизни",
+                    "type": "integer"
+                },
+                "max_open_connections": {
+                    "description": "Ограничение пула; 0 - без ограничения",
+                    "type": "integer"
+                },
+                "open_connections": {
+                    "description": "Открыто сейчас, занятых и простаивающих",
+                    "type": "integer"
+                },
+                "wait_count": {
+                    "description": "Сколько раз запрос ждал свободного соединения",
+                    "type": "integer"
+                },
+                "wait_duration_ms": {
+                    "description": "Сколько всего запросы ждали соединения",
+                    "type": "number"
+                }
+            }
+        },
+        "models.FieldChange": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string",
+                    "enum": [
+                        "song_name",
+                        "artist",
+                        "release_date",
+                        "song_url"
+                    ]
+                },
+                "from": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ImportReport": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "integer"
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "rows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ImportRowResult"
+                    }
+                },
+                "skipped": {
+                    "type": "integer"
+                },
+                "updated": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ImportRowResult": {
+            "type": "object",
+            "properties": {
+                "artist": {
+                    "type": "string"
+                },
+                "error": {
+                    "description": "Причина для failed",
+                    "type": "string"
+                },
+                "row": {
+                    "description": "Номер записи, начиная с 1",
+                    "type": "integer"
+                },
+                "song": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "created",
+                        "updated",
+                        "skipped",
+                        "failed"
+                    ]
+                }
+            }
+        },
+        "models.LyricsAtResponse": {
+            "type": "object",
+            "properties": {
+                "current": {
+                    "description": "null, если первая строка ещё не началась",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/lyrics.TimedLine"
+                        }
+                    ]
+                },
+                "next": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/lyrics.TimedLine"
+                    }
+                },
+                "position_ms": {
+                    "description": "Запрошенный момент в миллисекундах",
+                    "type": "integer"
+                },
+                "song_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.PaginatedLyricsRespons": {
+            "type": "object",
+            "properties": {
+                "expanded": {
+                    "description": "Повторы выписаны полностью",
+                    "type": "boolean"
+                },
+                "format": {
+                    "type": "string",
+                    "enum": [
+                        "sections",
+                        "legacy"
+                    ]
+                },
+                "sections": {
+                    "description": "Разделы страницы; заполняется в формате sections",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/lyrics.Section"
+                    }
+                },
+                "song_name": {
+                    "description": "Название песни",
+                    "type": "string"
+                },
+                "total_verses": {
+                    "description": "Общее количество разделов",
+                    "type": "integer"
+                },
+                "verse_limit": {
+                    "description": "Количество разделов на странице",
+                    "type": "integer"
+                },
+                "verse_page": {
+                    "description": "Номер страницы разделов",
+                    "type": "integer"
+                },
+                "verses": {
+                    "description": "Куплеты страницы в плоском формате; заполняется в формате legacy",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.Playlist": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "Дата создания записи",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "owner": {
+                    "description": "Владелец плейлиста",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "description": "Дата последнего изменения, в том числе состава",
+                    "type": "string"
+                }
+            }
+        },
+        "models.PlaylistEntry": {
+            "type": "object",
+            "properties": {
+                "added_at": {
+                    "type": "string"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "song": {
+                    "$ref": "#/definitions/models.SongDetail"
+                }
+            }
+        },
+        "models.PlaylistInput": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "Дорожный"
+                },
+                "owner": {
+                    "type": "string",
+                    "example": "user-1"
+                }
+            }
+        },
+        "models.PlaylistItem": {
+            "type": "object",
+            "properties": {
+                "added_at": {
+                    "type": "string"
+                },
+                "playlist_id": {
+                    "type": "integer"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "song_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.PlaylistResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "Дата создания записи",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PlaylistEntry"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "owner": {
+                    "description": "Владелец плейлиста",
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "total_items": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "description": "Дата последнего изменения, в том числе состава",
+                    "type": "string"
+                }
+            }
+        },
+        "models.PlaylistSongInput": {
+            "type": "object",
+            "properties": {
+                "position": {
+                    "description": "Начиная с 1; 0 - в конец плейлиста",
+                    "type": "integer"
+                },
+                "song_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.PlaylistsResponse": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "integer"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "playlists": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Playlist"
+                    }
+                },
+                "total_items": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.RevisionDiff": {
+            "type": "object",
+            "properties": {
+                "fields": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FieldChange"
+                    }
+                },
+                "from": {
+                    "type": "integer"
+                },
+                "lyrics": {
+                    "description": "Куплеты обеих правок по порядку; пусто, если текст не менялся",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/lyrics.VerseChange"
+                    }
+                },
+                "song_id": {
+                    "type": "integer"
+                },
+                "to": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.RevisionsResponse": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "integer"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "revisions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SongRevision"
+                    }
+                },
+                "total_items": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.SearchHit": {
+            "type": "object",
+            "properties": {
+                "rank": {
+                    "description": "Релевантность; совпадение в названии весит больше, чем в тексте",
+                    "type": "number"
+                },
+                "snippet": {
+                    "description": "Фрагмент куплета с совпадениями, выделенными \u003cmark\u003e",
+                    "type": "string"
+                },
+                "song": {
+                    "$ref": "#/definitions/models.SongDetail"
+                },
+                "verse_index": {
+                    "description": "Наиболее релевантный куплет, начиная с 0; null, если совпало только название или исполнитель",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.SearchResponse": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "integer"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "query": {
+                    "type": "string"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SearchHit"
+                    }
+                },
+                "total_items": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.SongDetail": {
+            "type": "object",
+            "properties": {
+                "artistID": {
+                    "type": "integer"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "enrichmentStatus": {
+                    "description": "Статус обогащения из внешнего API",
+                    "type": "string"
+                },
+                "groupName": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "releaseDate": {
+                    "description": "Дата релиза; nil, если неизвестна",
+                    "type": "string"
+                },
+                "songName": {
+                    "type": "string"
+                },
+                "songURL": {
+                    "description": "Убедитесь, что это поле присутствует",
+                    "type": "string"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "version": {
+                    "description": "Растёт при каждом изменении; отдаётся в ETag",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.SongInput": {
             "type": "object",
-            "required": [
-                "group",
-                "song"
-            ],
             "properties": {
                 "group": {
                     "type": "string"
                 },
+                "release_date": {
+                    "description": "Дата релиза в формате строки",
+                    "type": "string"
+                },
                 "song": {
                     "type": "string"
                 }
             }
         },
+        "models.SongPatch": {
+            "type": "object",
+            "properties": {
+                "artist_name": {
+                    "type": "string",
+                    "example": "Исполнитель"
+                },
+                "group_link": {
+                    "type": "string",
+                    "example": "http://example.com"
+                },
+                "release_date": {
+                    "description": "null очищает дату релиза",
+                    "type": "string",
+                    "example": "1985.02.05"
+                },
+                "song_name": {
+                    "type": "string",
+                    "example": "Название песни"
+                },
+                "text": {
+                    "description": "null удаляет текст",
+                    "type": "object"
+                }
+            }
+        },
+        "models.SongRevision": {
+            "type": "object",
+            "properties": {
+                "artist_id": {
+                    "type": "integer"
+                },
+                "client_id": {
+                    "description": "Клиент из заголовка X-Client-ID; пусто, если клиент не представился",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "group_name": {
+                    "type": "string"
+                },
+                "number": {
+                    "type": "integer"
+                },
+                "release_date": {
+                    "type": "string"
+                },
+                "song_id": {
+                    "type": "integer"
+                },
+                "song_name": {
+                    "type": "string"
+                },
+                "song_url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.SongRevisionResponse": {
+            "type": "object",
+            "properties": {
+                "artist_id": {
+                    "type": "integer"
+                },
+                "client_id": {
+                    "description": "Клиент из заголовка X-Client-ID; пусто, если клиент не представился",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "group_name": {
+                    "type": "string"
+                },
+                "number": {
+                    "type": "integer"
+                },
+                "release_date": {
+                    "type": "string"
+                },
+                "song_id": {
+                    "type": "integer"
+                },
+                "song_name": {
+                    "type": "string"
+                },
+                "song_url": {
+                    "type": "string"
+                },
+                "text": {
+                    "$ref": "#/definitions/models.SongText"
+                }
+            }
+        },
         "models.SongText": {
             "type": "object",
             "properties": {
+                "sections": {
+                    "description": "Разделы по строкам с числом повторов",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/lyrics.Section"
+                    }
+                },
                 "verses": {
-                    "description": "Срез для хранения куплетов",
+                    "description": "Плоский формат: куплет на строку, без пометок о повторе",
                     "type": "array",
                     "items": {
                         "type": "string"
@@ -335,47 +3568,46 @@ const docTemplate = `{
             }
         },
         "models.SongUpdateResponse": {
-            "description": "Структура ответа после обновления песни",
             "type": "object",
             "properties": {
                 "artist_name": {
-                    "description": "Имя исполнителя",
                     "type": "string",
                     "example": "Исполнитель"
                 },
                 "group_link": {
-                    "description": "Ссылка на группу",
                     "type": "string",
                     "example": "http://example.com"
                 },
                 "release_date": {
-                    "description": "Укажите формат даты в Swagger",
+                    "description": "Изменено на time.Time",
                     "type": "string",
                     "format": "date",
-                    "example": "1985.02.05"
+                    "example": "1985-02-05"
                 },
                 "song_name": {
-                    "description": "Название песни",
                     "type": "string",
                     "example": "Название песни"
                 },
                 "text": {
-                    "description": "Текст песни",
-                    "allOf": [
-                        {
-                            "$ref": "#/definitions/models.SongText"
-                        }
-                    ]
+                    "$ref": "#/definitions/models.SongText"
                 }
             }
         },
         "models.SongsResponse": {
             "type": "object",
             "properties": {
+                "has_next": {
+                    "type": "boolean"
+                },
                 "limit": {
                     "type": "integer"
                 },
+                "next_cursor": {
+                    "description": "Значение параметра after для следующей страницы",
+                    "type": "string"
+                },
                 "page": {
+                    "description": "Не заполняется в режиме курсора",
                     "type": "integer"
                 },
                 "songs": {
@@ -384,6 +3616,71 @@ const docTemplate = `{
                         "$ref": "#/definitions/models.SongDetail"
                     }
                 },
+                "total_items": {
+                    "description": "Не заполняется при count=false",
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "description": "Не заполняется при count=false",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.TrashItem": {
+            "type": "object",
+            "properties": {
+                "artist": {
+                    "type": "string"
+                },
+                "artist_id": {
+                    "description": "Исполнитель песни",
+                    "type": "integer"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "description": "Название песни или имя исполнителя",
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "song",
+                        "artist"
+                    ]
+                }
+            }
+        },
+        "models.TrashPurgeResult": {
+            "type": "object",
+            "properties": {
+                "artists": {
+                    "type": "integer"
+                },
+                "songs": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.TrashResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TrashItem"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "page": {
+                    "type": "integer"
+                },
                 "total_items": {
                     "type": "integer"
                 }
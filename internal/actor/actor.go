@@ -0,0 +1,43 @@
+// Package actor передаёт через контекст запроса клиента, от имени которого вносятся изменения
+package actor
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// Header - заголовок, в котором клиент передаёт свой идентификатор
+const Header = "X-Client-ID"
+
+// maxClientIDLength - длина столбца client_id в истории правок
+const maxClientIDLength = 255
+
+type contextKey struct{}
+
+// ToContext возвращает контекст с идентификатором клиента
+func ToContext(ctx context.Context, clientID string) context.Context {
+	return context.WithValue(ctx, contextKey{}, clientID)
+}
+
+// FromContext возвращает идентификатор клиента; пустая строка означает, что клиент не представился
+func FromContext(ctx context.Context) string {
+	clientID, _ := ctx.Value(contextKey{}).(string)
+	return clientID
+}
+
+// Middleware кладёт в контекст запроса идентификатор клиента из заголовка X-Client-ID
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clientID := strings.TrimSpace(r.Header.Get(Header))
+		if utf8.RuneCountInString(clientID) > maxClientIDLength {
+			http.Error(w, "Bad Request: "+Header+" is too long", http.StatusBadRequest)
+			return
+		}
+		if clientID != "" {
+			r = r.WithContext(ToContext(r.Context(), clientID))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,46 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Backoff повторяет действие с экспоненциально растущей паузой
+type Backoff struct {
+	Initial time.Duration // Пауза перед первым повтором
+	Max     time.Duration // Наибольшая пауза; 0 - без ограничения
+	MaxWait time.Duration // Сколько всего ждать с начала первой попытки; 0 - одна попытка
+}
+
+// Retry вызывает fn с номером попытки начиная с 1, пока она не вернёт nil. Если следующая пауза
+// закончилась бы позже MaxWait, возвращается последняя ошибка. Отмена ctx прерывает ожидание.
+func (b Backoff) Retry(ctx context.Context, fn func(attempt int) error) error {
+	deadline := time.Now().Add(b.MaxWait)
+	delay := b.Initial
+	for attempt := 1; ; attempt++ {
+		err := fn(attempt)
+		if err == nil {
+			return nil
+		}
+		if b.MaxWait <= 0 {
+			return err
+		}
+		if time.Now().Add(delay).After(deadline) {
+			return fmt.Errorf("gave up after %d attempts in %s: %w", attempt, b.MaxWait, err)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w (last error: %v)", ctx.Err(), err)
+		case <-timer.C:
+		}
+
+		delay *= 2
+		if b.Max > 0 && delay > b.Max {
+			delay = b.Max
+		}
+	}
+}
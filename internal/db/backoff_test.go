@@ -0,0 +1,59 @@
+package db_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"music/internal/db"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackoff_Retry(t *testing.T) {
+	backoff := db.Backoff{Initial: time.Millisecond, Max: 4 * time.Millisecond, MaxWait: time.Second}
+
+	var calls []time.Time
+	err := backoff.Retry(context.Background(), func(attempt int) error {
+		calls = append(calls, time.Now())
+		if attempt < 5 {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, calls, 5)
+
+	// Паузы растут вдвое: 1, 2, 4 и снова 4 мс из-за ограничения Max
+	for i, want := range []time.Duration{time.Millisecond, 2 * time.Millisecond, 4 * time.Millisecond, 4 * time.Millisecond} {
+		assert.GreaterOrEqual(t, calls[i+1].Sub(calls[i]), want, "pause before attempt %d", i+2)
+	}
+}
+
+func TestBackoff_GivesUp(t *testing.T) {
+	refused := errors.New("connection refused")
+
+	attempts := 0
+	err := db.Backoff{Initial: 10 * time.Millisecond, MaxWait: 60 * time.Millisecond}.Retry(context.Background(), func(int) error {
+		attempts++
+		return refused
+	})
+	require.ErrorIs(t, err, refused)
+	// Паузы 10, 20 мс укладываются в 60 мс, а пауза 40 мс уже нет
+	assert.Equal(t, 3, attempts)
+
+	attempts = 0
+	err = db.Backoff{Initial: time.Millisecond}.Retry(context.Background(), func(int) error {
+		attempts++
+		return refused
+	})
+	assert.Equal(t, refused, err, "without MaxWait the first error is returned as is")
+	assert.Equal(t, 1, attempts)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = db.Backoff{Initial: time.Hour, MaxWait: 2 * time.Hour}.Retry(ctx, func(int) error { return refused })
+	assert.ErrorIs(t, err, context.Canceled)
+}
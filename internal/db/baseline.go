@@ -0,0 +1,186 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"music/pkg/logger"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/database"
+)
+
+// ErrBaselineMismatch возвращается, если схему базы нельзя считать созданной первой миграцией
+var ErrBaselineMismatch = errors.New("database schema does not match the initial migration")
+
+// baselineColumns - таблицы и колонки первой миграции. Их же создавал AutoMigrate до перехода на миграции.
+var baselineColumns = map[string][]string{
+	"artists":      {"id", "name", "created_at"},
+	"song_details": {"id", "artist_id", "group_name", "song_name", "release_date", "text", "song_url", "created_at"},
+}
+
+// laterObjects - таблицы и колонки, которые добавляют следующие миграции. Если они уже есть,
+// запись первой версии приведёт к повторному созданию, поэтому такую базу принять нельзя.
+var laterObjects = map[string][]string{
+	"artists":                {"deleted_at", "version"},
+	"song_details":           {"enrichment_status", "deleted_at", "version"},
+	"albums":                 nil,
+	"album_tracks":           nil,
+	"playlists":              nil,
+	"playlist_items":         nil,
+	"song_verses":            nil,
+	"song_revisions":         nil,
+	"trashed_album_tracks":   nil,
+	"trashed_playlist_items": nil,
+}
+
+// baselineStatements приводят схему AutoMigrate к первой миграции в том, на что опираются следующие:
+// ограничения уникальности получают имена Postgres по умолчанию, появляются внешний ключ и индексы.
+// Дубликаты или песни без исполнителя остановят baseline с ошибкой Postgres.
+var baselineStatements = []string{
+	`DO $$ BEGIN
+		IF EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'uni_artists_name' AND conrelid = 'artists'::regclass) THEN
+			ALTER TABLE artists RENAME CONSTRAINT uni_artists_name TO artists_name_key;
+		ELSIF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'artists_name_key' AND conrelid = 'artists'::regclass) THEN
+			ALTER TABLE artists ADD CONSTRAINT artists_name_key UNIQUE (name);
+		END IF;
+	END $$`,
+	`DO $$ BEGIN
+		IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'song_details_song_name_artist_id_key' AND conrelid = 'song_details'::regclass) THEN
+			ALTER TABLE song_details ADD CONSTRAINT song_details_song_name_artist_id_key UNIQUE (song_name, artist_id);
+		END IF;
+		IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conrelid = 'song_details'::regclass AND contype = 'f') THEN
+			ALTER TABLE song_details ADD CONSTRAINT song_details_artist_id_fkey
+				FOREIGN KEY (artist_id) REFERENCES artists(id) ON DELETE CASCADE;
+		END IF;
+	END $$`,
+	`CREATE INDEX IF NOT EXISTS idx_song_details_artist_id ON song_details (artist_id)`,
+	`CREATE INDEX IF NOT EXISTS idx_song_details_release_date ON song_details (release_date)`,
+}
+
+// Baseline принимает базу, созданную AutoMigrate до перехода на миграции: проверяет, что схема совпадает
+// с первой миграцией, приводит к ней ограничения и индексы и записывает её версию в таблицу goose.
+// Следующий запуск сервера или migrate up применит остальные миграции. Возвращает записанную версию.
+func (m *Migrator) Baseline(ctx context.Context) (int64, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	// Та же блокировка, что у goose: baseline не пересечётся с миграциями другого экземпляра
+	if err := m.locker.SessionLock(ctx, conn); err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err := m.locker.SessionUnlock(context.WithoutCancel(ctx), conn); err != nil {
+			logger.Error(ctx, "Failed to release migration lock", err)
+		}
+	}()
+
+	var hasVersions bool
+	if err := conn.QueryRowContext(ctx, `SELECT to_regclass($1) IS NOT NULL`, goose.DefaultTablename).Scan(&hasVersions); err != nil {
+		return 0, err
+	}
+	if hasVersions {
+		return 0, fmt.Errorf("%w: %s already exists", ErrBaselineMismatch, goose.DefaultTablename)
+	}
+	columns, err := tableColumns(ctx, conn)
+	if err != nil {
+		return 0, err
+	}
+	if err := CheckBaselineSchema(columns); err != nil {
+		return 0, err
+	}
+
+	version := m.Sources()[0].Version
+	store, err := database.NewStore(database.DialectPostgres, goose.DefaultTablename)
+	if err != nil {
+		return 0, err
+	}
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	for _, statement := range baselineStatements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return 0, fmt.Errorf("adopt existing schema: %w", err)
+		}
+	}
+	// Как и goose при создании таблицы версий, сначала записываем нулевую версию
+	if err := store.CreateVersionTable(ctx, tx); err != nil {
+		return 0, err
+	}
+	for _, v := range []int64{0, version} {
+		if err := store.Insert(ctx, tx, database.InsertRequest{Version: v}); err != nil {
+			return 0, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	logger.InfoKV(ctx, "Existing schema adopted", "version", version)
+	return version, nil
+}
+
+// tableColumns возвращает колонки всех таблиц текущей схемы
+func tableColumns(ctx context.Context, conn *sql.Conn) (map[string]map[string]bool, error) {
+	rows, err := conn.QueryContext(ctx,
+		`SELECT table_name, column_name FROM information_schema.columns WHERE table_schema = current_schema()`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := make(map[string]map[string]bool)
+	for rows.Next() {
+		var table, column string
+		if err := rows.Scan(&table, &column); err != nil {
+			return nil, err
+		}
+		if columns[table] == nil {
+			columns[table] = make(map[string]bool)
+		}
+		columns[table][column] = true
+	}
+	return columns, rows.Err()
+}
+
+// CheckBaselineSchema сверяет колонки базы (таблица -> колонки) с первой миграцией и перечисляет все расхождения
+func CheckBaselineSchema(columns map[string]map[string]bool) error {
+	var problems []string
+	for table, names := range baselineColumns {
+		if columns[table] == nil {
+			problems = append(problems, "missing table "+table)
+			continue
+		}
+		for _, name := range names {
+			if !columns[table][name] {
+				problems = append(problems, "missing column "+table+"."+name)
+			}
+		}
+	}
+	for table, names := range laterObjects {
+		if names == nil {
+			if columns[table] != nil {
+				problems = append(problems, "unexpected table "+table)
+			}
+			continue
+		}
+		for _, name := range names {
+			if columns[table][name] {
+				problems = append(problems, "unexpected column "+table+"."+name)
+			}
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("%w: %s", ErrBaselineMismatch, strings.Join(problems, ", "))
+	}
+	return nil
+}
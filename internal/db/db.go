@@ -2,29 +2,67 @@
 package db
 
 import (
+	"context"
 	"fmt"
-	"os"
+	"time"
+
+	"music/config"
+	"music/pkg/logger"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+const (
+	// maxConnectBackoff ограничивает паузу между попытками подключения
+	maxConnectBackoff = 5 * time.Second
+	// connectAttemptTimeout - время на одну попытку; без него недоступный адрес держит попытку до таймаута TCP
+	connectAttemptTimeout = 5
 )
 
-// Connect - функция для подключения к базе данных
-func Connect() (*gorm.DB, error) {
-	// Строка подключения к базе данных из переменных окружения
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
-		os.Getenv("DB_HOST"),
-		os.Getenv("DB_USER"),
-		os.Getenv("DB_PASSWORD"),
-		os.Getenv("DB_NAME"),
-		os.Getenv("DB_PORT"),
-	)
-
-	// Подключение к базе данных
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+// Connect - функция для подключения к базе данных. Пока база не отвечает, подключение повторяется
+// с экспоненциально растущей паузой, но не дольше cfg.ConnectMaxWait.
+func Connect(ctx context.Context, cfg config.DatabaseConfig) (*gorm.DB, error) {
+	dsn := fmt.Sprintf("%s connect_timeout=%d", cfg.DSN(), connectAttemptTimeout)
+
+	var db *gorm.DB
+	backoff := Backoff{Initial: cfg.ConnectBackoff, Max: maxConnectBackoff, MaxWait: cfg.ConnectMaxWait}
+	err := backoff.Retry(ctx, func(attempt int) error {
+		// Подключение к базе данных
+		// TranslateError приводит ошибки драйвера к ошибкам GORM (например, gorm.ErrDuplicatedKey)
+		// Неудачные попытки записываются в лог здесь, поэтому собственный лог GORM на время подключения отключён
+		opened, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+			TranslateError: true,
+			Logger:         gormlogger.Default.LogMode(gormlogger.Silent),
+		})
+		if err != nil {
+			// GORM оставляет пул открытым, даже если проверочный запрос не прошёл
+			if opened != nil {
+				if sqlDB, dbErr := opened.DB(); dbErr == nil {
+					sqlDB.Close()
+				}
+			}
+			logger.WarnKV(ctx, "Database is not available yet", "attempt", attempt, "error", err)
+			return err
+		}
+		opened.Logger = gormlogger.Default
+		db = opened
+		return nil
+	})
 	if err != nil {
 		return nil, err // Возвращаем ошибку, если подключение не удалось
 	}
 
+	// Настройки пула соединений
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
 	return db, nil // Возвращаем подключение, если успешно
 }
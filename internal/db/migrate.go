@@ -2,19 +2,173 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
 
-	"music/internal/models"
+	"music/pkg/logger"
 
-	"gorm.io/gorm"
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
+)
 
-	"music/pkg/logger"
+var (
+	// ErrSchemaAhead возвращается, если в базе применены миграции, которых нет в этой сборке
+	ErrSchemaAhead = errors.New("database schema is ahead of the application")
+	// ErrSchemaBehind возвращается, если в базе применены не все миграции этой сборки
+	ErrSchemaBehind = errors.New("database schema is behind the application")
+	// ErrUnversionedSchema возвращается для базы, созданной без таблицы версий (например, через AutoMigrate)
+	ErrUnversionedSchema = errors.New("database has tables but no migration version table")
 )
 
-func Migrate(db *gorm.DB) {
-	// Выполняем миграции для моделей
-	err := db.AutoMigrate(&models.Artist{}, &models.SongDetail{})
+// Migrator применяет и откатывает миграции goose из набора файлов. Версии хранятся в таблице goose,
+// поэтому схему можно обслуживать и утилитой goose.
+type Migrator struct {
+	db       *sql.DB
+	provider *goose.Provider
+	locker   lock.SessionLocker
+}
+
+// NewMigrator собирает миграции из fsys. Для встроенных в бинарный файл миграций передайте migrations.FS.
+func NewMigrator(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	// Advisory-блокировка не даёт двум экземплярам мигрировать одновременно
+	locker, err := lock.NewPostgresSessionLocker()
+	if err != nil {
+		return nil, err
+	}
+	// Пропущенные более старые миграции применяются, а не останавливают запуск
+	provider, err := goose.NewProvider(goose.DialectPostgres, db, fsys,
+		goose.WithSessionLocker(locker),
+		goose.WithAllowOutofOrder(true),
+		goose.WithDisableGlobalRegistry(true),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, provider: provider, locker: locker}, nil
+}
+
+// Migrate проверяет, что схема не новее приложения, и применяет недостающие миграции
+func (m *Migrator) Migrate(ctx context.Context) error {
+	if err := m.Check(ctx); err != nil {
+		return err
+	}
+	applied, err := m.Up(ctx)
+	if err != nil {
+		return err
+	}
+	version, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+	logger.InfoKV(ctx, "Database migrated successfully!", "applied", len(applied), "version", version)
+	return nil
+}
+
+// Sources возвращает известные миграции по возрастанию версии
+func (m *Migrator) Sources() []*goose.Source {
+	return m.provider.ListSources()
+}
+
+// Latest возвращает версию самой новой известной миграции
+func (m *Migrator) Latest() int64 {
+	sources := m.provider.ListSources()
+	return sources[len(sources)-1].Version
+}
+
+// Version возвращает версию последней применённой миграции; 0 для пустой базы
+func (m *Migrator) Version(ctx context.Context) (int64, error) {
+	if err := m.checkVersioned(ctx); err != nil {
+		return 0, err
+	}
+	// GetVersions, в отличие от GetDBVersion, не ждёт блокировку миграций, поэтому подходит для /readyz
+	version, _, err := m.provider.GetVersions(ctx)
+	return version, err
+}
+
+// Check возвращает ErrSchemaAhead, если в базе применена миграция новее последней известной
+func (m *Migrator) Check(ctx context.Context) error {
+	version, err := m.Version(ctx)
 	if err != nil {
-		logger.Fatal(context.Background(), "failed to migrate database", err)
+		return err
+	}
+	if version > m.Latest() {
+		return fmt.Errorf("%w: database version %d, latest known migration %d", ErrSchemaAhead, version, m.Latest())
+	}
+	return nil
+}
+
+// CheckCurrent возвращает ошибку, если версия схемы не совпадает с последней известной миграцией
+func (m *Migrator) CheckCurrent(ctx context.Context) error {
+	version, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+	switch {
+	case version > m.Latest():
+		return fmt.Errorf("%w: database version %d, expected %d", ErrSchemaAhead, version, m.Latest())
+	case version < m.Latest():
+		return fmt.Errorf("%w: database version %d, expected %d", ErrSchemaBehind, version, m.Latest())
+	}
+	return nil
+}
+
+// Status возвращает все известные миграции по возрастанию версии вместе с моментом применения
+func (m *Migrator) Status(ctx context.Context) ([]*goose.MigrationStatus, error) {
+	if err := m.checkVersioned(ctx); err != nil {
+		return nil, err
+	}
+	return m.provider.Status(ctx)
+}
+
+// Up применяет все ещё не применённые миграции по возрастанию версии. Каждая миграция выполняется
+// в своей транзакции вместе с записью версии; при ошибке возвращаются миграции, применённые до неё.
+func (m *Migrator) Up(ctx context.Context) ([]*goose.MigrationResult, error) {
+	if err := m.checkVersioned(ctx); err != nil {
+		return nil, err
+	}
+	results, err := m.provider.Up(ctx)
+	var partial *goose.PartialError
+	if errors.As(err, &partial) {
+		results = partial.Applied
+	}
+	for _, result := range results {
+		logger.InfoKV(ctx, "Migration applied", "migration", result.Source.Path)
+	}
+	return results, err
+}
+
+// Down откатывает последнюю применённую миграцию; goose.ErrNoNextVersion - если откатывать нечего
+func (m *Migrator) Down(ctx context.Context) (*goose.MigrationResult, error) {
+	if err := m.checkVersioned(ctx); err != nil {
+		return nil, err
+	}
+	result, err := m.provider.Down(ctx)
+	if err != nil {
+		return nil, err
+	}
+	logger.InfoKV(ctx, "Migration rolled back", "migration", result.Source.Path)
+	return result, nil
+}
+
+// Create записывает в dir заготовку новой миграции SQL с версией по текущему времени
+func Create(dir, name string) error {
+	return goose.Create(nil, dir, name, "sql")
+}
+
+// checkVersioned не даёт goose создать таблицу версий в базе, которую создал AutoMigrate:
+// иначе первая миграция попыталась бы заново создать существующие таблицы.
+func (m *Migrator) checkVersioned(ctx context.Context) error {
+	var hasVersions, hasTables bool
+	err := m.db.QueryRowContext(ctx,
+		`SELECT to_regclass($1) IS NOT NULL, to_regclass('artists') IS NOT NULL`, goose.DefaultTablename,
+	).Scan(&hasVersions, &hasTables)
+	if err != nil {
+		return err
+	}
+	if !hasVersions && hasTables {
+		return fmt.Errorf("%w: run \"migrate baseline\" to adopt the existing schema", ErrUnversionedSchema)
 	}
-	logger.Info(context.Background(), "Database migrated successfully!")
+	return nil
 }
@@ -0,0 +1,90 @@
+package db_test
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+
+	"music/internal/db"
+	"music/internal/db/migrations"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lazyDB возвращает пул, который не подключается к базе, пока к ней не обратятся
+func lazyDB(t *testing.T) *sql.DB {
+	t.Helper()
+	sqlDB, err := sql.Open("pgx", "host=127.0.0.1 port=1 connect_timeout=1")
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+	return sqlDB
+}
+
+func TestNewMigrator_Embedded(t *testing.T) {
+	migrator, err := db.NewMigrator(lazyDB(t), migrations.FS)
+	require.NoError(t, err)
+
+	sources := migrator.Sources()
+	require.NotEmpty(t, sources)
+	assert.Equal(t, int64(20241002175019), sources[0].Version)
+	for i, source := range sources {
+		if i > 0 {
+			assert.Greater(t, source.Version, sources[i-1].Version)
+		}
+	}
+	assert.Equal(t, sources[len(sources)-1].Version, migrator.Latest())
+}
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, db.Create(dir, "add_song_tags"))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Regexp(t, `^\d{14}_add_song_tags\.sql$`, entries[0].Name())
+
+	// Заготовка подхватывается как миграция
+	migrator, err := db.NewMigrator(lazyDB(t), os.DirFS(dir))
+	require.NoError(t, err)
+	assert.Len(t, migrator.Sources(), 1)
+}
+
+func TestCheckBaselineSchema(t *testing.T) {
+	columns := func(names ...string) map[string]bool {
+		set := make(map[string]bool, len(names))
+		for _, name := range names {
+			set[name] = true
+		}
+		return set
+	}
+	// Схема, которую создавал AutoMigrate до перехода на миграции
+	autoMigrated := func() map[string]map[string]bool {
+		return map[string]map[string]bool{
+			"artists":      columns("id", "name", "created_at"),
+			"song_details": columns("id", "artist_id", "group_name", "song_name", "release_date", "text", "song_url", "created_at"),
+		}
+	}
+
+	assert.NoError(t, db.CheckBaselineSchema(autoMigrated()))
+
+	missing := autoMigrated()
+	delete(missing["song_details"], "song_url")
+	delete(missing, "artists")
+	err := db.CheckBaselineSchema(missing)
+	assert.ErrorIs(t, err, db.ErrBaselineMismatch)
+	assert.ErrorContains(t, err, "missing table artists")
+	assert.ErrorContains(t, err, "missing column song_details.song_url")
+
+	later := autoMigrated()
+	later["song_details"]["deleted_at"] = true
+	later["albums"] = columns("id")
+	err = db.CheckBaselineSchema(later)
+	assert.ErrorIs(t, err, db.ErrBaselineMismatch)
+	assert.ErrorContains(t, err, "unexpected column song_details.deleted_at")
+	assert.ErrorContains(t, err, "unexpected table albums")
+
+	assert.ErrorIs(t, db.CheckBaselineSchema(nil), db.ErrBaselineMismatch)
+}
@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE song_details ADD COLUMN enrichment_status VARCHAR(16) NOT NULL DEFAULT 'pending';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE song_details DROP COLUMN enrichment_status;
+-- +goose StatementEnd
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE albums (
+    id SERIAL PRIMARY KEY,
+    title VARCHAR(255) NOT NULL,
+    release_date DATE,
+    type VARCHAR(16) NOT NULL DEFAULT 'album' CHECK (type IN ('album', 'single', 'ep', 'compilation')),
+    artist_id INTEGER NOT NULL REFERENCES artists(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Треклист: одна песня может входить в несколько релизов
+CREATE TABLE album_tracks (
+    album_id INTEGER NOT NULL REFERENCES albums(id) ON DELETE CASCADE,
+    disc_number INTEGER NOT NULL CHECK (disc_number > 0),
+    track_number INTEGER NOT NULL CHECK (track_number > 0),
+    song_id INTEGER NOT NULL REFERENCES song_details(id) ON DELETE CASCADE,
+    PRIMARY KEY (album_id, disc_number, track_number)
+);
+
+CREATE INDEX idx_albums_artist_id ON albums (artist_id);
+CREATE INDEX idx_album_tracks_song_id ON album_tracks (song_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE album_tracks;
+DROP TABLE albums;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Текст песни хранится строкой с JSON-документом разделов, а пустая строка означает, что текста нет.
+-- В JSONB пустую строку не записать, поэтому колонка переводится в TEXT.
+ALTER TABLE song_details ALTER COLUMN text TYPE TEXT USING text::text;
+UPDATE song_details SET text = '' WHERE text IS NULL;
+ALTER TABLE song_details ALTER COLUMN text SET DEFAULT '';
+ALTER TABLE song_details ALTER COLUMN text SET NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE song_details ALTER COLUMN text DROP NOT NULL;
+ALTER TABLE song_details ALTER COLUMN text DROP DEFAULT;
+ALTER TABLE song_details ALTER COLUMN text TYPE JSONB USING NULLIF(text, '')::jsonb;
+-- +goose StatementEnd
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Куплеты песен для полнотекстового поиска; таблицу поддерживает хранилище при каждом сохранении песни
+CREATE TABLE song_verses (
+    song_id INTEGER NOT NULL REFERENCES song_details(id) ON DELETE CASCADE,
+    verse_index INTEGER NOT NULL CHECK (verse_index >= 0),
+    text TEXT NOT NULL,
+    PRIMARY KEY (song_id, verse_index)
+);
+
+INSERT INTO song_verses (song_id, verse_index, text)
+SELECT s.id, v.ordinality - 1, v.verse
+FROM song_details s,
+     jsonb_array_elements_text(s.text::jsonb -> 'verses') WITH ORDINALITY AS v(verse, ordinality)
+WHERE s.text LIKE '{%';
+
+-- Выражения индексов совпадают с выражениями запроса поиска в internal/repository/postgres_search.go
+CREATE INDEX idx_song_details_search ON song_details USING GIN ((
+    setweight(to_tsvector('russian', coalesce(song_name, '')) || to_tsvector('english', coalesce(song_name, '')), 'A') ||
+    setweight(to_tsvector('russian', coalesce(group_name, '')) || to_tsvector('english', coalesce(group_name, '')), 'B')
+));
+CREATE INDEX idx_song_verses_search ON song_verses USING GIN ((to_tsvector('russian', text) || to_tsvector('english', text)));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_song_details_search;
+DROP TABLE song_verses;
+-- +goose StatementEnd
@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Удалённые песни и исполнители остаются в таблицах с моментом удаления, пока их не удалят из корзины
+ALTER TABLE artists ADD COLUMN deleted_at TIMESTAMP;
+ALTER TABLE song_details ADD COLUMN deleted_at TIMESTAMP;
+
+-- Имена и названия уникальны только среди не удалённых записей, чтобы удалённое можно было добавить заново
+ALTER TABLE artists DROP CONSTRAINT artists_name_key;
+CREATE UNIQUE INDEX idx_artists_name ON artists (name) WHERE deleted_at IS NULL;
+ALTER TABLE song_details DROP CONSTRAINT song_details_song_name_artist_id_key;
+CREATE UNIQUE INDEX idx_song_details_song_artist ON song_details (song_name, artist_id) WHERE deleted_at IS NULL;
+
+CREATE INDEX idx_artists_deleted_at ON artists (deleted_at);
+CREATE INDEX idx_song_details_deleted_at ON song_details (deleted_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- Содержимое корзины удаляется: без него уникальность снова можно проверять по всем записям
+DELETE FROM song_details WHERE deleted_at IS NOT NULL;
+DELETE FROM artists WHERE deleted_at IS NOT NULL;
+
+DROP INDEX idx_song_details_deleted_at;
+DROP INDEX idx_artists_deleted_at;
+DROP INDEX idx_song_details_song_artist;
+ALTER TABLE song_details ADD CONSTRAINT song_details_song_name_artist_id_key UNIQUE (song_name, artist_id);
+DROP INDEX idx_artists_name;
+ALTER TABLE artists ADD CONSTRAINT artists_name_key UNIQUE (name);
+
+ALTER TABLE song_details DROP COLUMN deleted_at;
+ALTER TABLE artists DROP COLUMN deleted_at;
+-- +goose StatementEnd
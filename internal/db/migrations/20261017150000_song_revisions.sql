@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+-- История правок песни. Исполнитель хранится по имени и ID без внешнего ключа:
+-- правка остаётся в истории, даже если исполнителя уже нет.
+CREATE TABLE song_revisions (
+    id SERIAL PRIMARY KEY,
+    song_id INTEGER NOT NULL REFERENCES song_details(id) ON DELETE CASCADE,
+    number INTEGER NOT NULL CHECK (number > 0),
+    client_id VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    artist_id INTEGER,
+    group_name VARCHAR(255) NOT NULL,
+    song_name VARCHAR(255) NOT NULL,
+    release_date DATE,
+    song_url VARCHAR(255),
+    text TEXT NOT NULL DEFAULT ''
+);
+
+CREATE UNIQUE INDEX idx_song_revisions_song_number ON song_revisions (song_id, number);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE song_revisions;
+-- +goose StatementEnd
@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Версия записи растёт при каждом изменении и отдаётся клиентам в ETag
+ALTER TABLE artists ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE song_details ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE song_details DROP COLUMN version;
+ALTER TABLE artists DROP COLUMN version;
+-- +goose StatementEnd
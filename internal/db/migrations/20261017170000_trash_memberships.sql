@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Релизы удаляются в корзину вместе с исполнителем и восстанавливаются вместе с ним
+ALTER TABLE albums ADD COLUMN deleted_at TIMESTAMP;
+CREATE INDEX idx_albums_deleted_at ON albums (deleted_at);
+
+-- Треки и позиции в плейлистах песен из корзины: возвращаются при восстановлении песни
+-- и удаляются вместе с ней при очистке корзины
+CREATE TABLE trashed_album_tracks (
+    album_id INTEGER NOT NULL REFERENCES albums(id) ON DELETE CASCADE,
+    disc_number INTEGER NOT NULL,
+    track_number INTEGER NOT NULL,
+    song_id INTEGER NOT NULL REFERENCES song_details(id) ON DELETE CASCADE,
+    PRIMARY KEY (song_id, album_id, disc_number, track_number)
+);
+
+CREATE TABLE trashed_playlist_items (
+    playlist_id INTEGER NOT NULL REFERENCES playlists(id) ON DELETE CASCADE,
+    song_id INTEGER NOT NULL REFERENCES song_details(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    added_at TIMESTAMP,
+    PRIMARY KEY (song_id, playlist_id)
+);
+
+CREATE INDEX idx_trashed_album_tracks_album_id ON trashed_album_tracks (album_id);
+CREATE INDEX idx_trashed_playlist_items_playlist_id ON trashed_playlist_items (playlist_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE trashed_playlist_items;
+DROP TABLE trashed_album_tracks;
+DELETE FROM albums WHERE deleted_at IS NOT NULL;
+DROP INDEX idx_albums_deleted_at;
+ALTER TABLE albums DROP COLUMN deleted_at;
+-- +goose StatementEnd
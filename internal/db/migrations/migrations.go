@@ -0,0 +1,9 @@
+// Package migrations встраивает SQL-миграции схемы в бинарный файл.
+package migrations
+
+import "embed"
+
+// FS содержит файлы миграций в формате goose: <версия>_<название>.sql
+//
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"music/internal/models"
+	"music/internal/repository"
+	"music/internal/utils"
+	"music/pkg/logger"
+)
+
+// GetAlbumsHandler возвращает список релизов с пагинацией.
+// @Summary Получить список релизов
+// @Tags albums
+// @Produce json
+// @Param artist_id query int false "ID основного исполнителя"
+// @Param limit query int false "Количество записей на странице" maximum(100)
+// @Param page query int false "Номер страницы"
+// @Success 200 {object} models.AlbumsResponse "Список релизов"
+// @Failure 400 {object} nil "Некорректный запрос"
+// @Failure 500 {object} nil "Ошибка на сервере"
+// @Router /albums [get]
+func GetAlbumsHandler(albums repository.AlbumRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		limit, page, offset := parsePagination(r)
+
+		filter := repository.AlbumFilter{Limit: limit, Offset: offset}
+		if raw := r.URL.Query().Get("artist_id"); raw != "" {
+			artistID, err := strconv.ParseUint(raw, 10, 0)
+			if err != nil {
+				http.Error(w, "Bad Request: Invalid artist_id", http.StatusBadRequest)
+				return
+			}
+			filter.ArtistID = uint(artistID)
+		}
+
+		list, total, err := albums.List(ctx, filter)
+		if err != nil {
+			logger.Error(ctx, "Error fetching albums", err)
+			http.Error(w, "Error fetching albums", http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(ctx, w, http.StatusOK, models.AlbumsResponse{
+			TotalItems: total,
+			Page:       page,
+			Limit:      limit,
+			Albums:     list,
+		})
+	}
+}
+
+// GetAlbumHandler возвращает релиз с треклистом.
+// @Summary Получить релиз
+// @Tags albums
+// @Produce json
+// @Param id path int true "ID релиза"
+// @Success 200 {object} models.Album "Релиз"
+// @Failure 404 {object} nil "Релиз не найден"
+// @Router /albums/{id} [get]
+func GetAlbumHandler(albums repository.AlbumRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := parseIDParam(w, r, "id")
+		if !ok {
+			return
+		}
+
+		album, err := albums.GetByID(r.Context(), id)
+		if err != nil {
+			writeAlbumError(w, r, err)
+			return
+		}
+		writeJSON(r.Context(), w, http.StatusOK, album)
+	}
+}
+
+// AddAlbumHandler создаёт релиз.
+// @Summary Создать релиз
+// @Description Треки задаются номером диска и номером трека; одна песня может входить в несколько релизов.
+// @Tags albums
+// @Accept json
+// @Produce json
+// @Param album body models.AlbumInput true "Релиз"
+// @Success 201 {object} models.Album "Релиз создан"
+// @Failure 400 {string} string "Неверный запрос или ссылка на несуществующего исполнителя или песню"
+// @Failure 500 {string} string "Внутренняя ошибка сервера"
+// @Router /albums [post]
+func AddAlbumHandler(albums repository.AlbumRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		album, ok := decodeAlbumInput(w, r)
+		if !ok {
+			return
+		}
+
+		if err := albums.Create(ctx, album); err != nil {
+			writeAlbumError(w, r, err)
+			return
+		}
+
+		logger.InfoKV(ctx, "New album created", "album_id", album.ID)
+		writeJSON(ctx, w, http.StatusCreated, album)
+	}
+}
+
+// UpdateAlbumHandler полностью заменяет данные и треклист релиза.
+// @Summary Изменить релиз
+// @Tags albums
+// @Accept json
+// @Produce json
+// @Param id path int true "ID релиза"
+// @Param album body models.AlbumInput true "Релиз"
+// @Success 200 {object} models.Album "Релиз изменён"
+// @Failure 400 {string} string "Неверный запрос"
+// @Failure 404 {string} string "Релиз не найден"
+// @Router /albums/{id} [put]
+func UpdateAlbumHandler(albums repository.AlbumRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		id, ok := parseIDParam(w, r, "id")
+		if !ok {
+			return
+		}
+
+		album, ok := decodeAlbumInput(w, r)
+		if !ok {
+			return
+		}
+
+		album.ID = id
+		if err := albums.Update(ctx, album); err != nil {
+			writeAlbumError(w, r, err)
+			return
+		}
+
+		logger.InfoKV(ctx, "Album updated", "album_id", album.ID)
+		writeJSON(ctx, w, http.StatusOK, album)
+	}
+}
+
+// DeleteAlbumHandler удаляет релиз. Песни релиза не удаляются.
+// @Summary Удалить релиз
+// @Tags albums
+// @Param id path int true "ID релиза"
+// @Success 204 {object} nil "Релиз удалён"
+// @Failure 404 {object} nil "Релиз не найден"
+// @Router /albums/{id} [delete]
+func DeleteAlbumHandler(albums repository.AlbumRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := parseIDParam(w, r, "id")
+		if !ok {
+			return
+		}
+
+		if err := albums.Delete(r.Context(), id); err != nil {
+			writeAlbumError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// decodeAlbumInput читает тело запроса и превращает его в модель релиза
+func decodeAlbumInput(w http.ResponseWriter, r *http.Request) (*models.Album, bool) {
+	var input models.AlbumInput
+	if err := utils.DecodeInput(w, r, r.Context(), &input, "Decoded album input"); err != nil {
+		return nil, false
+	}
+
+	input.Title = utils.NormalizeSongName(input.Title)
+	if err := input.Validate(); err != nil {
+		http.Error(w, "Bad Request: "+err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+
+	album := &models.Album{
+		Title:    input.Title,
+		Type:     input.Type,
+		ArtistID: input.ArtistID,
+		Tracks:   make([]models.AlbumTrack, 0, len(input.Tracks)),
+	}
+
+	if input.ReleaseDate != "" {
+		releaseDate, err := time.Parse("2006-01-02", input.ReleaseDate)
+		if err != nil {
+			http.Error(w, "Bad Request: Invalid release date format", http.StatusBadRequest)
+			return nil, false
+		}
+		album.ReleaseDate = &releaseDate
+	}
+
+	for _, track := range input.Tracks {
+		album.Tracks = append(album.Tracks, models.AlbumTrack{
+			SongID:      track.SongID,
+			DiscNumber:  track.DiscNumber,
+			TrackNumber: track.TrackNumber,
+		})
+	}
+	return album, true
+}
+
+// writeAlbumError переводит ошибку хранилища релизов в HTTP-ответ
+func writeAlbumError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		http.Error(w, "Album Not Found", http.StatusNotFound)
+	case errors.Is(err, repository.ErrInvalidReference):
+		http.Error(w, "Bad Request: artist or song does not exist", http.StatusBadRequest)
+	default:
+		logger.Error(r.Context(), "Album repository error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
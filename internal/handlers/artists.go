@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"music/internal/models"
+	"music/internal/repository"
+	"music/internal/utils"
+	"music/pkg/logger"
+)
+
+// GetArtistsHandler возвращает список исполнителей с пагинацией.
+// @Summary Получить список исполнителей
+// @Tags artists
+// @Produce json
+// @Param limit query int false "Количество записей на странице" maximum(100)
+// @Param page query int false "Номер страницы"
+// @Success 200 {object} models.ArtistsResponse "Список исполнителей"
+// @Failure 500 {object} nil "Ошибка на сервере"
+// @Router /artists [get]
+func GetArtistsHandler(artists repository.ArtistRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		limit, page, offset := parsePagination(r)
+
+		list, total, err := artists.List(ctx, limit, offset)
+		if err != nil {
+			logger.Error(ctx, "Error fetching artists", err)
+			http.Error(w, "Error fetching artists", http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(ctx, w, http.StatusOK, models.ArtistsResponse{
+			TotalItems: total,
+			Page:       page,
+			Limit:      limit,
+			Artists:    list,
+		})
+	}
+}
+
+// GetArtistHandler возвращает исполнителя по ID.
+// @Summary Получить исполнителя
+// @Tags artists
+// @Produce json
+// @Param id path int true "ID исполнителя"
+// @Success 200 {object} models.Artist "Исполнитель"
+// @Failure 404 {object} nil "Исполнитель не найден"
+// @Router /artists/{id} [get]
+func GetArtistHandler(artists repository.ArtistRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		artist, ok := lookupArtist(w, r, artists)
+		if !ok {
+			return
+		}
+		setETag(w, artist.Version)
+		writeJSON(r.Context(), w, http.StatusOK, artist)
+	}
+}
+
+// AddArtistHandler создаёт исполнителя.
+// @Summary Создать исполнителя
+// @Tags artists
+// @Accept json
+// @Produce json
+// @Param artist body models.ArtistInput true "Исполнитель"
+// @Success 201 {object} models.Artist "Исполнитель создан"
+// @Failure 400 {string} string "Неверный запрос"
+// @Failure 409 {string} string "Исполнитель уже существует"
+// @Failure 500 {string} string "Внутренняя ошибка сервера"
+// @Router /artists [post]
+func AddArtistHandler(artists repository.ArtistRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		input, ok := decodeArtistInput(w, r)
+		if !ok {
+			return
+		}
+
+		artist := models.Artist{Name: input.Name}
+		if err := artists.Create(ctx, &artist); err != nil {
+			writeArtistError(w, r, err)
+			return
+		}
+
+		logger.InfoKV(ctx, "New artist created", "artist_id", artist.ID)
+		setETag(w, artist.Version)
+		writeJSON(ctx, w, http.StatusCreated, artist)
+	}
+}
+
+// RenameArtistHandler переименовывает исполнителя и обновляет имя исполнителя в его песнях.
+// @Summary Переименовать исполнителя
+// @Tags artists
+// @Accept json
+// @Produce json
+// @Param id path int true "ID исполнителя"
+// @Param artist body models.ArtistInput true "Новое имя"
+// @Param If-Match header string false "ETag исполнителя; если исполнитель с тех пор изменился, возвращается 412"
+// @Success 200 {object} models.Artist "Исполнитель переименован"
+// @Failure 400 {string} string "Неверный запрос"
+// @Failure 404 {string} string "Исполнитель не найден"
+// @Failure 409 {string} string "Исполнитель с таким именем уже существует"
+// @Failure 412 {string} string "Исполнитель изменился после получения ETag"
+// @Router /artists/{id} [put]
+func RenameArtistHandler(artists repository.ArtistRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		current, ok := lookupArtist(w, r, artists)
+		if !ok {
+			return
+		}
+		version, ok := checkIfMatch(w, r, current.Version)
+		if !ok {
+			return
+		}
+
+		input, ok := decodeArtistInput(w, r)
+		if !ok {
+			return
+		}
+
+		artist, err := artists.Rename(ctx, current.ID, version, input.Name)
+		if err != nil {
+			writeArtistError(w, r, err)
+			return
+		}
+
+		logger.InfoKV(ctx, "Artist renamed", "artist_id", artist.ID, "name", artist.Name)
+		setETag(w, artist.Version)
+		writeJSON(ctx, w, http.StatusOK, artist)
+	}
+}
+
+// DeleteArtistHandler удаляет исполнителя.
+// @Summary Удалить исполнителя
+// @Description Исполнитель переносится в корзину. Без cascade=true исполнитель с песнями или релизами не удаляется и возвращается 409.
+// @Description С cascade=true его песни переносятся в корзину вместе с ним, а релизы скрываются и возвращаются при восстановлении исполнителя.
+// @Tags artists
+// @Param id path int true "ID исполнителя"
+// @Param cascade query bool false "Удалить вместе с песнями и релизами"
+// @Param If-Match header string false "ETag исполнителя; если исполнитель с тех пор изменился, возвращается 412"
+// @Success 204 {object} nil "Исполнитель удалён"
+// @Failure 404 {object} nil "Исполнитель не найден"
+// @Failure 409 {object} nil "У исполнителя есть песни или релизы"
+// @Failure 412 {object} nil "Исполнитель изменился после получения ETag"
+// @Router /artists/{id} [delete]
+func DeleteArtistHandler(artists repository.ArtistRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		artist, ok := lookupArtist(w, r, artists)
+		if !ok {
+			return
+		}
+		id := artist.ID
+		version, ok := checkIfMatch(w, r, artist.Version)
+		if !ok {
+			return
+		}
+
+		cascade, err := parseBoolQuery(r, "cascade")
+		if err != nil {
+			http.Error(w, "Bad Request: Invalid cascade", http.StatusBadRequest)
+			return
+		}
+
+		if err := artists.Delete(ctx, id, version, cascade); err != nil {
+			writeArtistError(w, r, err)
+			return
+		}
+
+		logger.InfoKV(ctx, "Artist deleted", "artist_id", id, "cascade", cascade)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// GetArtistSongsHandler возвращает песни исполнителя с пагинацией.
+// @Summary Получить песни исполнителя
+// @Tags artists
+// @Produce json
+// @Param id path int true "ID исполнителя"
+// @Param limit query int false "Количество записей на странице" maximum(100)
+// @Param page query int false "Номер страницы"
+// @Param count query bool false "Считать общее количество песен" default(true)
+// @Param after query string false "Курсор next_cursor из предыдущего ответа; заменяет page"
+// @Success 200 {object} models.SongsResponse "Песни исполнителя"
+// @Failure 404 {object} nil "Исполнитель не найден"
+// @Router /artists/{id}/songs [get]
+func GetArtistSongsHandler(artists repository.ArtistRepository, songs repository.SongRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		artist, ok := lookupArtist(w, r, artists)
+		if !ok {
+			return
+		}
+
+		response, ok := listSongsPage(w, r, songs, repository.SongFilter{ArtistID: artist.ID})
+		if !ok {
+			return
+		}
+		writeJSON(ctx, w, http.StatusOK, response)
+	}
+}
+
+// lookupArtist находит исполнителя по параметру маршрута {id}
+func lookupArtist(w http.ResponseWriter, r *http.Request, artists repository.ArtistRepository) (*models.Artist, bool) {
+	id, ok := parseIDParam(w, r, "id")
+	if !ok {
+		return nil, false
+	}
+
+	artist, err := artists.GetByID(r.Context(), id)
+	if err != nil {
+		writeArtistError(w, r, err)
+		return nil, false
+	}
+	return artist, true
+}
+
+// decodeArtistInput читает и нормализует тело запроса с именем исполнителя
+func decodeArtistInput(w http.ResponseWriter, r *http.Request) (*models.ArtistInput, bool) {
+	var input models.ArtistInput
+	if err := utils.DecodeInput(w, r, r.Context(), &input, "Decoded artist input"); err != nil {
+		return nil, false
+	}
+
+	input.Name = utils.NormalizeSongName(input.Name)
+	if err := input.Validate(); err != nil {
+		http.Error(w, "Bad Request: "+err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	return &input, true
+}
+
+// writeArtistError переводит ошибку хранилища исполнителей в HTTP-ответ
+func writeArtistError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		http.Error(w, "Artist Not Found", http.StatusNotFound)
+	case errors.Is(err, repository.ErrAlreadyExists):
+		http.Error(w, "Artist already exists", http.StatusConflict)
+	case errors.Is(err, repository.ErrArtistHasSongs):
+		http.Error(w, "Artist has songs, use cascade=true to delete them", http.StatusConflict)
+	case errors.Is(err, repository.ErrArtistHasAlbums):
+		http.Error(w, "Artist has albums, use cascade=true to delete them", http.StatusConflict)
+	case errors.Is(err, repository.ErrVersionConflict):
+		writeVersionConflict(w, r)
+	default:
+		logger.Error(r.Context(), "Artist repository error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
+
+// parseBoolQuery читает необязательный булев параметр запроса
+func parseBoolQuery(r *http.Request, name string) (bool, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(raw)
+}
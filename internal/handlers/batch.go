@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"music/internal/models"
+	"music/internal/repository"
+	"music/internal/utils"
+	"music/pkg/logger"
+)
+
+const (
+	// maxBatchSongs ограничивает число песен в одном пакете
+	maxBatchSongs = 5000
+	// maxNameLength - длина столбцов с названием песни и именем исполнителя
+	maxNameLength = 255
+)
+
+// errBatchRejected откатывает атомарный пакет, в котором есть ошибки
+var errBatchRejected = errors.New("batch rejected")
+
+// batchItem - прошедшая проверку песня пакета
+type batchItem struct {
+	index       int
+	group       string
+	song        string
+	releaseDate *time.Time
+}
+
+// AddSongsBatchHandler добавляет пакет песен за несколько запросов к базе.
+// @Summary Добавить пакет песен
+// @Description Исполнители, которых ещё нет, создаются. Результат возвращается для каждой песни.
+// @Description При atomic=true песни добавляются в одной транзакции: если хотя бы одна песня не прошла проверку
+// @Description или уже существует, не добавляется ни одна и возвращается 422. Иначе добавляются все корректные песни.
+// @Description Внешний API не вызывается, песни сохраняются со статусом обогащения pending.
+// @Tags songs
+// @Accept json
+// @Produce json
+// @Param atomic query bool false "Добавить все песни или ни одной"
+// @Param songs body []models.SongInput true "Песни; release_date в формате YYYY-MM-DD"
+// @Success 200 {object} models.BatchSongsResponse
+// @Failure 400 {string} string "Неверный запрос"
+// @Failure 413 {string} string "Слишком большой пакет"
+// @Failure 422 {object} models.BatchSongsResponse "Атомарный пакет отклонён"
+// @Failure 500 {string} string "Внутренняя ошибка сервера"
+// @Router /songs/batch [post]
+func AddSongsBatchHandler(repos repository.Repositories) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		atomic, err := parseBoolQuery(r, "atomic")
+		if err != nil {
+			http.Error(w, "Bad Request: invalid atomic", http.StatusBadRequest)
+			return
+		}
+
+		var inputs []models.SongInput
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxImportSize)).Decode(&inputs); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				http.Error(w, "Request Entity Too Large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, "Bad Request: expected JSON array of songs", http.StatusBadRequest)
+			return
+		}
+		if len(inputs) == 0 {
+			http.Error(w, "Bad Request: batch is empty", http.StatusBadRequest)
+			return
+		}
+		if len(inputs) > maxBatchSongs {
+			http.Error(w, fmt.Sprintf("Request Entity Too Large: batch is limited to %d songs", maxBatchSongs), http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		response := models.BatchSongsResponse{Atomic: atomic, Results: make([]models.BatchSongResult, len(inputs))}
+		items := prepareBatch(inputs, response.Results)
+
+		insert := func(repos repository.Repositories) error {
+			if err := insertBatch(ctx, repos, items, response.Results); err != nil {
+				return err
+			}
+			if atomic && !allCreated(response.Results) {
+				return errBatchRejected
+			}
+			return nil
+		}
+		if atomic {
+			err = repos.Tx.InTx(ctx, insert)
+		} else {
+			err = insert(repos)
+		}
+
+		status := http.StatusOK
+		switch {
+		case errors.Is(err, errBatchRejected):
+			status = http.StatusUnprocessableEntity
+			for i := range response.Results {
+				if result := &response.Results[i]; result.Status == models.BatchCreated {
+					result.Status, result.Song = models.BatchAborted, nil
+				}
+			}
+		case err != nil:
+			logger.Error(ctx, "Failed to add songs batch", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+
+		for _, result := range response.Results {
+			switch result.Status {
+			case models.BatchCreated:
+				response.Created++
+			case models.BatchConflict:
+				response.Conflicts++
+			case models.BatchInvalid:
+				response.Invalid++
+			}
+		}
+		logger.InfoKV(ctx, "Songs batch processed", "atomic", atomic, "songs", len(inputs),
+			"created", response.Created, "conflicts", response.Conflicts, "invalid", response.Invalid)
+		writeJSON(ctx, w, status, response)
+	}
+}
+
+// prepareBatch нормализует и проверяет песни пакета. Для отклонённых песен заполняет results,
+// остальные возвращает для вставки.
+func prepareBatch(inputs []models.SongInput, results []models.BatchSongResult) []batchItem {
+	items := make([]batchItem, 0, len(inputs))
+	seen := make(map[[2]string]int, len(inputs))
+	for i, input := range inputs {
+		results[i].Index = i
+		item := batchItem{
+			index: i,
+			group: utils.NormalizeSongName(input.Group),
+			song:  utils.NormalizeSongName(input.Song),
+		}
+
+		reason := ""
+		switch {
+		case item.group == "":
+			reason = "group is required"
+		case item.song == "":
+			reason = "song is required"
+		case utf8.RuneCountInString(item.group) > maxNameLength || utf8.RuneCountInString(item.song) > maxNameLength:
+			reason = fmt.Sprintf("group and song must not exceed %d characters", maxNameLength)
+		case input.ReleaseDate != "":
+			releaseDate, err := time.Parse("2006-01-02", input.ReleaseDate)
+			if err != nil {
+				reason = "release_date must be in format YYYY-MM-DD"
+			} else {
+				item.releaseDate = &releaseDate
+			}
+		}
+		if reason != "" {
+			results[i].Status, results[i].Error = models.BatchInvalid, reason
+			continue
+		}
+
+		key := [2]string{item.group, item.song}
+		if first, ok := seen[key]; ok {
+			results[i].Status, results[i].Error = models.BatchConflict, fmt.Sprintf("duplicate of song %d in batch", first)
+			continue
+		}
+		seen[key] = i
+		items = append(items, item)
+	}
+	return items
+}
+
+// insertBatch создаёт недостающих исполнителей и добавляет песни, записывая результат каждой в results
+func insertBatch(ctx context.Context, repos repository.Repositories, items []batchItem, results []models.BatchSongResult) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(items))
+	seen := make(map[string]bool, len(items))
+	for _, item := range items {
+		if !seen[item.group] {
+			seen[item.group] = true
+			names = append(names, item.group)
+		}
+	}
+	artists, err := repos.Artists.EnsureNames(ctx, names)
+	if err != nil {
+		return err
+	}
+
+	songs := make([]*models.SongDetail, 0, len(items))
+	for _, item := range items {
+		artist := artists[item.group]
+		songs = append(songs, &models.SongDetail{
+			ArtistID:         artist.ID,
+			GroupName:        artist.Name,
+			SongName:         item.song,
+			ReleaseDate:      item.releaseDate,
+			EnrichmentStatus: models.EnrichmentPending,
+		})
+	}
+	created, err := repos.Songs.CreateMany(ctx, songs)
+	if err != nil {
+		return err
+	}
+
+	for i, item := range items {
+		if created[i] {
+			results[item.index].Status, results[item.index].Song = models.BatchCreated, songs[i]
+		} else {
+			results[item.index].Status, results[item.index].Error = models.BatchConflict, "song already exists"
+		}
+	}
+	return nil
+}
+
+// allCreated проверяет, что все песни пакета добавлены
+func allCreated(results []models.BatchSongResult) bool {
+	for _, result := range results {
+		if result.Status != models.BatchCreated {
+			return false
+		}
+	}
+	return true
+}
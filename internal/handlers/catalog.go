@@ -0,0 +1,357 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"music/internal/models"
+	"music/internal/repository"
+	"music/internal/utils"
+	"music/pkg/logger"
+	"music/pkg/lyrics"
+)
+
+const (
+	// exportBatchSize - сколько записей читается из хранилища за один запрос при выгрузке
+	exportBatchSize = 500
+	// maxImportSize ограничивает размер загружаемого каталога
+	maxImportSize = 64 << 20
+	// maxLinkLength - длина столбца со ссылкой на песню
+	maxLinkLength = 255
+)
+
+// errDryRun откатывает транзакцию пробного импорта
+var errDryRun = errors.New("dry run")
+
+// ExportHandler выгружает каталог: сначала всех исполнителей, затем все песни с текстами.
+// @Summary Выгрузить каталог
+// @Description Записи отдаются потоком по мере чтения из базы. В CSV текст песни записывается в столбец text в виде JSON.
+// @Tags catalog
+// @Produce json
+// @Produce plain
+// @Param format query string false "Формат выгрузки" Enums(json, csv, ndjson) default(json)
+// @Success 200 {array} models.CatalogRecord
+// @Failure 400 {string} string "Неизвестный формат"
+// @Router /export [get]
+func ExportHandler(repos repository.Repositories) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = catalogJSON
+		}
+		contentType, ok := catalogContentTypes[format]
+		if !ok {
+			http.Error(w, "Bad Request: invalid format, expected json, csv or ndjson", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", contentType+"; charset=utf-8")
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": "catalog." + format}))
+		writer := newCatalogWriter(format, w)
+
+		// После начала ответа код статуса уже не изменить, поэтому ошибки только логируются
+		artists, songs, err := exportCatalog(ctx, repos, writer, func() {
+			if flusher, ok := w.(http.Flusher); ok {
+				flusher.Flush()
+			}
+		})
+		if err == nil {
+			err = writer.Close()
+		}
+		if err != nil {
+			logger.Error(ctx, "Catalog export interrupted", err)
+			return
+		}
+		logger.InfoKV(ctx, "Catalog exported", "format", format, "artists", artists, "songs", songs)
+	}
+}
+
+// exportCatalog записывает исполнителей и песни пачками, вызывая flush после каждой пачки
+func exportCatalog(ctx context.Context, repos repository.Repositories, writer catalogWriter, flush func()) (int, int, error) {
+	var artists, songs int
+	for offset := 0; ; offset += exportBatchSize {
+		batch, _, err := repos.Artists.List(ctx, exportBatchSize, offset)
+		if err != nil {
+			return artists, songs, err
+		}
+		for _, artist := range batch {
+			if err := writer.Write(models.CatalogRecord{Artist: artist.Name}); err != nil {
+				return artists, songs, err
+			}
+		}
+		artists += len(batch)
+		flush()
+		if len(batch) < exportBatchSize {
+			break
+		}
+	}
+
+	// Песни читаются по ключу, чтобы добавление песен во время выгрузки не сдвигало страницы
+	filter := repository.SongFilter{SkipTotal: true, Limit: exportBatchSize}
+	for {
+		batch, _, err := repos.Songs.List(ctx, filter)
+		if err != nil {
+			return artists, songs, err
+		}
+		for _, song := range batch {
+			record, err := catalogRecord(song)
+			if err != nil {
+				return artists, songs, fmt.Errorf("song %d: %w", song.ID, err)
+			}
+			if err := writer.Write(record); err != nil {
+				return artists, songs, err
+			}
+		}
+		songs += len(batch)
+		flush()
+		if len(batch) < exportBatchSize {
+			return artists, songs, nil
+		}
+		cursor := repository.NewSongCursor(batch[len(batch)-1], nil)
+		filter.After = &cursor
+	}
+}
+
+// catalogRecord переводит песню в запись каталога
+func catalogRecord(song models.SongDetail) (models.CatalogRecord, error) {
+	record := models.CatalogRecord{Artist: song.GroupName, Song: song.SongName, Link: song.SongURL}
+	if song.ReleaseDate != nil {
+		record.ReleaseDate = song.ReleaseDate.Format("2006-01-02")
+	}
+	text, err := models.ParseSongText(song.Text)
+	if err != nil {
+		return record, err
+	}
+	if len(text.Sections) > 0 {
+		record.Text = &text
+	}
+	return record, nil
+}
+
+// ImportHandler загружает каталог, добавляя и обновляя исполнителей и песни по паре (исполнитель, название).
+// @Summary Загрузить каталог
+// @Description Принимает выгрузку GET /export. Названия нормализуются так же, как при добавлении песни.
+// @Description Непустые поля записи заменяют поля сохранённой песни. Записи с ошибками пропускаются и попадают в отчёт.
+// @Description Все изменения сохраняются в одной транзакции; при dry_run=true транзакция откатывается.
+// @Tags catalog
+// @Accept json
+// @Accept plain
+// @Produce json
+// @Param format query string false "Формат; по умолчанию определяется по Content-Type" Enums(json, csv, ndjson)
+// @Param dry_run query bool false "Только проверить и вернуть отчёт, ничего не сохраняя"
+// @Param catalog body []models.CatalogRecord true "Записи каталога"
+// @Success 200 {object} models.ImportReport
+// @Failure 400 {string} string "Документ не разобран"
+// @Failure 413 {string} string "Слишком большой каталог"
+// @Failure 415 {string} string "Неизвестный формат"
+// @Failure 500 {string} string "Ошибка при сохранении"
+// @Router /import [post]
+func ImportHandler(repos repository.Repositories) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		format, ok := importFormat(r)
+		if !ok {
+			http.Error(w, "Unsupported Media Type: expected json, csv or ndjson", http.StatusUnsupportedMediaType)
+			return
+		}
+		dryRun, err := parseBoolQuery(r, "dry_run")
+		if err != nil {
+			http.Error(w, "Bad Request: invalid dry_run", http.StatusBadRequest)
+			return
+		}
+
+		reader := newCatalogReader(format, http.MaxBytesReader(w, r.Body, maxImportSize))
+		report := models.ImportReport{DryRun: dryRun, Rows: []models.ImportRowResult{}}
+		var decodeErr error
+
+		err = repos.Tx.InTx(ctx, func(tx repository.Repositories) error {
+			for row := 1; ; row++ {
+				record, err := reader.Next()
+				if err == io.EOF {
+					break
+				}
+				var rowErr *catalogRowError
+				if errors.As(err, &rowErr) {
+					report.Add(importResult(row, record, models.ImportFailed, rowErr))
+					continue
+				}
+				if err != nil {
+					decodeErr = err
+					return err
+				}
+
+				status, err := importRecord(ctx, tx, &record)
+				if err != nil && status != models.ImportFailed {
+					return fmt.Errorf("row %d: %w", row, err)
+				}
+				report.Add(importResult(row, record, status, err))
+			}
+			if dryRun {
+				return errDryRun
+			}
+			return nil
+		})
+
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooLarge):
+			http.Error(w, "Request Entity Too Large", http.StatusRequestEntityTooLarge)
+			return
+		case decodeErr != nil:
+			http.Error(w, "Bad Request: "+decodeErr.Error(), http.StatusBadRequest)
+			return
+		case err != nil && !errors.Is(err, errDryRun):
+			logger.Error(ctx, "Catalog import failed", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+
+		logger.InfoKV(ctx, "Catalog imported", "format", format, "dry_run", dryRun,
+			"created", report.Created, "updated", report.Updated, "skipped", report.Skipped, "failed", report.Failed)
+		writeJSON(ctx, w, http.StatusOK, report)
+	}
+}
+
+// importFormat определяет формат каталога по параметру format или по Content-Type
+func importFormat(r *http.Request) (string, bool) {
+	if format := r.URL.Query().Get("format"); format != "" {
+		_, ok := catalogContentTypes[format]
+		return format, ok
+	}
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return "", false
+	}
+	for format, contentType := range catalogContentTypes {
+		if mediaType == contentType {
+			return format, true
+		}
+	}
+	return "", false
+}
+
+// importResult описывает результат импорта записи для отчёта
+func importResult(row int, record models.CatalogRecord, status string, err error) models.ImportRowResult {
+	result := models.ImportRowResult{Row: row, Artist: record.Artist, Song: record.Song, Status: status}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// importRecord сохраняет запись каталога. Ошибки в данных записи возвращаются со статусом failed,
+// ошибки хранилища - с пустым статусом и прерывают импорт.
+func importRecord(ctx context.Context, repos repository.Repositories, record *models.CatalogRecord) (string, error) {
+	record.Artist = utils.NormalizeSongName(record.Artist)
+	record.Song = utils.NormalizeSongName(record.Song)
+	switch {
+	case record.Artist == "":
+		return models.ImportFailed, errors.New("artist is required")
+	case utf8.RuneCountInString(record.Artist) > maxNameLength || utf8.RuneCountInString(record.Song) > maxNameLength:
+		return models.ImportFailed, fmt.Errorf("artist and song must not exceed %d characters", maxNameLength)
+	case utf8.RuneCountInString(record.Link) > maxLinkLength:
+		return models.ImportFailed, fmt.Errorf("link must not exceed %d characters", maxLinkLength)
+	}
+
+	var releaseDate *time.Time
+	if record.ReleaseDate != "" {
+		parsed, err := time.Parse("2006-01-02", record.ReleaseDate)
+		if err != nil {
+			return models.ImportFailed, errors.New("release_date must be in format YYYY-MM-DD")
+		}
+		releaseDate = &parsed
+	}
+	var textJSON string
+	if record.Text != nil {
+		text := record.Text.WithSections()
+		sections, err := lyrics.Validate(text.Sections)
+		if err != nil {
+			return models.ImportFailed, err
+		}
+		if len(sections) > 0 {
+			raw, err := json.Marshal(models.NewSongText(sections))
+			if err != nil {
+				return "", err
+			}
+			textJSON = string(raw)
+		}
+	}
+
+	artist, err := repos.Artists.GetByName(ctx, record.Artist)
+	artistCreated := false
+	if errors.Is(err, repository.ErrNotFound) {
+		artist = &models.Artist{Name: record.Artist}
+		if err := repos.Artists.Create(ctx, artist); err != nil {
+			return "", err
+		}
+		artistCreated = true
+	} else if err != nil {
+		return "", err
+	}
+
+	if record.Song == "" {
+		if artistCreated {
+			return models.ImportCreated, nil
+		}
+		return models.ImportSkipped, nil
+	}
+
+	song, err := repos.Songs.FindByArtistAndName(ctx, artist.ID, record.Song)
+	if errors.Is(err, repository.ErrNotFound) {
+		song = &models.SongDetail{
+			ArtistID:         artist.ID,
+			GroupName:        artist.Name,
+			SongName:         record.Song,
+			ReleaseDate:      releaseDate,
+			SongURL:          record.Link,
+			Text:             textJSON,
+			EnrichmentStatus: models.EnrichmentDone,
+		}
+		if err := repos.Songs.Create(ctx, song); err != nil {
+			return "", err
+		}
+		return models.ImportCreated, nil
+	} else if err != nil {
+		return "", err
+	}
+
+	// Пустые поля записи не затирают сохранённые данные
+	updated := *song
+	if releaseDate != nil {
+		updated.ReleaseDate = releaseDate
+	}
+	if record.Link != "" {
+		updated.SongURL = record.Link
+	}
+	if textJSON != "" && !sameSongText(song.Text, textJSON) {
+		updated.Text = textJSON
+	}
+	if models.SameDate(updated.ReleaseDate, song.ReleaseDate) && updated.SongURL == song.SongURL && updated.Text == song.Text {
+		return models.ImportSkipped, nil
+	}
+	if err := repos.Songs.Update(ctx, &updated); err != nil {
+		return "", err
+	}
+	return models.ImportUpdated, nil
+}
+
+// sameSongText сравнивает тексты песен по содержимому, а не по записи JSON:
+// текст в старом плоском формате совпадает с тем же текстом, разбитым на разделы
+func sameSongText(stored, imported string) bool {
+	storedText, err := models.ParseSongText(stored)
+	if err != nil {
+		return false
+	}
+	raw, err := json.Marshal(storedText)
+	return err == nil && string(raw) == imported
+}
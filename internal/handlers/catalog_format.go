@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"music/internal/models"
+)
+
+// Форматы выгрузки и загрузки каталога
+const (
+	catalogJSON   = "json"
+	catalogCSV    = "csv"
+	catalogNDJSON = "ndjson"
+)
+
+// catalogContentTypes - типы содержимого форматов каталога
+var catalogContentTypes = map[string]string{
+	catalogJSON:   "application/json",
+	catalogCSV:    "text/csv",
+	catalogNDJSON: "application/x-ndjson",
+}
+
+// catalogColumns - столбцы CSV в порядке записи
+var catalogColumns = []string{"artist", "song", "release_date", "link", "text"}
+
+// catalogWriter записывает записи каталога по одной, не накапливая их в памяти
+type catalogWriter interface {
+	Write(record models.CatalogRecord) error
+	// Close дописывает окончание документа и сбрасывает буферы
+	Close() error
+}
+
+func newCatalogWriter(format string, w io.Writer) catalogWriter {
+	switch format {
+	case catalogCSV:
+		return &csvCatalogWriter{w: csv.NewWriter(w)}
+	case catalogNDJSON:
+		return &ndjsonCatalogWriter{encoder: newCatalogEncoder(w)}
+	default:
+		return &jsonCatalogWriter{w: w, encoder: newCatalogEncoder(w)}
+	}
+}
+
+func newCatalogEncoder(w io.Writer) *json.Encoder {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	return encoder
+}
+
+// jsonCatalogWriter записывает JSON-массив записей
+type jsonCatalogWriter struct {
+	w       io.Writer
+	encoder *json.Encoder
+	started bool
+}
+
+func (j *jsonCatalogWriter) Write(record models.CatalogRecord) error {
+	separator := ","
+	if !j.started {
+		separator, j.started = "[", true
+	}
+	if _, err := io.WriteString(j.w, separator); err != nil {
+		return err
+	}
+	return j.encoder.Encode(record)
+}
+
+func (j *jsonCatalogWriter) Close() error {
+	if !j.started {
+		_, err := io.WriteString(j.w, "[]\n")
+		return err
+	}
+	_, err := io.WriteString(j.w, "]\n")
+	return err
+}
+
+// ndjsonCatalogWriter записывает по одной записи JSON на строку
+type ndjsonCatalogWriter struct {
+	encoder *json.Encoder
+}
+
+func (n *ndjsonCatalogWriter) Write(record models.CatalogRecord) error {
+	return n.encoder.Encode(record)
+}
+
+func (n *ndjsonCatalogWriter) Close() error {
+	return nil
+}
+
+// csvCatalogWriter записывает CSV с заголовком; текст песни хранится в столбце text в виде JSON
+type csvCatalogWriter struct {
+	w       *csv.Writer
+	started bool
+}
+
+func (c *csvCatalogWriter) Write(record models.CatalogRecord) error {
+	if !c.started {
+		c.started = true
+		if err := c.w.Write(catalogColumns); err != nil {
+			return err
+		}
+	}
+	var text string
+	if record.Text != nil {
+		raw, err := json.Marshal(record.Text)
+		if err != nil {
+			return err
+		}
+		text = string(raw)
+	}
+	if err := c.w.Write([]string{record.Artist, record.Song, record.ReleaseDate, record.Link, text}); err != nil {
+		return err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvCatalogWriter) Close() error {
+	if !c.started {
+		if err := c.w.Write(catalogColumns); err != nil {
+			return err
+		}
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// catalogRowError - ошибка в отдельной записи; импорт продолжается со следующей
+type catalogRowError struct {
+	err error
+}
+
+func (e *catalogRowError) Error() string {
+	return e.err.Error()
+}
+
+// catalogReader читает записи каталога по одной. После последней записи возвращает io.EOF,
+// для повреждённой записи - *catalogRowError, для повреждённого документа - другую ошибку.
+type catalogReader interface {
+	Next() (models.CatalogRecord, error)
+}
+
+func newCatalogReader(format string, r io.Reader) catalogReader {
+	switch format {
+	case catalogCSV:
+		return &csvCatalogReader{r: csv.NewReader(r)}
+	case catalogNDJSON:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxLyricsSize)
+		return &ndjsonCatalogReader{scanner: scanner}
+	default:
+		return &jsonCatalogReader{decoder: json.NewDecoder(r)}
+	}
+}
+
+// jsonCatalogReader читает JSON-массив записей
+type jsonCatalogReader struct {
+	decoder *json.Decoder
+	started bool
+}
+
+func (j *jsonCatalogReader) Next() (models.CatalogRecord, error) {
+	var record models.CatalogRecord
+	if !j.started {
+		j.started = true
+		token, err := j.decoder.Token()
+		if err != nil {
+			return record, fmt.Errorf("expected JSON array: %w", err)
+		}
+		if delim, ok := token.(json.Delim); !ok || delim != '[' {
+			return record, errors.New("expected JSON array")
+		}
+	}
+	if !j.decoder.More() {
+		if _, err := j.decoder.Token(); err != nil {
+			return record, err
+		}
+		return record, io.EOF
+	}
+
+	err := j.decoder.Decode(&record)
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		// Значение неверного типа прочитано целиком, следующая запись читается с правильного места
+		return record, &catalogRowError{err: err}
+	}
+	return record, err
+}
+
+// ndjsonCatalogReader читает по одной записи JSON на строку, пустые строки пропускаются
+type ndjsonCatalogReader struct {
+	scanner *bufio.Scanner
+}
+
+func (n *ndjsonCatalogReader) Next() (models.CatalogRecord, error) {
+	var record models.CatalogRecord
+	for n.scanner.Scan() {
+		line := n.scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		if err := json.Unmarshal(line, &record); err != nil {
+			return record, &catalogRowError{err: err}
+		}
+		return record, nil
+	}
+	if err := n.scanner.Err(); err != nil {
+		return record, err
+	}
+	return record, io.EOF
+}
+
+// csvCatalogReader читает CSV с заголовком. Порядок столбцов берётся из заголовка, обязательны artist и song.
+type csvCatalogReader struct {
+	r       *csv.Reader
+	columns map[string]int
+}
+
+func (c *csvCatalogReader) Next() (models.CatalogRecord, error) {
+	var record models.CatalogRecord
+	if c.columns == nil {
+		header, err := c.r.Read()
+		if err != nil {
+			if err == io.EOF {
+				return record, errors.New("CSV header is missing")
+			}
+			return record, err
+		}
+		c.columns = make(map[string]int, len(header))
+		for i, name := range header {
+			c.columns[name] = i
+		}
+		for _, required := range []string{"artist", "song"} {
+			if _, ok := c.columns[required]; !ok {
+				return record, fmt.Errorf("CSV header has no %s column", required)
+			}
+		}
+		c.r.FieldsPerRecord = len(header)
+	}
+
+	row, err := c.r.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) && errors.Is(parseErr.Err, csv.ErrFieldCount) {
+			return record, &catalogRowError{err: err}
+		}
+		return record, err
+	}
+
+	field := func(name string) string {
+		if i, ok := c.columns[name]; ok {
+			return row[i]
+		}
+		return ""
+	}
+	record = models.CatalogRecord{
+		Artist:      field("artist"),
+		Song:        field("song"),
+		ReleaseDate: field("release_date"),
+		Link:        field("link"),
+	}
+	if raw := field("text"); raw != "" {
+		var text models.SongText
+		if err := json.Unmarshal([]byte(raw), &text); err != nil {
+			return record, &catalogRowError{err: fmt.Errorf("invalid text: %w", err)}
+		}
+		record.Text = &text
+	}
+	return record, nil
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+
+	"music/internal/models"
+)
+
+// GetDBStatsHandler возвращает состояние пула соединений с базой данных.
+// @Summary Статистика пула соединений
+// @Description Счётчики ожиданий и закрытых соединений растут с момента запуска; по ним видно, хватает ли пулу соединений.
+// @Tags health
+// @Produce json
+// @Success 200 {object} models.DBPoolStats "Состояние пула"
+// @Router /debug/db [get]
+func GetDBStatsHandler(stats func() sql.DBStats) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		current := stats()
+		w.Header().Set("Cache-Control", "no-store")
+		writeJSON(r.Context(), w, http.StatusOK, models.DBPoolStats{
+			MaxOpenConnections: current.MaxOpenConnections,
+			OpenConnections:    current.OpenConnections,
+			InUse:              current.InUse,
+			Idle:               current.Idle,
+			WaitCount:          current.WaitCount,
+			WaitDurationMS:     float64(current.WaitDuration.Microseconds()) / 1000,
+			MaxIdleClosed:      current.MaxIdleClosed,
+			MaxIdleTimeClosed:  current.MaxIdleTimeClosed,
+			MaxLifetimeClosed:  current.MaxLifetimeClosed,
+		})
+	}
+}
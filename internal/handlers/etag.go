@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"music/pkg/logger"
+)
+
+// etag записывает версию песни или исполнителя сильным тегом ETag
+func etag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// setETag отдаёт версию записи в заголовке ETag; вызывается до записи тела ответа
+func setETag(w http.ResponseWriter, version int64) {
+	w.Header().Set("ETag", etag(version))
+}
+
+// checkIfMatch проверяет заголовок If-Match по текущей версии записи и возвращает версию, которую
+// хранилище должно проверить при сохранении: 0, если заголовка нет. Слабые теги W/ никогда не совпадают.
+// При несовпадении отправляет клиенту 412 и возвращает false.
+func checkIfMatch(w http.ResponseWriter, r *http.Request, current int64) (int64, bool) {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return 0, true
+	}
+
+	expected := etag(current)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || tag == expected {
+			return current, true
+		}
+	}
+
+	logger.WarnKV(r.Context(), "If-Match precondition failed", "if_match", header, "etag", expected)
+	w.Header().Set("ETag", expected)
+	http.Error(w, "Precondition Failed: the record has been modified, fetch it again", http.StatusPreconditionFailed)
+	return 0, false
+}
+
+// writeVersionConflict отвечает на ErrVersionConflict: запись изменилась между чтением и сохранением.
+// Если клиент передал If-Match, это нарушение предусловия, иначе запрос можно просто повторить.
+func writeVersionConflict(w http.ResponseWriter, r *http.Request) {
+	logger.WarnKV(r.Context(), "Record modified concurrently", "if_match", r.Header.Get("If-Match"))
+	if r.Header.Get("If-Match") != "" {
+		http.Error(w, "Precondition Failed: the record has been modified, fetch it again", http.StatusPreconditionFailed)
+		return
+	}
+	http.Error(w, "Conflict: the record was modified by another request, retry", http.StatusConflict)
+}
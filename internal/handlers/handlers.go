@@ -1,20 +1,27 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
 	"music/internal/models"
+	"music/internal/repository"
+	"music/internal/songdetails"
 	"music/internal/utils"
 	"music/pkg/logger"
-
-	"github.com/go-chi/chi"
-	"gorm.io/gorm"
+	"music/pkg/lyrics"
 )
 
+// SongDetailsFetcher получает подробности о песне из внешнего API
+type SongDetailsFetcher interface {
+	Fetch(ctx context.Context, group, song string) (*songdetails.Details, error)
+}
+
 // GetInfoHandler godoc
 // @Summary Get API Information
 // @Description Returns general information about the API, including title and version.
@@ -42,92 +49,49 @@ func GetInfoHandler(w http.ResponseWriter, r *http.Request) {
 
 // GetSongsHandler возвращает обработчик HTTP для получения списка песен с поддержкой фильтрации и пагинации.
 // @Summary Получить список песен
-// @Description Получение списка песен с поддержкой фильтрации и пагинации.
+// @Description Получение списка песен с поддержкой фильтрации, сортировки и пагинации. Все фильтры объединяются через AND.
 // @Tags songs
-// @Param field query string false "Поле для фильтрации (song_name, artist_name, release_date)"
-// @Param value query string false "Значение для фильтрации"
-// @Param limit query int false "Количество записей на странице"
+// @Param artist_id query int false "ID исполнителя"
+// @Param artist query string false "Подстрока имени исполнителя"
+// @Param title query string false "Подстрока названия песни"
+// @Param album_id query int false "ID релиза: без sort песни возвращаются в порядке треклиста"
+// @Param release_from query string false "Дата релиза не раньше (YYYY-MM-DD)"
+// @Param release_to query string false "Дата релиза не позже (YYYY-MM-DD)"
+// @Param created_from query string false "Добавлена не раньше (YYYY-MM-DD или RFC 3339)"
+// @Param created_to query string false "Добавлена не позже (YYYY-MM-DD включительно или RFC 3339)"
+// @Param has_lyrics query bool false "Только песни с текстом или без текста"
+// @Param sort query string false "Сортировка: release_date, title, artist, created_at через запятую; минус - по убыванию" example(-release_date,title)
+// @Param field query string false "Устаревший фильтр: поле (song_name, artist_name, release_date)"
+// @Param value query string false "Устаревший фильтр: значение"
+// @Param limit query int false "Количество записей на странице" maximum(100)
 // @Param page query int false "Номер страницы"
+// @Param count query bool false "Считать общее количество песен" default(true)
+// @Param after query string false "Курсор next_cursor из предыдущего ответа; заменяет page"
 // @Success 200 {object} models.SongsResponse "Успешное получение списка песен"
-// @Failure 400 {object} nil "Неверное поле для фильтрации"
+// @Failure 400 {string} string "Некорректный параметр; в ответе указано его имя"
 // @Failure 500 {object} nil "Ошибка на сервере"
 // @Router /songs [get]
-func GetSongsHandler(db *gorm.DB) http.HandlerFunc {
+func GetSongsHandler(songs repository.SongRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 		logger.Info(ctx, "Handling GetSongs request...")
 
-		// Получаем параметры запроса
-		field := r.URL.Query().Get("field")
-		value := r.URL.Query().Get("value")
-
-		// Нормализуем поля фильтрации
-		normalizedField := utils.NormalizeSongName(field)
-		normalizedValue := utils.NormalizeSongName(value)
-
-		// Параметры пагинации
-		limitStr := r.URL.Query().Get("limit")
-		pageStr := r.URL.Query().Get("page")
-
-		limit, err := strconv.Atoi(limitStr)
-		if err != nil || limit < 1 {
-			limit = 10 // Дефолтное количество записей на страницу
-		}
-
-		page, err := strconv.Atoi(pageStr)
-		if err != nil || page < 1 {
-			page = 1 // Дефолтная страница
-		}
-
-		offset := (page - 1) * limit
-
-		logger.DebugKV(ctx, "Filter parameters", "field", normalizedField, "value", normalizedValue)
-		logger.DebugKV(ctx, "Pagination", "limit", limit, "page", page, "offset", offset)
-
-		// Подготавливаем запрос с фильтрацией и пагинацией
-		var songs []models.SongDetail
-		query := db.Model(&models.SongDetail{})
-
-		if normalizedField != "" && normalizedValue != "" {
-			switch normalizedField {
-			case "song_name":
-				// Используем ILIKE для точного соответствия, игнорируя регистр
-				query = query.Where("song_name ILIKE ?", normalizedValue)
-			case "artist_name":
-				query = query.Joins("JOIN artists ON artists.id = song_details.artist_id").
-					Where("artists.name ILIKE ?", "%"+normalizedValue+"%")
-			case "release_date":
-				releaseDate, err := time.Parse("2006-01-02", normalizedValue)
-				if err == nil {
-					query = query.Where("release_date = ?", releaseDate)
-				}
-			default:
-				logger.Error(ctx, "Invalid filter field")
-				http.Error(w, "Invalid filter field", http.StatusBadRequest)
-				return
-			}
-		} else {
-			logger.Debug(ctx, "No filtering parameters provided")
+		filter, err := parseSongFilter(r)
+		if err != nil {
+			logger.DebugKV(ctx, "Invalid song filter", "error", err)
+			http.Error(w, "Bad Request: "+err.Error(), http.StatusBadRequest)
+			return
 		}
 
-		// Пагинация
-		query = query.Limit(limit).Offset(offset)
+		logger.DebugKV(ctx, "Filter parameters", "filter", filter)
 
-		if err := query.Find(&songs).Error; err != nil {
-			logger.Error(ctx, "Error fetching songs")
-			http.Error(w, "Error fetching songs", http.StatusInternalServerError)
+		response, ok := listSongsPage(w, r, songs, filter)
+		if !ok {
 			return
 		}
 
-		logger.DebugKV(ctx, "Fetched songs count", "count", len(songs))
+		logger.DebugKV(ctx, "Fetched songs count", "count", len(response.Songs), "has_next", response.HasNext)
 
-		// Формируем ответ
-		response := models.SongsResponse{
-			TotalItems: len(songs),
-			Page:       page,
-			Limit:      limit,
-			Songs:      songs,
-		}
 		// Отправляем ответ
 		w.Header().Set("Content-Type", "application/json")
 		if err := json.NewEncoder(w).Encode(response); err != nil {
@@ -139,9 +103,10 @@ func GetSongsHandler(db *gorm.DB) http.HandlerFunc {
 	}
 }
 
-// AddSongHandler добавляет новую песню в базу данных.
+// AddSongHandler добавляет новую песню в базу данных и обогащает её данными из внешнего API.
 // @Summary Добавить новую песню
 // @Description Добавляет новую песню к исполнителю. Если исполнитель не существует, он будет создан.
+// @Description Дата релиза, текст и ссылка запрашиваются во внешнем API; при его недоступности песня сохраняется со статусом обогащения pending.
 // @Tags songs
 // @Accept json
 // @Produce json
@@ -151,7 +116,7 @@ func GetSongsHandler(db *gorm.DB) http.HandlerFunc {
 // @Failure 409 {string} string "Песня уже существует"
 // @Failure 500 {string} string "Внутренняя ошибка сервера"
 // @Router /songs [post]
-func AddSongHandler(db *gorm.DB) http.HandlerFunc {
+func AddSongHandler(songs repository.SongRepository, artists repository.ArtistRepository, details SongDetailsFetcher) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 		logger.Debug(ctx, "Entering AddSongHandler")
@@ -174,12 +139,12 @@ func AddSongHandler(db *gorm.DB) http.HandlerFunc {
 		logger.DebugKV(ctx, "Normalized artist name", "artist_name", songInput.Group)
 
 		// Проверка на существование исполнителя
-		var artist models.Artist
-		if err := db.Where("name = ?", songInput.Group).First(&artist).Error; err != nil {
+		artist, err := artists.GetByName(ctx, songInput.Group)
+		if err != nil {
 			logger.DebugKV(ctx, "Artist not found, creating new artist", "artist_name", songInput.Group)
 			// Если исполнитель не существует, создаем нового
-			artist = models.Artist{Name: songInput.Group}
-			if err := db.Create(&artist).Error; err != nil {
+			artist = &models.Artist{Name: songInput.Group}
+			if err := artists.Create(ctx, artist); err != nil {
 				logger.Error(ctx, "Failed to add new artist to database", err)
 				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 				return
@@ -190,8 +155,7 @@ func AddSongHandler(db *gorm.DB) http.HandlerFunc {
 		}
 
 		// Проверка на существование песни с таким названием у данного исполнителя
-		var existingSong models.SongDetail
-		if err := db.Where("song_name = ? AND artist_id = ?", songInput.Song, artist.ID).First(&existingSong).Error; err == nil {
+		if _, err := songs.FindByArtistAndName(ctx, artist.ID, songInput.Song); err == nil {
 			// Песня уже существует
 			logger.Error(ctx, "Song already exists", err)
 			http.Error(w, "Song already exists", http.StatusConflict)
@@ -202,21 +166,26 @@ func AddSongHandler(db *gorm.DB) http.HandlerFunc {
 
 		// Создаем новую песню с минимальной информацией (название и исполнитель)
 		newSong := models.SongDetail{
-			ArtistID:  artist.ID, // Приведение типа
-			SongName:  songInput.Song,
-			GroupName: songInput.Group,
+			ArtistID:         artist.ID, // Приведение типа
+			SongName:         songInput.Song,
+			GroupName:        songInput.Group,
+			EnrichmentStatus: models.EnrichmentPending,
 		}
 
 		logger.DebugKV(ctx, "Creating new song", "new_song", newSong)
 
 		// Сохраняем новую песню в базе данных
-		if err := db.Create(&newSong).Error; err != nil {
+		if err := songs.Create(ctx, &newSong); err != nil {
 			logger.Error(ctx, "Failed to add new song to database", err)
 			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 			return
 		}
 
+		// Обогащаем песню данными из внешнего API, ошибки не мешают сохранению песни
+		enrichSong(ctx, songs, details, &newSong)
+
 		// Возвращаем статус 200 Created
+		setETag(w, newSong.Version)
 		w.WriteHeader(http.StatusOK) // Измените статус на 200 OK
 		if err := json.NewEncoder(w).Encode(newSong); err != nil {
 			logger.Error(ctx, "Failed to encode new song response", err)
@@ -228,37 +197,94 @@ func AddSongHandler(db *gorm.DB) http.HandlerFunc {
 	}
 }
 
-// DeleteSongHandler возвращает обработчик HTTP, который удаляет песню из базы данных по её имени.
+// enrichSong запрашивает подробности о песне и сохраняет их. При ошибке песня остаётся в статусе pending.
+func enrichSong(ctx context.Context, songs repository.SongRepository, details SongDetailsFetcher, song *models.SongDetail) {
+	if details == nil {
+		logger.Debug(ctx, "Song details client is not configured, skipping enrichment")
+		return
+	}
+
+	fetched, err := details.Fetch(ctx, song.GroupName, song.SongName)
+	if err != nil {
+		logger.WarnKV(ctx, "Failed to fetch song details, song left pending enrichment", "song_id", song.ID, "error", err)
+		return
+	}
+
+	enriched := *song
+	if err := fetched.Apply(&enriched); err != nil {
+		logger.WarnKV(ctx, "Invalid song details received, song left pending enrichment", "song_id", song.ID, "error", err)
+		return
+	}
+
+	if err := songs.Update(ctx, &enriched); err != nil {
+		logger.ErrorKV(ctx, "Failed to save song details", "song_id", song.ID, "error", err)
+		return
+	}
+
+	*song = enriched
+	logger.DebugKV(ctx, "Song enriched with external details", "song_id", song.ID)
+}
+
+// GetSongHandler возвращает одну песню.
+// @Summary Получить песню
+// @Description Возвращает песню по ID или по названию. Если названию соответствует несколько песен, возвращается 409 со списком ID.
+// @Description Название из одних цифр /songs/{songName} принимает за ID, такие песни ищутся через /songs/by-name/{songName}.
+// @Tags songs
+// @Produce json
+// @Router /songs/{id} [get]
+// @Router /songs/{songName} [get]
+// @Router /songs/by-name/{songName} [get]
+// @Param id path int false "ID песни"
+// @Param songName path string false "Название песни"
+// @Success 200 {object} models.SongDetail "Песня"
+// @Failure 404 {object} nil "Песня не найдена"
+// @Failure 409 {object} models.AmbiguousSongResponse "Название песни неоднозначно"
+// @Failure 500 {object} nil "Ошибка на сервере"
+func GetSongHandler(lookup SongLookup) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		song, ok := lookup(w, r)
+		if !ok {
+			return
+		}
+		setETag(w, song.Version)
+		writeJSON(r.Context(), w, http.StatusOK, song)
+	}
+}
+
+// DeleteSongHandler возвращает обработчик HTTP, который переносит песню в корзину по ID или по имени.
 // @Summary Удалить песню
+// @Description Песня переносится в корзину вместе с текстом и убирается из релизов и плейлистов; при восстановлении возвращается на прежние позиции.
+// @Description Её можно восстановить через POST /trash/songs/{id}/restore, пока она не удалена из корзины.
+// @Router /songs/{id} [delete]
 // @Router /songs/{songName} [delete]
-// @Param songName path string true "Имя песни для удаления"
+// @Router /songs/by-name/{songName} [delete]
+// @Param id path int false "ID песни для удаления"
+// @Param songName path string false "Имя песни для удаления"
+// @Param If-Match header string false "ETag песни; если песня с тех пор изменилась, возвращается 412"
 // @Success 204 {object} nil "Успешное удаление"
 // @Failure 404 {object} nil "Песня не найдена"
+// @Failure 409 {object} models.AmbiguousSongResponse "Название песни неоднозначно"
+// @Failure 412 {object} nil "Песня изменилась после получения ETag"
 // @Failure 500 {object} nil "Ошибка при удалении песни"
-func DeleteSongHandler(db *gorm.DB) http.HandlerFunc {
+func DeleteSongHandler(lookup SongLookup, songs repository.SongRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
-		songName := chi.URLParam(r, "songName")
 
-		// Используем функцию DecodeURLParameter для декодирования
-		decodedSongName, ok := utils.DecodeURLParameter(ctx, songName, w, "Invalid song name")
+		song, ok := lookup(w, r)
 		if !ok {
 			return
 		}
 
-		// Нормализуем имя песни с помощью utils
-		normalizedSongName := utils.NormalizeSongName(decodedSongName)
-		logger.Debug(ctx, "Нормализуем", "normalizedSongName", normalizedSongName)
-
-		var song models.SongDetail
-		if err := db.Where("song_name = ?", normalizedSongName).First(&song).Error; err != nil {
-			logger.Warn(ctx, "Attempt to delete non-existent song", "songName", normalizedSongName)
-			http.Error(w, "Not Found", http.StatusNotFound)
+		version, ok := checkIfMatch(w, r, song.Version)
+		if !ok {
 			return
 		}
 
-		// Удаляем песню
-		if err := db.Delete(&song).Error; err != nil {
+		// Переносим песню в корзину
+		if err := songs.Delete(ctx, song.ID, version); errors.Is(err, repository.ErrVersionConflict) {
+			writeVersionConflict(w, r)
+			return
+		} else if err != nil {
 			logger.Error(ctx, "Failed to delete song from database", "error", err)
 			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 			return
@@ -269,36 +295,34 @@ func DeleteSongHandler(db *gorm.DB) http.HandlerFunc {
 	}
 }
 
+// @Router /songs/{id} [put]
 // @Router /songs/{songName} [put]
+// @Router /songs/by-name/{songName} [put]
 // @Summary Изменение данных песни
-// @Param songName path string true "Имя песни для обновления"
+// @Param id path int false "ID песни для обновления"
+// @Param songName path string false "Имя песни для обновления"
 // @Param body body models.SongUpdateResponse true "Обновленные данные песни. Все поля являются необязательными."
+// @Param If-Match header string false "ETag песни; если песня с тех пор изменилась, возвращается 412"
 // @Success 200 {object} models.SongUpdateResponse "Успешное обновление песни"
 // @Failure 400 {object} nil "Некорректный запрос"
 // @Failure 404 {object} nil "Песня не найдена"
+// @Failure 409 {object} models.AmbiguousSongResponse "Название песни неоднозначно или песню одновременно изменил другой запрос"
+// @Failure 412 {object} nil "Песня изменилась после получения ETag"
 // @Failure 500 {object} nil "Ошибка при обновлении песни"
-// @Description Обновляет данные существующей песни по имени. Поля, которые не переданы, останутся без изменений.
-func UpdateSongHandler(db *gorm.DB) http.HandlerFunc {
+// @Description Обновляет данные существующей песни по ID или по имени. Поля, которые не переданы, останутся без изменений.
+// @Description Новая версия песни возвращается в заголовке ETag.
+func UpdateSongHandler(lookup SongLookup, songs repository.SongRepository, artists repository.ArtistRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 		logger.Debug(ctx, "Entering UpdateSongHandler")
 
-		// Получаем название песни из URL и декодируем его с помощью DecodeURLParameter
-		songName := chi.URLParam(r, "songName")
-		decodedSongName, ok := utils.DecodeURLParameter(ctx, songName, w, "Invalid song name")
+		// Проверяем, существует ли песня
+		song, ok := lookup(w, r)
 		if !ok {
 			return
 		}
-
-		// Нормализуем название песни через utils
-		normalizedSongName := utils.NormalizeSongName(decodedSongName)
-		logger.Debug(ctx, "Normalized song name from URL param", "songName", normalizedSongName)
-
-		// Проверяем, существует ли песня
-		var song models.SongDetail
-		if err := db.Where("song_name = ?", normalizedSongName).First(&song).Error; err != nil {
-			logger.Warn(ctx, "Attempt to update non-existent song", "songName", normalizedSongName, "error", err)
-			http.Error(w, "Song Not Found", http.StatusNotFound)
+		// Версию песни при сохранении проверяет хранилище, поэтому одновременные правки не затирают друг друга
+		if _, ok := checkIfMatch(w, r, song.Version); !ok {
 			return
 		}
 
@@ -310,7 +334,7 @@ func UpdateSongHandler(db *gorm.DB) http.HandlerFunc {
 		}
 
 		// Проверка на наличие полей для обновления
-		if updatedData.SongName == "" && updatedData.ArtistName == "" && updatedData.GroupLink == "" && len(updatedData.Text.Verses) == 0 && updatedData.ReleaseDate == "" {
+		if updatedData.SongName == "" && updatedData.ArtistName == "" && updatedData.GroupLink == "" && len(updatedData.Text.Verses) == 0 && len(updatedData.Text.Sections) == 0 && updatedData.ReleaseDate == "" {
 			logger.Warn(ctx, "No fields to update")
 			http.Error(w, "Bad Request: No fields to update", http.StatusBadRequest)
 			return
@@ -324,21 +348,22 @@ func UpdateSongHandler(db *gorm.DB) http.HandlerFunc {
 				http.Error(w, "Bad Request: Invalid release date format", http.StatusBadRequest)
 				return
 			}
-			song.ReleaseDate = parsedDate
+			song.ReleaseDate = &parsedDate
 			logger.Debug(ctx, "Release date updated", "newReleaseDate", song.ReleaseDate)
 		}
 
 		// Обновление информации о исполнителе
 		if updatedData.ArtistName != "" {
 			normalizedArtistName := utils.NormalizeSongName(updatedData.ArtistName) // Нормализуем имя исполнителя
-			var artist models.Artist
-			if err := db.Where("name = ?", normalizedArtistName).First(&artist).Error; err != nil {
+			artist, err := artists.GetByName(ctx, normalizedArtistName)
+			if err != nil {
 				// Если исполнитель не найден, возвращаем ошибку
 				logger.Error(ctx, "Artist not found", "artistName", normalizedArtistName)
 				http.Error(w, "Artist Not Found", http.StatusNotFound)
 				return
 			}
 			song.ArtistID = artist.ID
+			song.GroupName = artist.Name
 			logger.Debug(ctx, "Artist ID updated", "artistID", artist.ID)
 		}
 
@@ -355,7 +380,14 @@ func UpdateSongHandler(db *gorm.DB) http.HandlerFunc {
 			logger.Debug(ctx, "Group link updated", "newGroupLink", normalizedGroupLink)
 		}
 
-		if len(updatedData.Text.Verses) > 0 {
+		if len(updatedData.Text.Verses) > 0 || len(updatedData.Text.Sections) > 0 {
+			text, err := normalizeSongText(updatedData.Text)
+			if err != nil {
+				logger.Warn(ctx, "Invalid lyrics sections", "error", err)
+				http.Error(w, "Bad Request: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			updatedData.Text = text
 			textJSON, err := json.Marshal(updatedData.Text)
 			if err != nil {
 				logger.Error(ctx, "Failed to marshal updated text", "error", err)
@@ -370,7 +402,10 @@ func UpdateSongHandler(db *gorm.DB) http.HandlerFunc {
 		logger.Debug(ctx, "Saving song", "song", song)
 
 		// Сохранение обновленной песни в базу данных
-		if err := db.Save(&song).Error; err != nil {
+		if err := songs.Update(ctx, song); errors.Is(err, repository.ErrVersionConflict) {
+			writeVersionConflict(w, r)
+			return
+		} else if err != nil {
 			logger.Error(ctx, "Failed to update song in database", "error", err)
 			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 			return
@@ -380,12 +415,13 @@ func UpdateSongHandler(db *gorm.DB) http.HandlerFunc {
 		response := models.SongUpdateResponse{
 			ArtistName:  updatedData.ArtistName,
 			SongName:    song.SongName,
-			ReleaseDate: song.ReleaseDate.Format("2006.01.02"), // Форматируем обратно в строку
+			ReleaseDate: formatReleaseDate(song.ReleaseDate), // Форматируем обратно в строку
 			GroupLink:   song.SongURL,
 			Text:        updatedData.Text,
 		}
 
 		w.Header().Set("Content-Type", "application/json")
+		setETag(w, song.Version)
 		if err := json.NewEncoder(w).Encode(response); err != nil {
 			logger.Error(ctx, "Failed to encode updated song response", "error", err)
 			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
@@ -397,30 +433,25 @@ func UpdateSongHandler(db *gorm.DB) http.HandlerFunc {
 }
 
 // GetSongLyricsHandler получает текст песни с поддержкой пагинации.
-// @Summary Получение текста песни с пагинацией по куплетам
+// @Summary Получение текста песни с пагинацией по разделам
+// @Router /songs/{id}/lyrics [get]
 // @Router /songs/{songName}/lyrics [get]
-// @Param songName path string true "Имя песни для получения текста"
-// @Param verse_page query int false "Номер страницы куплетов" default(1)
-// @Param verse_limit query int false "Количество куплетов на странице" default(3)
+// @Router /songs/by-name/{songName}/lyrics [get]
+// @Param id path int false "ID песни для получения текста"
+// @Param songName path string false "Имя песни для получения текста"
+// @Param verse_page query int false "Номер страницы разделов" default(1)
+// @Param verse_limit query int false "Количество разделов на странице" default(3)
+// @Param expand query bool false "Выписать повторы полностью; без него передаётся число повторов"
+// @Param format query string false "Формат ответа: разделы со строками или плоский список куплетов" Enums(sections, legacy) default(sections)
 // @Success 200 {object} models.PaginatedLyricsRespons "Успешное получение текста песни"
 // @Failure 400 {object} nil "Некорректный запрос"
-// @Failure 404 {object} nil "Песня не найдена"
+// @Failure 404 {object} nil "Песня не найдена или у неё нет текста"
+// @Failure 409 {object} models.AmbiguousSongResponse "Название песни неоднозначно"
 // @Failure 500 {object} nil "Ошибка при получении текста песни"
-func GetSongLyricsHandler(db *gorm.DB) http.HandlerFunc {
+func GetSongLyricsHandler(lookup SongLookup) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 
-		// Извлекаем и декодируем название песни из параметров маршрута
-		songName := chi.URLParam(r, "songName")
-		decodedSongName, ok := utils.DecodeURLParameter(ctx, songName, w, "Invalid song name")
-		if !ok {
-			return
-		}
-
-		// Нормализуем название песни
-		normalizedSongName := utils.NormalizeSongName(decodedSongName)
-		logger.Debug(ctx, "Request to get song lyrics", "songName", normalizedSongName)
-
 		// Параметры пагинации (страница и лимит куплетов на странице)
 		versePageStr := r.URL.Query().Get("verse_page")
 		verseLimitStr := r.URL.Query().Get("verse_limit")
@@ -439,29 +470,54 @@ func GetSongLyricsHandler(db *gorm.DB) http.HandlerFunc {
 
 		logger.Debug(ctx, "Pagination params", "versePage", versePage, "verseLimit", verseLimit)
 
+		expand, err := parseBoolQuery(r, "expand")
+		if err != nil {
+			http.Error(w, "Bad Request: invalid expand", http.StatusBadRequest)
+			return
+		}
+
+		format := r.URL.Query().Get("format")
+		switch format {
+		case "":
+			format = models.LyricsFormatSections
+		case models.LyricsFormatSections, models.LyricsFormatLegacy:
+		default:
+			http.Error(w, "Bad Request: invalid format, expected sections or legacy", http.StatusBadRequest)
+			return
+		}
+
 		// Поиск песни в базе данных
-		var song models.SongDetail
-		if err := db.Where("song_name = ?", normalizedSongName).First(&song).Error; err != nil {
-			logger.Warn(ctx, "Song not found", "songName", normalizedSongName)
-			http.Error(w, "Song not found", http.StatusNotFound)
+		song, ok := lookup(w, r)
+		if !ok {
 			return
 		}
 
 		// Проверяем текст песни, предполагая, что он уже разделен на куплеты
 		logger.Debug(ctx, "Raw song text", "rawText", fmt.Sprintf("%q", song.Text))
 
-		// Преобразуем текст в структуру SongText
-		var lyrics models.SongText
-		if err := json.Unmarshal([]byte(song.Text), &lyrics); err != nil {
+		// Преобразуем текст в структуру SongText; пустой текст означает, что слов у песни нет
+		songText, err := models.ParseSongText(song.Text)
+		if err != nil {
 			logger.Error(ctx, "Failed to unmarshal song text", "error", err)
 			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 			return
 		}
+		if len(songText.Sections) == 0 {
+			logger.Warn(ctx, "Song has no lyrics", "songID", song.ID)
+			http.Error(w, "Song has no lyrics", http.StatusNotFound)
+			return
+		}
 
-		logger.Debug(ctx, "Lyrics retrieved", "totalVerses", len(lyrics.Verses))
+		// В развёрнутом виде повторы выписываются полностью, иначе передаются числом повторов
+		sections := songText.Sections
+		if expand {
+			sections = lyrics.ExpandSections(sections)
+		}
+
+		logger.Debug(ctx, "Lyrics retrieved", "totalSections", len(sections), "expand", expand, "format", format)
 
-		// Пагинация по куплетам
-		totalVerses := len(lyrics.Verses)
+		// Пагинация по разделам
+		totalVerses := len(sections)
 		start := (versePage - 1) * verseLimit
 		end := start + verseLimit
 
@@ -475,16 +531,21 @@ func GetSongLyricsHandler(db *gorm.DB) http.HandlerFunc {
 			end = totalVerses
 		}
 
-		paginatedVerses := lyrics.Verses[start:end]
-		logger.Debug(ctx, "Paginated verses", "start", start, "end", end)
+		logger.Debug(ctx, "Paginated sections", "start", start, "end", end)
 
-		// Формируем ответ с куплетами и пагинацией
+		// Формируем ответ с разделами и пагинацией
 		response := models.PaginatedLyricsRespons{
 			SongName:    song.SongName,
 			VersePage:   versePage,
 			VerseLimit:  verseLimit,
 			TotalVerses: totalVerses,
-			Verses:      paginatedVerses,
+			Format:      format,
+			Expanded:    expand,
+		}
+		if format == models.LyricsFormatLegacy {
+			response.Verses = lyrics.Verses(sections[start:end])
+		} else {
+			response.Sections = sections[start:end]
 		}
 
 		// Отправляем ответ в формате JSON
@@ -495,6 +556,19 @@ func GetSongLyricsHandler(db *gorm.DB) http.HandlerFunc {
 			return
 		}
 
-		logger.Info(ctx, "Song lyrics retrieved successfully", "songName", normalizedSongName)
+		logger.Info(ctx, "Song lyrics retrieved successfully", "songID", song.ID)
+	}
+}
+
+// normalizeSongText приводит текст из запроса к хранимому виду. Текст хранится разделами;
+// в плоских куплетах разбираются заголовки и пометки о повторе.
+func normalizeSongText(text models.SongText) (models.SongText, error) {
+	if len(text.Sections) > 0 {
+		sections, err := lyrics.Validate(text.Sections)
+		if err != nil {
+			return models.SongText{}, err
+		}
+		return models.NewSongText(sections), nil
 	}
+	return models.NewSongText(lyrics.ParseVerses(text.Verses)), nil
 }
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"net/http"
+
+	"music/internal/health"
+)
+
+// GetHealthzHandler сообщает, что процесс запущен и отвечает на запросы.
+// @Summary Проверка живости
+// @Description Не проверяет зависимости: отвечает 200, пока процесс обслуживает HTTP, в том числе во время остановки.
+// @Tags health
+// @Produce json
+// @Success 200 {object} map[string]string "Процесс запущен"
+// @Router /healthz [get]
+func GetHealthzHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(r.Context(), w, http.StatusOK, map[string]string{"status": health.StatusOK})
+}
+
+// GetReadyzHandler проверяет, готово ли приложение принимать запросы.
+// @Summary Проверка готовности
+// @Description Проверяет доступность базы данных и совпадение версии схемы с миграциями приложения.
+// @Description Для каждой проверки возвращается статус и длительность. Во время остановки проверки не выполняются.
+// @Tags health
+// @Produce json
+// @Success 200 {object} health.Report "Приложение готово"
+// @Failure 503 {object} health.Report "Проверка не прошла или приложение останавливается"
+// @Router /readyz [get]
+func GetReadyzHandler(checker *health.Checker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Без проверок, например в тестах с хранилищем в памяти, приложение всегда готово
+		report := health.Report{Status: health.StatusOK, Checks: []health.CheckResult{}}
+		if checker != nil {
+			report = checker.Check(r.Context())
+		}
+
+		status := http.StatusOK
+		if !report.Ready() {
+			status = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		writeJSON(r.Context(), w, status, report)
+	}
+}
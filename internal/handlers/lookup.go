@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"music/internal/models"
+	"music/internal/repository"
+	"music/internal/utils"
+	"music/pkg/logger"
+
+	"github.com/go-chi/chi"
+)
+
+// SongLookup находит песню, к которой обращается запрос. Если песню определить нельзя,
+// функция сама отправляет ответ клиенту и возвращает false.
+type SongLookup func(w http.ResponseWriter, r *http.Request) (*models.SongDetail, bool)
+
+// SongByID ищет песню по числовому параметру маршрута {id}
+func SongByID(songs repository.SongRepository) SongLookup {
+	return func(w http.ResponseWriter, r *http.Request) (*models.SongDetail, bool) {
+		ctx := r.Context()
+
+		id, ok := parseIDParam(w, r, "id")
+		if !ok {
+			return nil, false
+		}
+
+		song, err := songs.GetByID(ctx, id)
+		if errors.Is(err, repository.ErrNotFound) {
+			logger.WarnKV(ctx, "Song not found", "id", id)
+			http.Error(w, "Song Not Found", http.StatusNotFound)
+			return nil, false
+		}
+		if err != nil {
+			logger.ErrorKV(ctx, "Failed to fetch song", "id", id, "error", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return nil, false
+		}
+		return song, true
+	}
+}
+
+// SongByName ищет песню по параметру маршрута {songName}. Если песен с таким названием
+// несколько, клиент получает 409 со списком ID кандидатов.
+func SongByName(songs repository.SongRepository) SongLookup {
+	return func(w http.ResponseWriter, r *http.Request) (*models.SongDetail, bool) {
+		ctx := r.Context()
+
+		decodedSongName, ok := utils.DecodeURLParameter(ctx, chi.URLParam(r, "songName"), w, "Invalid song name")
+		if !ok {
+			return nil, false
+		}
+
+		// Нормализуем название песни
+		normalizedSongName := utils.NormalizeSongName(decodedSongName)
+		logger.DebugKV(ctx, "Looking up song by name", "songName", normalizedSongName)
+
+		found, err := songs.FindByName(ctx, normalizedSongName)
+		if err != nil {
+			logger.ErrorKV(ctx, "Failed to fetch song", "songName", normalizedSongName, "error", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return nil, false
+		}
+
+		switch len(found) {
+		case 0:
+			logger.WarnKV(ctx, "Song not found", "songName", normalizedSongName)
+			http.Error(w, "Song Not Found", http.StatusNotFound)
+			return nil, false
+		case 1:
+			return &found[0], true
+		}
+
+		response := models.AmbiguousSongResponse{
+			Error:        "song name is ambiguous, use /songs/{id}",
+			SongName:     normalizedSongName,
+			CandidateIDs: make([]uint, 0, len(found)),
+		}
+		for i := range found {
+			response.CandidateIDs = append(response.CandidateIDs, found[i].ID)
+		}
+
+		logger.WarnKV(ctx, "Ambiguous song name", "songName", normalizedSongName, "candidates", response.CandidateIDs)
+		writeJSON(ctx, w, http.StatusConflict, response)
+		return nil, false
+	}
+}
+
+// parseIDParam разбирает числовой параметр маршрута
+func parseIDParam(w http.ResponseWriter, r *http.Request, name string) (uint, bool) {
+	raw := chi.URLParam(r, name)
+	id, err := strconv.ParseUint(raw, 10, 0)
+	if err != nil || id == 0 {
+		logger.WarnKV(r.Context(), "Invalid id parameter", "param", name, "value", raw)
+		http.Error(w, "Bad Request: Invalid "+name, http.StatusBadRequest)
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"music/internal/models"
+	"music/internal/repository"
+	"music/pkg/logger"
+	"music/pkg/lyrics"
+)
+
+// Количество следующих строк в ответе GET /songs/{id}/lyrics/at
+const (
+	defaultNextLines = 3
+	maxNextLines     = 50
+)
+
+// maxPosition ограничивает момент песни, заданный числом секунд или минутами и секундами,
+// чтобы он не переполнил time.Duration
+const maxPosition = 24 * time.Hour
+
+// ImportLRCHandler заменяет текст песни текстом из файла LRC с моментами начала строк.
+// @Summary Загрузить текст песни в формате LRC
+// @Description Принимает файл LRC: заголовки [ar:], [ti:], [offset:] и строки с одной или несколькими метками времени.
+// @Description Разделы отделяются пустыми строками, паузами без текста или заголовками вроде [Припев].
+// @Tags songs
+// @Accept plain
+// @Produce json
+// @Param id path int true "ID песни"
+// @Param lrc body string true "Файл LRC"
+// @Success 200 {object} models.SongText "Сохранённый текст"
+// @Failure 400 {string} string "Файл не разобран или в нём нет меток времени"
+// @Failure 404 {string} string "Песня не найдена"
+// @Failure 413 {string} string "Слишком большой файл"
+// @Failure 415 {string} string "Тело запроса не text/plain"
+// @Router /songs/{id}/lyrics/lrc [post]
+func ImportLRCHandler(lookup SongLookup, songs repository.SongRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil || (mediaType != "text/plain" && mediaType != "text/x-lrc" && mediaType != "application/x-lrc") {
+			http.Error(w, "Unsupported Media Type: expected text/plain", http.StatusUnsupportedMediaType)
+			return
+		}
+
+		song, ok := lookup(w, r)
+		if !ok {
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxLyricsSize))
+		if err != nil {
+			http.Error(w, "Request Entity Too Large", http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		parsed, err := lyrics.ParseLRC(string(body))
+		if err != nil {
+			http.Error(w, "Bad Request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if len(lyrics.Timeline(parsed.Sections)) == 0 {
+			http.Error(w, "Bad Request: LRC has no timed lines", http.StatusBadRequest)
+			return
+		}
+		// Исполнитель и название берутся из карточки песни; расхождение с файлом только логируется
+		if artist, title := parsed.Tags["ar"], parsed.Tags["ti"]; (artist != "" && !strings.EqualFold(artist, song.GroupName)) ||
+			(title != "" && !strings.EqualFold(title, song.SongName)) {
+			logger.WarnKV(ctx, "LRC headers do not match song", "song_id", song.ID, "ar", artist, "ti", title)
+		}
+
+		text := models.NewSongText(parsed.Sections)
+		textJSON, err := json.Marshal(text)
+		if err != nil {
+			logger.Error(ctx, "Failed to marshal imported lyrics", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		song.Text = string(textJSON)
+
+		if err := songs.Update(ctx, song); errors.Is(err, repository.ErrVersionConflict) {
+			writeVersionConflict(w, r)
+			return
+		} else if err != nil {
+			logger.Error(ctx, "Failed to save imported lyrics", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+
+		logger.InfoKV(ctx, "Song lyrics imported from LRC", "song_id", song.ID, "sections", len(text.Sections))
+		writeJSON(ctx, w, http.StatusOK, text)
+	}
+}
+
+// ExportLRCHandler отдаёт текст песни в формате LRC.
+// @Summary Выгрузить текст песни в формате LRC
+// @Description Заголовки [ar:] и [ti:] заполняются из карточки песни, строки без меток времени записываются без них.
+// @Tags songs
+// @Produce plain
+// @Param id path int true "ID песни"
+// @Success 200 {string} string "Файл LRC"
+// @Failure 404 {string} string "Песня не найдена или у неё нет синхронизированного текста"
+// @Router /songs/{id}/lyrics/lrc [get]
+func ExportLRCHandler(lookup SongLookup) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		song, ok := lookup(w, r)
+		if !ok {
+			return
+		}
+		text, ok := syncedSongText(w, r, song)
+		if !ok {
+			return
+		}
+
+		tags := map[string]string{"ar": song.GroupName, "ti": song.SongName}
+		filename := song.SongName + ".lrc"
+		if song.GroupName != "" {
+			filename = song.GroupName + " - " + filename
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+		if _, err := io.WriteString(w, lyrics.FormatLRC(tags, text.Sections)); err != nil {
+			logger.Error(ctx, "Failed to write LRC", err)
+		}
+	}
+}
+
+// GetLyricsAtHandler возвращает строку, которая звучит в заданный момент песни, и несколько следующих.
+// @Summary Строка текста в заданный момент песни
+// @Tags songs
+// @Produce json
+// @Param id path int true "ID песни"
+// @Param position query string true "Момент песни: 83.5s, 1m23.5s, 83.5 (секунды) или 1:23.5"
+// @Param next query int false "Количество следующих строк" default(3)
+// @Success 200 {object} models.LyricsAtResponse
+// @Failure 400 {string} string "Неверные параметры"
+// @Failure 404 {string} string "Песня не найдена или у неё нет синхронизированного текста"
+// @Router /songs/{id}/lyrics/at [get]
+func GetLyricsAtHandler(lookup SongLookup) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		position, err := parsePosition(r.URL.Query().Get("position"))
+		if err != nil {
+			http.Error(w, "Bad Request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		next := defaultNextLines
+		if raw := r.URL.Query().Get("next"); raw != "" {
+			if next, err = strconv.Atoi(raw); err != nil || next < 0 || next > maxNextLines {
+				http.Error(w, "Bad Request: invalid next, expected 0-"+strconv.Itoa(maxNextLines), http.StatusBadRequest)
+				return
+			}
+		}
+
+		song, ok := lookup(w, r)
+		if !ok {
+			return
+		}
+		text, ok := syncedSongText(w, r, song)
+		if !ok {
+			return
+		}
+
+		current, following := lyrics.At(lyrics.Timeline(text.Sections), position.Milliseconds(), next)
+		writeJSON(ctx, w, http.StatusOK, models.LyricsAtResponse{
+			SongName: song.SongName,
+			Position: position.Milliseconds(),
+			Current:  current,
+			Next:     following,
+		})
+	}
+}
+
+// syncedSongText читает текст песни и проверяет, что у строк есть моменты начала.
+// При ошибке ответ уже записан.
+func syncedSongText(w http.ResponseWriter, r *http.Request, song *models.SongDetail) (models.SongText, bool) {
+	text, err := models.ParseSongText(song.Text)
+	if err != nil {
+		logger.Error(r.Context(), "Failed to unmarshal song text", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return text, false
+	}
+	if len(lyrics.Timeline(text.Sections)) == 0 {
+		http.Error(w, "Song has no synced lyrics", http.StatusNotFound)
+		return text, false
+	}
+	return text, true
+}
+
+// parsePosition разбирает момент песни: длительность Go (83.5s), число секунд (83.5) или минуты и секунды (1:23.5)
+func parsePosition(raw string) (time.Duration, error) {
+	if raw == "" {
+		return 0, &queryParamError{param: "position", reason: "is required"}
+	}
+	invalid := &queryParamError{param: "position", reason: "expected duration like 83.5s or 1:23.5"}
+
+	var position time.Duration
+	if minutes, seconds, ok := strings.Cut(raw, ":"); ok {
+		m, err := strconv.ParseUint(minutes, 10, 32)
+		// Минуты проверяются до умножения: 4000000000 минут переполнили бы time.Duration
+		if err != nil || m > uint64(maxPosition/time.Minute) {
+			return 0, invalid
+		}
+		s, err := strconv.ParseFloat(seconds, 64)
+		if err != nil || s < 0 || s >= 60 {
+			return 0, invalid
+		}
+		position = time.Duration(m)*time.Minute + time.Duration(s*float64(time.Second))
+		if position > maxPosition {
+			return 0, invalid
+		}
+	} else if seconds, err := strconv.ParseFloat(raw, 64); err == nil {
+		if math.IsNaN(seconds) || math.Abs(seconds) > maxPosition.Seconds() {
+			return 0, invalid
+		}
+		position = time.Duration(seconds * float64(time.Second))
+	} else if position, err = time.ParseDuration(raw); err != nil {
+		return 0, invalid
+	}
+
+	if position < 0 {
+		return 0, &queryParamError{param: "position", reason: "must not be negative"}
+	}
+	return position, nil
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"music/internal/models"
+	"music/internal/repository"
+	"music/pkg/logger"
+	"music/pkg/lyrics"
+)
+
+// maxLyricsSize ограничивает размер загружаемого текста песни
+const maxLyricsSize = 1 << 20
+
+// UploadSongLyricsHandler заменяет текст песни текстом из тела запроса.
+// @Summary Загрузить текст песни
+// @Description Принимает текст в формате text/plain. Разделы отделяются пустыми строками или заголовками
+// @Description вроде [Припев], [Bridge] или "Куплет 2:", по которым определяется тип раздела.
+// @Description Пометки вида (х3) в конце строки или на отдельной строке сохраняются как число повторов строки или куплета.
+// @Tags songs
+// @Accept plain
+// @Produce json
+// @Param id path int true "ID песни"
+// @Param keep_line_breaks query bool false "Сохранять строки внутри разделов; false склеивает их через пробел" default(true)
+// @Param trim_repeats query bool false "Отбрасывать пометки о повторе вместо сохранения числа повторов"
+// @Param lyrics body string true "Текст песни"
+// @Success 200 {object} models.SongText "Сохранённый текст"
+// @Failure 400 {string} string "Пустой текст или неверные параметры"
+// @Failure 404 {string} string "Песня не найдена"
+// @Failure 413 {string} string "Слишком большой текст"
+// @Failure 415 {string} string "Тело запроса не text/plain"
+// @Router /songs/{id}/lyrics [post]
+func UploadSongLyricsHandler(lookup SongLookup, songs repository.SongRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "text/plain" {
+			http.Error(w, "Unsupported Media Type: expected text/plain", http.StatusUnsupportedMediaType)
+			return
+		}
+
+		opts := lyrics.Options{KeepLineBreaks: true}
+		var err error
+		if raw := r.URL.Query().Get("keep_line_breaks"); raw != "" {
+			if opts.KeepLineBreaks, err = strconv.ParseBool(raw); err != nil {
+				http.Error(w, "Bad Request: invalid keep_line_breaks", http.StatusBadRequest)
+				return
+			}
+		}
+		if opts.TrimRepeats, err = parseBoolQuery(r, "trim_repeats"); err != nil {
+			http.Error(w, "Bad Request: invalid trim_repeats", http.StatusBadRequest)
+			return
+		}
+
+		song, ok := lookup(w, r)
+		if !ok {
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxLyricsSize))
+		if err != nil {
+			http.Error(w, "Request Entity Too Large", http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		sections := lyrics.Parse(string(body))
+		if opts.TrimRepeats {
+			sections = lyrics.DropRepeats(sections)
+		}
+		if !opts.KeepLineBreaks {
+			sections = lyrics.MergeLines(sections)
+		}
+		text := models.NewSongText(sections)
+		if len(text.Sections) == 0 {
+			http.Error(w, "Bad Request: lyrics are empty", http.StatusBadRequest)
+			return
+		}
+
+		textJSON, err := json.Marshal(text)
+		if err != nil {
+			logger.Error(ctx, "Failed to marshal uploaded lyrics", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		song.Text = string(textJSON)
+
+		if err := songs.Update(ctx, song); errors.Is(err, repository.ErrVersionConflict) {
+			writeVersionConflict(w, r)
+			return
+		} else if err != nil {
+			logger.Error(ctx, "Failed to save uploaded lyrics", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+
+		logger.InfoKV(ctx, "Song lyrics uploaded", "song_id", song.ID, "sections", len(text.Sections))
+		writeJSON(ctx, w, http.StatusOK, text)
+	}
+}
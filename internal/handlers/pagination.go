@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"music/internal/models"
+	"music/internal/repository"
+	"music/pkg/logger"
+)
+
+const (
+	defaultPageLimit = 10
+	// maxPageLimit ограничивает размер страницы, чтобы один запрос не выгружал всю таблицу
+	maxPageLimit = 100
+	// maxPage не даёт смещению (page-1)*limit переполниться
+	maxPage = math.MaxInt32
+)
+
+// parsePagination читает параметры limit и page. Некорректные значения заменяются значениями по умолчанию,
+// слишком большие - наибольшими допустимыми.
+func parsePagination(r *http.Request) (limit, page, offset int) {
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit < 1 {
+		limit = defaultPageLimit // Дефолтное количество записей на страницу
+	}
+	limit = min(limit, maxPageLimit)
+
+	page, err = strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page < 1 {
+		page = 1 // Дефолтная страница
+	}
+	page = min(page, maxPage)
+
+	return limit, page, (page - 1) * limit
+}
+
+// listSongsPage получает страницу песен по фильтру с учётом параметров count и after и формирует ответ.
+// При ошибке ответ уже отправлен клиенту.
+func listSongsPage(w http.ResponseWriter, r *http.Request, songs repository.SongRepository, filter repository.SongFilter) (*models.SongsResponse, bool) {
+	ctx := r.Context()
+	limit, page, offset := parsePagination(r)
+
+	if raw := r.URL.Query().Get("count"); raw != "" {
+		count, err := strconv.ParseBool(raw)
+		if err != nil {
+			http.Error(w, "Bad Request: invalid count: expected true or false", http.StatusBadRequest)
+			return nil, false
+		}
+		filter.SkipTotal = !count
+	}
+
+	// В режиме курсора номер страницы не используется
+	if raw := r.URL.Query().Get("after"); raw != "" {
+		cursor, err := repository.DecodeSongCursor(raw, filter.Sort)
+		if err != nil {
+			http.Error(w, "Bad Request: invalid after: cursor is malformed or was issued for another sort", http.StatusBadRequest)
+			return nil, false
+		}
+		filter.After = cursor
+		page, offset = 0, 0
+	}
+
+	// Одна лишняя песня показывает, есть ли следующая страница, без отдельного запроса
+	filter.Limit, filter.Offset = limit+1, offset
+	list, total, err := songs.List(ctx, filter)
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		http.Error(w, "Bad Request: invalid after: cursor pagination over album track order is not supported, pass sort", http.StatusBadRequest)
+		return nil, false
+	}
+	if err != nil {
+		logger.Error(ctx, "Error fetching songs", err)
+		http.Error(w, "Error fetching songs", http.StatusInternalServerError)
+		return nil, false
+	}
+
+	response := &models.SongsResponse{Page: page, Limit: limit, Songs: list}
+	if len(list) > limit {
+		response.Songs = list[:limit]
+		response.HasNext = true
+		// Курсор по треклисту релиза не поддерживается
+		if filter.AlbumID == 0 || len(filter.Sort) > 0 {
+			response.NextCursor = repository.NewSongCursor(list[limit-1], filter.Sort).Encode()
+		}
+	}
+	if !filter.SkipTotal {
+		totalPages := int((total + int64(limit) - 1) / int64(limit))
+		response.TotalItems = &total
+		response.TotalPages = &totalPages
+	}
+	return response, true
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"time"
+
+	"music/internal/models"
+	"music/internal/repository"
+	"music/internal/utils"
+	"music/pkg/logger"
+)
+
+// mergePatchMediaType - тип содержимого документа JSON Merge Patch
+const mergePatchMediaType = "application/merge-patch+json"
+
+// PatchSongHandler частично изменяет песню по правилам JSON Merge Patch (RFC 7396).
+// @Summary Частично изменить песню
+// @Description Отсутствующие в документе поля не меняются, null очищает поле, значение заменяет его.
+// @Description Очистить можно дату релиза, ссылку и текст; название и исполнитель обязательны. Текст заменяется целиком.
+// @Description Пустой документ {} ничего не меняет. В ответе - песня целиком, новая версия - в заголовке ETag.
+// @Tags songs
+// @Accept json
+// @Accept application/merge-patch+json
+// @Produce json
+// @Param id path int true "ID песни"
+// @Param patch body models.SongPatch true "Изменения песни"
+// @Param If-Match header string false "ETag песни; если песня с тех пор изменилась, возвращается 412"
+// @Success 200 {object} models.SongDetail "Песня после изменения"
+// @Failure 400 {object} nil "Некорректный документ"
+// @Failure 404 {object} nil "Песня или исполнитель не найдены"
+// @Failure 409 {object} nil "У исполнителя уже есть песня с таким названием или песню одновременно изменил другой запрос"
+// @Failure 412 {object} nil "Песня изменилась после получения ETag"
+// @Failure 415 {object} nil "Неподдерживаемый тип содержимого"
+// @Router /songs/{id} [patch]
+func PatchSongHandler(lookup SongLookup, songs repository.SongRepository, artists repository.ArtistRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil ||
+			(mediaType != mergePatchMediaType && mediaType != "application/json") {
+			http.Error(w, "Unsupported Media Type: expected "+mergePatchMediaType, http.StatusUnsupportedMediaType)
+			return
+		}
+
+		song, ok := lookup(w, r)
+		if !ok {
+			return
+		}
+		if _, ok := checkIfMatch(w, r, song.Version); !ok {
+			return
+		}
+
+		// Документ, который не является объектом, заменил бы песню целиком; такие правки не поддерживаются
+		var patch models.SongPatch
+		decoder := json.NewDecoder(r.Body)
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&patch); err != nil {
+			logger.WarnKV(ctx, "Invalid merge patch", "error", err)
+			http.Error(w, "Bad Request: expected a JSON object with artist_name, song_name, release_date, group_link or text", http.StatusBadRequest)
+			return
+		}
+
+		original := *song
+		if err := applySongPatch(r, artists, song, &patch); err != nil {
+			var invalid *queryParamError
+			switch {
+			case errors.As(err, &invalid):
+				http.Error(w, "Bad Request: "+err.Error(), http.StatusBadRequest)
+			case errors.Is(err, repository.ErrNotFound):
+				http.Error(w, "Artist Not Found", http.StatusNotFound)
+			default:
+				logger.Error(ctx, "Failed to apply song patch", err)
+				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		// Документ без изменений не увеличивает версию песни
+		var err error
+		if *song != original {
+			err = songs.Update(ctx, song)
+		}
+		switch {
+		case errors.Is(err, repository.ErrVersionConflict):
+			writeVersionConflict(w, r)
+			return
+		case errors.Is(err, repository.ErrAlreadyExists):
+			http.Error(w, "Conflict: the artist already has a song with this name", http.StatusConflict)
+			return
+		case err != nil:
+			logger.Error(ctx, "Failed to save patched song", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+
+		logger.InfoKV(ctx, "Song patched", "song_id", song.ID, "version", song.Version)
+		setETag(w, song.Version)
+		writeJSON(ctx, w, http.StatusOK, song)
+	}
+}
+
+// applySongPatch переносит изменения из документа в песню. Ошибки в данных документа
+// возвращаются как *queryParamError с именем поля.
+func applySongPatch(r *http.Request, artists repository.ArtistRepository, song *models.SongDetail, patch *models.SongPatch) error {
+	if patch.ArtistName.Set {
+		name := utils.NormalizeSongName(patch.ArtistName.Value)
+		if patch.ArtistName.Null || name == "" {
+			return &queryParamError{param: "artist_name", reason: "cannot be cleared"}
+		}
+		artist, err := artists.GetByName(r.Context(), name)
+		if err != nil {
+			return err
+		}
+		song.ArtistID, song.GroupName = artist.ID, artist.Name
+	}
+
+	if patch.SongName.Set {
+		name := utils.NormalizeSongName(patch.SongName.Value)
+		if patch.SongName.Null || name == "" {
+			return &queryParamError{param: "song_name", reason: "cannot be cleared"}
+		}
+		song.SongName = name
+	}
+
+	if patch.ReleaseDate.Set {
+		song.ReleaseDate = nil
+		if !patch.ReleaseDate.Null {
+			releaseDate, err := time.Parse("2006.01.02", patch.ReleaseDate.Value)
+			if err != nil {
+				return &queryParamError{param: "release_date", reason: "expected format YYYY.MM.DD or null"}
+			}
+			song.ReleaseDate = &releaseDate
+		}
+	}
+
+	if patch.GroupLink.Set {
+		song.SongURL = ""
+		if !patch.GroupLink.Null {
+			song.SongURL = utils.NormalizeSongName(patch.GroupLink.Value)
+		}
+	}
+
+	if patch.Text.Set {
+		song.Text = ""
+		if !patch.Text.Null {
+			text, err := normalizeSongText(patch.Text.Value)
+			if err != nil {
+				return &queryParamError{param: "text", reason: err.Error()}
+			}
+			if len(text.Sections) > 0 {
+				textJSON, err := json.Marshal(text)
+				if err != nil {
+					return err
+				}
+				song.Text = string(textJSON)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,327 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"music/internal/models"
+	"music/internal/repository"
+	"music/internal/utils"
+	"music/pkg/logger"
+)
+
+// GetPlaylistsHandler возвращает список плейлистов с пагинацией.
+// @Summary Получить список плейлистов
+// @Tags playlists
+// @Produce json
+// @Param owner query string false "Владелец плейлиста"
+// @Param limit query int false "Количество записей на странице" maximum(100)
+// @Param page query int false "Номер страницы"
+// @Success 200 {object} models.PlaylistsResponse "Список плейлистов"
+// @Failure 500 {object} nil "Ошибка на сервере"
+// @Router /playlists [get]
+func GetPlaylistsHandler(playlists repository.PlaylistRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		limit, page, offset := parsePagination(r)
+
+		filter := repository.PlaylistFilter{Owner: r.URL.Query().Get("owner"), Limit: limit, Offset: offset}
+		list, total, err := playlists.List(ctx, filter)
+		if err != nil {
+			logger.Error(ctx, "Error fetching playlists", err)
+			http.Error(w, "Error fetching playlists", http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(ctx, w, http.StatusOK, models.PlaylistsResponse{
+			TotalItems: total,
+			Page:       page,
+			Limit:      limit,
+			Playlists:  list,
+		})
+	}
+}
+
+// GetPlaylistHandler возвращает плейлист с постраничным списком песен.
+// @Summary Получить плейлист
+// @Tags playlists
+// @Produce json
+// @Param id path int true "ID плейлиста"
+// @Param limit query int false "Количество песен на странице" maximum(100)
+// @Param page query int false "Номер страницы"
+// @Success 200 {object} models.PlaylistResponse "Плейлист"
+// @Failure 404 {object} nil "Плейлист не найден"
+// @Router /playlists/{id} [get]
+func GetPlaylistHandler(playlists repository.PlaylistRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		id, ok := parseIDParam(w, r, "id")
+		if !ok {
+			return
+		}
+
+		playlist, err := playlists.GetByID(ctx, id)
+		if err != nil {
+			writePlaylistError(w, r, err)
+			return
+		}
+
+		limit, page, offset := parsePagination(r)
+		entries, total, err := playlists.Entries(ctx, id, limit, offset)
+		if err != nil {
+			writePlaylistError(w, r, err)
+			return
+		}
+
+		writeJSON(ctx, w, http.StatusOK, models.PlaylistResponse{
+			Playlist:   *playlist,
+			TotalItems: total,
+			Page:       page,
+			Limit:      limit,
+			Items:      entries,
+		})
+	}
+}
+
+// AddPlaylistHandler создаёт плейлист.
+// @Summary Создать плейлист
+// @Tags playlists
+// @Accept json
+// @Produce json
+// @Param playlist body models.PlaylistInput true "Плейлист"
+// @Success 201 {object} models.Playlist "Плейлист создан"
+// @Failure 400 {string} string "Неверный запрос"
+// @Router /playlists [post]
+func AddPlaylistHandler(playlists repository.PlaylistRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		input, ok := decodePlaylistInput(w, r)
+		if !ok {
+			return
+		}
+
+		playlist := models.Playlist{Name: input.Name, Owner: input.Owner}
+		if err := playlists.Create(ctx, &playlist); err != nil {
+			writePlaylistError(w, r, err)
+			return
+		}
+
+		logger.InfoKV(ctx, "New playlist created", "playlist_id", playlist.ID)
+		writeJSON(ctx, w, http.StatusCreated, playlist)
+	}
+}
+
+// RenamePlaylistHandler переименовывает плейлист.
+// @Summary Переименовать плейлист
+// @Tags playlists
+// @Accept json
+// @Produce json
+// @Param id path int true "ID плейлиста"
+// @Param playlist body models.PlaylistInput true "Новое название"
+// @Success 200 {object} models.Playlist "Плейлист переименован"
+// @Failure 400 {string} string "Неверный запрос"
+// @Failure 404 {string} string "Плейлист не найден"
+// @Router /playlists/{id} [put]
+func RenamePlaylistHandler(playlists repository.PlaylistRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		id, ok := parseIDParam(w, r, "id")
+		if !ok {
+			return
+		}
+
+		input, ok := decodePlaylistInput(w, r)
+		if !ok {
+			return
+		}
+
+		playlist, err := playlists.Rename(ctx, id, input.Name)
+		if err != nil {
+			writePlaylistError(w, r, err)
+			return
+		}
+		writeJSON(ctx, w, http.StatusOK, playlist)
+	}
+}
+
+// DeletePlaylistHandler удаляет плейлист. Песни не удаляются.
+// @Summary Удалить плейлист
+// @Tags playlists
+// @Param id path int true "ID плейлиста"
+// @Success 204 {object} nil "Плейлист удалён"
+// @Failure 404 {object} nil "Плейлист не найден"
+// @Router /playlists/{id} [delete]
+func DeletePlaylistHandler(playlists repository.PlaylistRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := parseIDParam(w, r, "id")
+		if !ok {
+			return
+		}
+
+		if err := playlists.Delete(r.Context(), id); err != nil {
+			writePlaylistError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// AddPlaylistSongHandler добавляет песню в плейлист.
+// @Summary Добавить песню в плейлист
+// @Description Песня вставляется на указанную позицию, последующие песни сдвигаются. Без позиции песня добавляется в конец.
+// @Tags playlists
+// @Accept json
+// @Produce json
+// @Param id path int true "ID плейлиста"
+// @Param song body models.PlaylistSongInput true "Песня и позиция"
+// @Success 201 {object} models.PlaylistItem "Песня добавлена"
+// @Failure 400 {string} string "Неверный запрос или песня не существует"
+// @Failure 404 {string} string "Плейлист не найден"
+// @Failure 409 {string} string "Песня уже в плейлисте"
+// @Router /playlists/{id}/songs [post]
+func AddPlaylistSongHandler(playlists repository.PlaylistRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		id, ok := parseIDParam(w, r, "id")
+		if !ok {
+			return
+		}
+
+		input, ok := decodePlaylistSongInput(w, r)
+		if !ok {
+			return
+		}
+		if input.SongID == 0 {
+			http.Error(w, "Bad Request: song_id is required", http.StatusBadRequest)
+			return
+		}
+
+		item, err := playlists.AddSong(ctx, id, input.SongID, input.Position)
+		if err != nil {
+			writePlaylistError(w, r, err)
+			return
+		}
+
+		logger.InfoKV(ctx, "Song added to playlist", "playlist_id", id, "song_id", item.SongID, "position", item.Position)
+		writeJSON(ctx, w, http.StatusCreated, item)
+	}
+}
+
+// RemovePlaylistSongHandler убирает песню из плейлиста.
+// @Summary Убрать песню из плейлиста
+// @Tags playlists
+// @Param id path int true "ID плейлиста"
+// @Param songID path int true "ID песни"
+// @Success 204 {object} nil "Песня убрана"
+// @Failure 404 {object} nil "Плейлист не найден или песни в нём нет"
+// @Router /playlists/{id}/songs/{songID} [delete]
+func RemovePlaylistSongHandler(playlists repository.PlaylistRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := parseIDParam(w, r, "id")
+		if !ok {
+			return
+		}
+		songID, ok := parseIDParam(w, r, "songID")
+		if !ok {
+			return
+		}
+
+		if err := playlists.RemoveSong(r.Context(), id, songID); err != nil {
+			writePlaylistError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// MovePlaylistSongHandler переносит песню на новую позицию.
+// @Summary Переместить песню в плейлисте
+// @Tags playlists
+// @Accept json
+// @Produce json
+// @Param id path int true "ID плейлиста"
+// @Param songID path int true "ID песни"
+// @Param position body models.PlaylistSongInput true "Новая позиция, начиная с 1"
+// @Success 200 {object} models.PlaylistItem "Песня перемещена"
+// @Failure 400 {string} string "Неверный запрос"
+// @Failure 404 {string} string "Плейлист не найден или песни в нём нет"
+// @Router /playlists/{id}/songs/{songID}/position [put]
+func MovePlaylistSongHandler(playlists repository.PlaylistRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		id, ok := parseIDParam(w, r, "id")
+		if !ok {
+			return
+		}
+		songID, ok := parseIDParam(w, r, "songID")
+		if !ok {
+			return
+		}
+
+		input, ok := decodePlaylistSongInput(w, r)
+		if !ok {
+			return
+		}
+		if input.Position < 1 {
+			http.Error(w, "Bad Request: position must be positive", http.StatusBadRequest)
+			return
+		}
+
+		item, err := playlists.MoveSong(ctx, id, songID, input.Position)
+		if err != nil {
+			writePlaylistError(w, r, err)
+			return
+		}
+
+		logger.InfoKV(ctx, "Song moved in playlist", "playlist_id", id, "song_id", songID, "position", item.Position)
+		writeJSON(ctx, w, http.StatusOK, item)
+	}
+}
+
+// decodePlaylistInput читает и нормализует тело запроса с названием плейлиста
+func decodePlaylistInput(w http.ResponseWriter, r *http.Request) (*models.PlaylistInput, bool) {
+	var input models.PlaylistInput
+	if err := utils.DecodeInput(w, r, r.Context(), &input, "Decoded playlist input"); err != nil {
+		return nil, false
+	}
+
+	input.Name = utils.NormalizeSongName(input.Name)
+	if err := input.Validate(); err != nil {
+		http.Error(w, "Bad Request: "+err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	return &input, true
+}
+
+// decodePlaylistSongInput читает тело запроса с песней и позицией
+func decodePlaylistSongInput(w http.ResponseWriter, r *http.Request) (*models.PlaylistSongInput, bool) {
+	var input models.PlaylistSongInput
+	if err := utils.DecodeInput(w, r, r.Context(), &input, "Decoded playlist song input"); err != nil {
+		return nil, false
+	}
+	if input.Position < 0 {
+		http.Error(w, "Bad Request: position must not be negative", http.StatusBadRequest)
+		return nil, false
+	}
+	return &input, true
+}
+
+// writePlaylistError переводит ошибку хранилища плейлистов в HTTP-ответ
+func writePlaylistError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		http.Error(w, "Playlist or playlist song Not Found", http.StatusNotFound)
+	case errors.Is(err, repository.ErrInvalidReference):
+		http.Error(w, "Bad Request: song does not exist", http.StatusBadRequest)
+	case errors.Is(err, repository.ErrAlreadyExists):
+		http.Error(w, "Song is already in the playlist", http.StatusConflict)
+	default:
+		logger.Error(r.Context(), "Playlist repository error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,18 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"music/pkg/logger"
+)
+
+// writeJSON отправляет ответ в формате JSON с указанным статусом
+func writeJSON(ctx context.Context, w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		logger.Error(ctx, "Failed to encode response", err)
+	}
+}
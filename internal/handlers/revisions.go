@@ -0,0 +1,310 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"music/internal/models"
+	"music/internal/repository"
+	"music/pkg/logger"
+	"music/pkg/lyrics"
+)
+
+// errRevisionNotFound возвращается, если у песни нет правки с запрошенным номером
+var errRevisionNotFound = errors.New("revision not found")
+
+// GetSongRevisionsHandler возвращает историю правок песни с пагинацией.
+// @Summary Получить историю правок песни
+// @Description Каждое изменение названия, исполнителя, даты релиза, ссылки или текста сохраняется отдельной правкой
+// @Description с моментом изменения и клиентом из заголовка X-Client-ID. Правки идут от новых к старым.
+// @Description У песен, добавленных до появления истории, она начинается с первого изменения.
+// @Tags revisions
+// @Produce json
+// @Param id path int true "ID песни"
+// @Param limit query int false "Количество записей на странице" maximum(100)
+// @Param page query int false "Номер страницы"
+// @Success 200 {object} models.RevisionsResponse "История правок"
+// @Failure 404 {object} nil "Песня не найдена"
+// @Failure 500 {object} nil "Ошибка на сервере"
+// @Router /songs/{id}/revisions [get]
+func GetSongRevisionsHandler(lookup SongLookup, revisions repository.RevisionRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		song, ok := lookup(w, r)
+		if !ok {
+			return
+		}
+		limit, page, offset := parsePagination(r)
+
+		list, total, err := revisions.List(ctx, song.ID, limit, offset)
+		if err != nil {
+			logger.Error(ctx, "Error fetching song revisions", err)
+			http.Error(w, "Error fetching song revisions", http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(ctx, w, http.StatusOK, models.RevisionsResponse{
+			TotalItems: total,
+			Page:       page,
+			Limit:      limit,
+			Revisions:  list,
+		})
+	}
+}
+
+// GetSongRevisionHandler возвращает правку песни вместе с текстом.
+// @Summary Получить правку песни
+// @Tags revisions
+// @Produce json
+// @Param id path int true "ID песни"
+// @Param rev path int true "Номер правки"
+// @Success 200 {object} models.SongRevisionResponse "Правка песни"
+// @Failure 400 {object} nil "Неверный номер правки"
+// @Failure 404 {object} nil "Песня или правка не найдена"
+// @Router /songs/{id}/revisions/{rev} [get]
+func GetSongRevisionHandler(lookup SongLookup, revisions repository.RevisionRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		song, ok := lookup(w, r)
+		if !ok {
+			return
+		}
+		number, ok := parseIDParam(w, r, "rev")
+		if !ok {
+			return
+		}
+
+		revision, err := revisions.Get(ctx, song.ID, int(number))
+		if err != nil {
+			writeRevisionError(w, r, err)
+			return
+		}
+		text, err := models.ParseSongText(revision.Text)
+		if err != nil {
+			logger.Error(ctx, "Failed to parse revision text", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(ctx, w, http.StatusOK, models.SongRevisionResponse{SongRevision: *revision, Text: text})
+	}
+}
+
+// DiffSongRevisionsHandler сравнивает две правки песни.
+// @Summary Сравнить правки песни
+// @Description Возвращает поля, которые различаются в правках, и сравнение текстов по куплетам.
+// @Description По умолчанию последняя правка сравнивается с предыдущей.
+// @Tags revisions
+// @Produce json
+// @Param id path int true "ID песни"
+// @Param from query int false "Номер старой правки; по умолчанию предыдущая перед to"
+// @Param to query int false "Номер новой правки; по умолчанию последняя"
+// @Success 200 {object} models.RevisionDiff "Различия между правками"
+// @Failure 400 {object} nil "Неверный номер правки"
+// @Failure 404 {object} nil "Песня или правка не найдена"
+// @Router /songs/{id}/revisions/diff [get]
+func DiffSongRevisionsHandler(lookup SongLookup, revisions repository.RevisionRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		song, ok := lookup(w, r)
+		if !ok {
+			return
+		}
+		from, err := parseRevisionQuery(r, "from")
+		if err != nil {
+			http.Error(w, "Bad Request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		to, err := parseRevisionQuery(r, "to")
+		if err != nil {
+			http.Error(w, "Bad Request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if to == 0 {
+			latest, _, err := revisions.List(ctx, song.ID, 1, 0)
+			if err != nil {
+				logger.Error(ctx, "Error fetching song revisions", err)
+				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				return
+			}
+			if len(latest) == 0 {
+				writeRevisionError(w, r, errRevisionNotFound)
+				return
+			}
+			to = latest[0].Number
+		}
+		if from == 0 {
+			from = max(to-1, 1)
+		}
+
+		older, err := revisions.Get(ctx, song.ID, from)
+		if err != nil {
+			writeRevisionError(w, r, err)
+			return
+		}
+		newer, err := revisions.Get(ctx, song.ID, to)
+		if err != nil {
+			writeRevisionError(w, r, err)
+			return
+		}
+
+		diff, err := diffRevisions(older, newer)
+		if err != nil {
+			logger.Error(ctx, "Failed to diff song revisions", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(ctx, w, http.StatusOK, diff)
+	}
+}
+
+// RevertSongHandler возвращает песню к состоянию правки.
+// @Summary Откатить песню к правке
+// @Description Название, исполнитель, дата релиза, ссылка и текст берутся из правки, а откат сохраняется новой правкой.
+// @Description Если исполнителя правки уже нет, он создаётся заново по имени.
+// @Tags revisions
+// @Produce json
+// @Param id path int true "ID песни"
+// @Param rev path int true "Номер правки"
+// @Success 200 {object} models.SongDetail "Песня после отката"
+// @Failure 400 {object} nil "Неверный номер правки"
+// @Failure 404 {object} nil "Песня или правка не найдена"
+// @Failure 409 {object} nil "У исполнителя уже есть песня с таким названием"
+// @Router /songs/{id}/revisions/{rev}/revert [post]
+func RevertSongHandler(lookup SongLookup, repos repository.Repositories) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		song, ok := lookup(w, r)
+		if !ok {
+			return
+		}
+		number, ok := parseIDParam(w, r, "rev")
+		if !ok {
+			return
+		}
+
+		err := repos.Tx.InTx(ctx, func(tx repository.Repositories) error {
+			revision, err := tx.Revisions.Get(ctx, song.ID, int(number))
+			if err != nil {
+				return err
+			}
+			// Песня перечитывается в транзакции, чтобы откат не споткнулся о правку, сделанную после поиска песни
+			current, err := tx.Songs.GetByID(ctx, song.ID)
+			if err != nil {
+				return err
+			}
+			song = current
+
+			artist, err := tx.Artists.GetByID(ctx, revision.ArtistID)
+			if errors.Is(err, repository.ErrNotFound) {
+				created, ensureErr := tx.Artists.EnsureNames(ctx, []string{revision.GroupName})
+				if ensureErr != nil {
+					return ensureErr
+				}
+				restored := created[revision.GroupName]
+				artist, err = &restored, nil
+			}
+			if err != nil {
+				return err
+			}
+
+			song.ArtistID, song.GroupName = artist.ID, artist.Name
+			song.SongName = revision.SongName
+			song.ReleaseDate = revision.ReleaseDate
+			song.SongURL = revision.SongURL
+			song.Text = revision.Text
+			return tx.Songs.Update(ctx, song)
+		})
+		if err != nil {
+			writeRevisionError(w, r, err)
+			return
+		}
+
+		logger.InfoKV(ctx, "Song reverted to revision", "song_id", song.ID, "revision", number)
+		setETag(w, song.Version)
+		writeJSON(ctx, w, http.StatusOK, song)
+	}
+}
+
+// diffRevisions сравнивает поля и тексты двух правок
+func diffRevisions(older, newer *models.SongRevision) (*models.RevisionDiff, error) {
+	diff := &models.RevisionDiff{
+		SongID: newer.SongID,
+		From:   older.Number,
+		To:     newer.Number,
+		Fields: []models.FieldChange{},
+		Lyrics: []lyrics.VerseChange{},
+	}
+
+	addChange := func(field, from, to string) {
+		if from != to {
+			diff.Fields = append(diff.Fields, models.FieldChange{Field: field, From: from, To: to})
+		}
+	}
+	addChange("song_name", older.SongName, newer.SongName)
+	addChange("artist", older.GroupName, newer.GroupName)
+	addChange("release_date", formatRevisionDate(older), formatRevisionDate(newer))
+	addChange("song_url", older.SongURL, newer.SongURL)
+
+	if older.Text == newer.Text {
+		return diff, nil
+	}
+	oldText, err := models.ParseSongText(older.Text)
+	if err != nil {
+		return nil, err
+	}
+	newText, err := models.ParseSongText(newer.Text)
+	if err != nil {
+		return nil, err
+	}
+	diff.Lyrics = lyrics.DiffVerses(oldText.FlatVerses(), newText.FlatVerses())
+	return diff, nil
+}
+
+// formatRevisionDate записывает дату релиза правки в формате запросов на изменение песни
+func formatRevisionDate(revision *models.SongRevision) string {
+	return formatReleaseDate(revision.ReleaseDate)
+}
+
+// formatReleaseDate записывает дату релиза в формате запросов на изменение песни; пусто, если даты нет
+func formatReleaseDate(releaseDate *time.Time) string {
+	if releaseDate == nil {
+		return ""
+	}
+	return releaseDate.Format("2006.01.02")
+}
+
+// parseRevisionQuery читает необязательный номер правки из параметра запроса; 0 означает, что параметра нет
+func parseRevisionQuery(r *http.Request, name string) (int, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return 0, nil
+	}
+	number, err := strconv.Atoi(raw)
+	if err != nil || number < 1 {
+		return 0, &queryParamError{param: name, reason: "must be a positive revision number"}
+	}
+	return number, nil
+}
+
+// writeRevisionError переводит ошибки истории правок в HTTP-ответы
+func writeRevisionError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound), errors.Is(err, errRevisionNotFound):
+		http.Error(w, "Revision Not Found", http.StatusNotFound)
+	case errors.Is(err, repository.ErrAlreadyExists):
+		http.Error(w, "Conflict: the artist already has a song with this name", http.StatusConflict)
+	case errors.Is(err, repository.ErrVersionConflict):
+		writeVersionConflict(w, r)
+	default:
+		logger.Error(r.Context(), "Revision repository error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"music/internal/models"
+	"music/internal/repository"
+	"music/pkg/logger"
+)
+
+// SearchHandler выполняет полнотекстовый поиск по названиям, исполнителям и текстам песен.
+// @Summary Полнотекстовый поиск песен
+// @Description Поиск учитывает русскую и английскую морфологию. Для каждой песни возвращается наиболее релевантный куплет с выделенными совпадениями.
+// @Tags songs
+// @Produce json
+// @Param q query string true "Поисковый запрос"
+// @Param limit query int false "Количество результатов на странице" maximum(100)
+// @Param page query int false "Номер страницы"
+// @Success 200 {object} models.SearchResponse "Результаты поиска"
+// @Failure 400 {string} string "Пустой запрос"
+// @Failure 500 {string} string "Ошибка на сервере"
+// @Router /search [get]
+func SearchHandler(songs repository.SongRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		query := strings.TrimSpace(r.URL.Query().Get("q"))
+		if query == "" {
+			http.Error(w, "Bad Request: q is required", http.StatusBadRequest)
+			return
+		}
+		limit, page, offset := parsePagination(r)
+
+		hits, total, err := songs.Search(ctx, query, limit, offset)
+		if err != nil {
+			logger.Error(ctx, "Error searching songs", err)
+			http.Error(w, "Error searching songs", http.StatusInternalServerError)
+			return
+		}
+
+		logger.DebugKV(ctx, "Search completed", "query", query, "total", total)
+		writeJSON(ctx, w, http.StatusOK, models.SearchResponse{
+			Query:      query,
+			TotalItems: total,
+			Page:       page,
+			Limit:      limit,
+			Results:    hits,
+		})
+	}
+}
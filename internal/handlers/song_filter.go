@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"music/internal/repository"
+	"music/internal/utils"
+)
+
+// queryParamError сообщает, какой параметр запроса некорректен
+type queryParamError struct {
+	param  string
+	reason string
+}
+
+func (e *queryParamError) Error() string {
+	return fmt.Sprintf("invalid %s: %s", e.param, e.reason)
+}
+
+// parseSongFilter собирает фильтр списка песен из параметров запроса. Пагинация не заполняется.
+func parseSongFilter(r *http.Request) (repository.SongFilter, error) {
+	query := r.URL.Query()
+	var filter repository.SongFilter
+	var err error
+
+	if filter.ArtistID, err = parseUintQuery(r, "artist_id"); err != nil {
+		return filter, err
+	}
+	if filter.AlbumID, err = parseUintQuery(r, "album_id"); err != nil {
+		return filter, err
+	}
+	filter.Title = utils.NormalizeSongName(query.Get("title"))
+	filter.ArtistName = utils.NormalizeSongName(query.Get("artist"))
+
+	// Устаревший фильтр по одной паре field/value
+	field := utils.NormalizeSongName(query.Get("field"))
+	value := utils.NormalizeSongName(query.Get("value"))
+	if field != "" && value != "" {
+		switch field {
+		case "song_name":
+			filter.SongName = value
+		case "artist_name":
+			filter.ArtistName = value
+		case "release_date":
+			releaseDate, err := time.Parse("2006-01-02", value)
+			if err != nil {
+				return filter, &queryParamError{param: "value", reason: "release_date must be in format YYYY-MM-DD"}
+			}
+			filter.ReleaseDate = &releaseDate
+		default:
+			return filter, &queryParamError{param: "field", reason: "expected song_name, artist_name or release_date"}
+		}
+	}
+
+	if filter.ReleasedFrom, err = parseDateQuery(r, "release_from", false); err != nil {
+		return filter, err
+	}
+	if filter.ReleasedTo, err = parseDateQuery(r, "release_to", false); err != nil {
+		return filter, err
+	}
+	if filter.ReleasedFrom != nil && filter.ReleasedTo != nil && filter.ReleasedFrom.After(*filter.ReleasedTo) {
+		return filter, &queryParamError{param: "release_from", reason: "must not be after release_to"}
+	}
+	if filter.CreatedFrom, err = parseDateQuery(r, "created_from", false); err != nil {
+		return filter, err
+	}
+	if filter.CreatedTo, err = parseDateQuery(r, "created_to", true); err != nil {
+		return filter, err
+	}
+	if filter.CreatedFrom != nil && filter.CreatedTo != nil && filter.CreatedFrom.After(*filter.CreatedTo) {
+		return filter, &queryParamError{param: "created_from", reason: "must not be after created_to"}
+	}
+
+	if raw := query.Get("has_lyrics"); raw != "" {
+		hasLyrics, err := strconv.ParseBool(raw)
+		if err != nil {
+			return filter, &queryParamError{param: "has_lyrics", reason: "expected true or false"}
+		}
+		filter.HasLyrics = &hasLyrics
+	}
+
+	if filter.Sort, err = repository.ParseSongSort(query.Get("sort")); err != nil {
+		return filter, &queryParamError{param: "sort", reason: err.Error() + ", expected release_date, title, artist or created_at with optional - prefix"}
+	}
+	return filter, nil
+}
+
+// parseUintQuery читает необязательный числовой идентификатор из параметров запроса
+func parseUintQuery(r *http.Request, name string) (uint, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return 0, nil
+	}
+	value, err := strconv.ParseUint(raw, 10, 0)
+	if err != nil {
+		return 0, &queryParamError{param: name, reason: "expected a positive integer"}
+	}
+	return uint(value), nil
+}
+
+// parseDateQuery читает дату в формате YYYY-MM-DD или момент времени в RFC 3339.
+// Для верхней границы дата без времени означает конец дня.
+func parseDateQuery(r *http.Request, name string, endOfDay bool) (*time.Time, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return nil, nil
+	}
+	if value, err := time.Parse(time.RFC3339, raw); err == nil {
+		return &value, nil
+	}
+	value, err := time.Parse("2006-01-02", raw)
+	if err != nil {
+		return nil, &queryParamError{param: name, reason: "expected date in format YYYY-MM-DD"}
+	}
+	if endOfDay {
+		value = value.Add(24*time.Hour - time.Nanosecond)
+	}
+	return &value, nil
+}
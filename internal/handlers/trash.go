@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"music/internal/models"
+	"music/internal/repository"
+	"music/pkg/logger"
+)
+
+// GetTrashHandler возвращает содержимое корзины с пагинацией.
+// @Summary Получить содержимое корзины
+// @Description Удалённые песни и исполнители от недавно удалённых к давним.
+// @Description Записи, пролежавшие в корзине дольше срока хранения, удаляются навсегда автоматически.
+// @Tags trash
+// @Produce json
+// @Param type query string false "Только песни или только исполнители" Enums(song, artist)
+// @Param limit query int false "Количество записей на странице" maximum(100)
+// @Param page query int false "Номер страницы"
+// @Success 200 {object} models.TrashResponse "Содержимое корзины"
+// @Failure 400 {object} nil "Неизвестный тип"
+// @Failure 500 {object} nil "Ошибка на сервере"
+// @Router /trash [get]
+func GetTrashHandler(trash repository.TrashRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		filter := repository.TrashFilter{Type: r.URL.Query().Get("type")}
+		switch filter.Type {
+		case "", models.TrashSong, models.TrashArtist:
+		default:
+			http.Error(w, "Bad Request: invalid type, expected song or artist", http.StatusBadRequest)
+			return
+		}
+		limit, page, offset := parsePagination(r)
+		filter.Limit, filter.Offset = limit, offset
+
+		items, total, err := trash.List(ctx, filter)
+		if err != nil {
+			logger.Error(ctx, "Error fetching trash", err)
+			http.Error(w, "Error fetching trash", http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(ctx, w, http.StatusOK, models.TrashResponse{
+			TotalItems: total,
+			Page:       page,
+			Limit:      limit,
+			Items:      items,
+		})
+	}
+}
+
+// RestoreSongHandler возвращает песню из корзины.
+// @Summary Восстановить песню
+// @Description Если исполнитель песни тоже в корзине, он восстанавливается вместе с ней.
+// @Description Песня возвращается в релизы и плейлисты на прежние позиции; занятый номер трека заменяется следующим свободным на диске.
+// @Tags trash
+// @Produce json
+// @Param id path int true "ID песни"
+// @Success 200 {object} models.SongDetail "Восстановленная песня"
+// @Failure 404 {object} nil "Песни нет в корзине"
+// @Failure 409 {object} nil "Песня с таким названием или исполнитель с таким именем уже есть"
+// @Router /trash/songs/{id}/restore [post]
+func RestoreSongHandler(trash repository.TrashRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		id, ok := parseIDParam(w, r, "id")
+		if !ok {
+			return
+		}
+
+		song, err := trash.RestoreSong(ctx, id)
+		if err != nil {
+			writeTrashError(w, r, err, "Song")
+			return
+		}
+
+		logger.InfoKV(ctx, "Song restored from trash", "song_id", song.ID)
+		setETag(w, song.Version)
+		writeJSON(ctx, w, http.StatusOK, song)
+	}
+}
+
+// RestoreArtistHandler возвращает исполнителя из корзины.
+// @Summary Восстановить исполнителя
+// @Description Вместе с исполнителем восстанавливаются песни, удалённые вместе с ним. Песни, удалённые раньше по отдельности, остаются в корзине.
+// @Description Релизы исполнителя возвращаются вместе с ним.
+// @Tags trash
+// @Produce json
+// @Param id path int true "ID исполнителя"
+// @Success 200 {object} models.Artist "Восстановленный исполнитель"
+// @Failure 404 {object} nil "Исполнителя нет в корзине"
+// @Failure 409 {object} nil "Исполнитель с таким именем уже есть"
+// @Router /trash/artists/{id}/restore [post]
+func RestoreArtistHandler(trash repository.TrashRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		id, ok := parseIDParam(w, r, "id")
+		if !ok {
+			return
+		}
+
+		artist, err := trash.RestoreArtist(ctx, id)
+		if err != nil {
+			writeTrashError(w, r, err, "Artist")
+			return
+		}
+
+		logger.InfoKV(ctx, "Artist restored from trash", "artist_id", artist.ID)
+		setETag(w, artist.Version)
+		writeJSON(ctx, w, http.StatusOK, artist)
+	}
+}
+
+// PurgeSongHandler удаляет песню из корзины навсегда.
+// @Summary Удалить песню из корзины
+// @Tags trash
+// @Param id path int true "ID песни"
+// @Success 204 {object} nil "Песня удалена навсегда"
+// @Failure 404 {object} nil "Песни нет в корзине"
+// @Router /trash/songs/{id} [delete]
+func PurgeSongHandler(trash repository.TrashRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		id, ok := parseIDParam(w, r, "id")
+		if !ok {
+			return
+		}
+
+		if err := trash.PurgeSong(ctx, id); err != nil {
+			writeTrashError(w, r, err, "Song")
+			return
+		}
+
+		logger.InfoKV(ctx, "Song purged from trash", "song_id", id)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// PurgeArtistHandler удаляет исполнителя из корзины навсегда.
+// @Summary Удалить исполнителя из корзины
+// @Description Вместе с исполнителем навсегда удаляются все его песни из корзины.
+// @Tags trash
+// @Param id path int true "ID исполнителя"
+// @Success 204 {object} nil "Исполнитель удалён навсегда"
+// @Failure 404 {object} nil "Исполнителя нет в корзине"
+// @Router /trash/artists/{id} [delete]
+func PurgeArtistHandler(trash repository.TrashRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		id, ok := parseIDParam(w, r, "id")
+		if !ok {
+			return
+		}
+
+		if err := trash.PurgeArtist(ctx, id); err != nil {
+			writeTrashError(w, r, err, "Artist")
+			return
+		}
+
+		logger.InfoKV(ctx, "Artist purged from trash", "artist_id", id)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// EmptyTrashHandler удаляет навсегда всё содержимое корзины.
+// @Summary Очистить корзину
+// @Tags trash
+// @Produce json
+// @Success 200 {object} models.TrashPurgeResult "Количество удалённых записей"
+// @Failure 500 {object} nil "Ошибка на сервере"
+// @Router /trash [delete]
+func EmptyTrashHandler(trash repository.TrashRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		result, err := trash.Purge(ctx, time.Now())
+		if err != nil {
+			logger.Error(ctx, "Failed to empty trash", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+
+		logger.InfoKV(ctx, "Trash emptied", "songs", result.Songs, "artists", result.Artists)
+		writeJSON(ctx, w, http.StatusOK, result)
+	}
+}
+
+// writeTrashError переводит ошибки корзины в HTTP-ответы; entity - "Song" или "Artist"
+func writeTrashError(w http.ResponseWriter, r *http.Request, err error, entity string) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		http.Error(w, entity+" Not Found in trash", http.StatusNotFound)
+	case errors.Is(err, repository.ErrAlreadyExists):
+		http.Error(w, "Conflict: the name is already taken, rename the existing record first", http.StatusConflict)
+	default:
+		logger.Error(r.Context(), "Trash repository error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,113 @@
+// Package health проверяет готовность приложения обслуживать запросы.
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Статусы проверок и готовности в целом
+const (
+	StatusOK           = "ok"
+	StatusFail         = "fail"
+	StatusShuttingDown = "shutting_down"
+)
+
+// CheckResult - результат одной проверки
+type CheckResult struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`      // Сколько длилась проверка, в миллисекундах
+	Error     string  `json:"error,omitempty"` // Причина неудачи
+}
+
+// Report - итог проверки готовности
+type Report struct {
+	Status string        `json:"status"`
+	Checks []CheckResult `json:"checks"`
+}
+
+// Ready сообщает, что приложение готово принимать запросы
+func (r Report) Ready() bool {
+	return r.Status == StatusOK
+}
+
+type check struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// Checker выполняет зарегистрированные проверки зависимостей
+type Checker struct {
+	timeout  time.Duration
+	stopping <-chan struct{}
+	checks   []check
+}
+
+// NewChecker создаёт Checker, который даёт каждой проверке не больше timeout.
+// После закрытия stopping приложение считается неготовым без выполнения проверок; nil - никогда.
+func NewChecker(timeout time.Duration, stopping <-chan struct{}) *Checker {
+	return &Checker{timeout: timeout, stopping: stopping}
+}
+
+// Add регистрирует проверку. Все проверки регистрируются до запуска сервера.
+func (c *Checker) Add(name string, fn func(ctx context.Context) error) {
+	c.checks = append(c.checks, check{name: name, fn: fn})
+}
+
+// Check выполняет проверки параллельно и возвращает результаты в порядке регистрации
+func (c *Checker) Check(ctx context.Context) Report {
+	select {
+	case <-c.stopping:
+		return Report{Status: StatusShuttingDown, Checks: []CheckResult{}}
+	default:
+	}
+
+	results := make([]CheckResult, len(c.checks))
+	var wg sync.WaitGroup
+	for i, check := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = c.run(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: results}
+	for _, result := range results {
+		if result.Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
+
+// run выполняет проверку с таймаутом. Проверка, которая не уважает контекст, всё равно
+// считается неудачной по истечении таймаута.
+func (c *Checker) run(ctx context.Context, check check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	started := time.Now()
+	done := make(chan error, 1)
+	go func() { done <- check.fn(ctx) }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := CheckResult{
+		Name:      check.name,
+		Status:    StatusOK,
+		LatencyMS: float64(time.Since(started).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status, result.Error = StatusFail, err.Error()
+	}
+	return result
+}
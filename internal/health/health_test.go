@@ -0,0 +1,68 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"music/internal/health"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecker_Check(t *testing.T) {
+	checker := health.NewChecker(50*time.Millisecond, nil)
+	checker.Add("database", func(context.Context) error { return nil })
+	checker.Add("migrations", func(context.Context) error { return errors.New("database schema is behind the application") })
+	checker.Add("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	checker.Add("stuck", func(context.Context) error {
+		// Проверка, которая не смотрит на контекст, не должна задерживать ответ дольше таймаута
+		time.Sleep(time.Second)
+		return nil
+	})
+
+	started := time.Now()
+	report := checker.Check(context.Background())
+	assert.Less(t, time.Since(started), 500*time.Millisecond)
+
+	assert.False(t, report.Ready())
+	assert.Equal(t, health.StatusFail, report.Status)
+	require.Len(t, report.Checks, 4)
+
+	assert.Equal(t, "database", report.Checks[0].Name)
+	assert.Equal(t, health.StatusOK, report.Checks[0].Status)
+	assert.Empty(t, report.Checks[0].Error)
+
+	assert.Equal(t, health.StatusFail, report.Checks[1].Status)
+	assert.Equal(t, "database schema is behind the application", report.Checks[1].Error)
+
+	for _, result := range report.Checks[2:] {
+		assert.Equal(t, health.StatusFail, result.Status, result.Name)
+		assert.Equal(t, context.DeadlineExceeded.Error(), result.Error, result.Name)
+		assert.GreaterOrEqual(t, result.LatencyMS, float64(50), result.Name)
+	}
+}
+
+func TestChecker_Stopping(t *testing.T) {
+	stopping := make(chan struct{})
+	called := false
+	checker := health.NewChecker(time.Second, stopping)
+	checker.Add("database", func(context.Context) error { called = true; return nil })
+
+	report := checker.Check(context.Background())
+	assert.True(t, report.Ready())
+	assert.True(t, called)
+
+	called = false
+	close(stopping)
+	report = checker.Check(context.Background())
+	assert.False(t, report.Ready())
+	assert.Equal(t, health.StatusShuttingDown, report.Status)
+	assert.Empty(t, report.Checks)
+	assert.False(t, called, "checks must not run while shutting down")
+}
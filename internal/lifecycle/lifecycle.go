@@ -0,0 +1,173 @@
+// Package lifecycle запускает HTTP-сервер с фоновыми задачами и корректно останавливает их по сигналу.
+package lifecycle
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"music/pkg/logger"
+)
+
+// Коды завершения процесса
+const (
+	ExitOK            = 0 // Сервер остановлен по сигналу, все запросы и задачи завершены
+	ExitServerError   = 1 // Сервер не запустился или упал во время работы
+	ExitShutdownError = 2 // Запросы не успели завершиться за отведённое время или хук остановки вернул ошибку
+)
+
+// Hook - действие при остановке, например закрытие соединений с базой
+type Hook struct {
+	Name string
+	Fn   func(ctx context.Context) error
+}
+
+// Lifecycle управляет остановкой сервера: после сигнала приложение сразу считается неготовым,
+// ещё drain продолжает обслуживать запросы, чтобы балансировщик успел убрать его из ротации,
+// затем сервер перестаёт принимать соединения, дожидается начатых запросов, останавливает фоновые задачи
+// и выполняет хуки. На всё это вместе с drain отводится grace; повторный сигнал завершает процесс сразу.
+type Lifecycle struct {
+	grace time.Duration
+	drain time.Duration
+
+	mu       sync.Mutex
+	hooks    []Hook
+	workers  sync.WaitGroup
+	stop     context.CancelFunc // Отменяет контекст фоновых задач
+	ctx      context.Context    // Контекст фоновых задач
+	stopping chan struct{}      // Закрывается в начале остановки
+}
+
+// New создаёт Lifecycle с временем на остановку grace и паузой drain между снятием готовности
+// и остановкой сервера; drain входит в grace
+func New(grace, drain time.Duration) *Lifecycle {
+	ctx, stop := context.WithCancel(context.Background())
+	return &Lifecycle{grace: grace, drain: drain, ctx: ctx, stop: stop, stopping: make(chan struct{})}
+}
+
+// Stopping возвращает канал, который закрывается в начале остановки, ещё до завершения начатых запросов
+func (l *Lifecycle) Stopping() <-chan struct{} {
+	return l.stopping
+}
+
+// OnShutdown регистрирует хук остановки. Хуки выполняются в обратном порядке регистрации
+// после остановки сервера и фоновых задач, поэтому ресурсы, нужные остальным, регистрируются первыми.
+func (l *Lifecycle) OnShutdown(name string, fn func(ctx context.Context) error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.hooks = append(l.hooks, Hook{Name: name, Fn: fn})
+}
+
+// Go запускает фоновую задачу. Её контекст наследует значения parent и отменяется при остановке,
+// а остановка дожидается возврата из fn.
+func (l *Lifecycle) Go(parent context.Context, name string, fn func(ctx context.Context)) {
+	ctx, cancel := context.WithCancel(context.WithoutCancel(parent))
+	stopOnShutdown := context.AfterFunc(l.ctx, cancel)
+
+	l.workers.Add(1)
+	go func() {
+		defer l.workers.Done()
+		defer stopOnShutdown()
+		defer cancel()
+		fn(ctx)
+		logger.DebugKV(parent, "Background worker stopped", "worker", name)
+	}()
+}
+
+// Run слушает srv.Addr и обслуживает запросы до SIGINT или SIGTERM либо до отмены ctx,
+// затем останавливает всё и возвращает код завершения процесса
+func (l *Lifecycle) Run(ctx context.Context, srv *http.Server) int {
+	listener, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		logger.Error(ctx, "Server failed to start", err)
+		l.shutdown(ctx, srv, 0)
+		return ExitServerError
+	}
+	return l.Serve(ctx, srv, listener)
+}
+
+// Serve работает как Run, но принимает соединения из готового listener
+func (l *Lifecycle) Serve(ctx context.Context, srv *http.Server, listener net.Listener) int {
+	signalCtx, stopSignals := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- srv.Serve(listener) }()
+
+	code := ExitOK
+	// Упавший сервер запросов уже не принимает, поэтому ждать перед остановкой нечего
+	drain := time.Duration(0)
+	select {
+	case err := <-serveErr:
+		logger.Error(ctx, "Server stopped unexpectedly", err)
+		code = ExitServerError
+	case <-signalCtx.Done():
+		// После первого сигнала возвращаем обработку по умолчанию: второй сигнал завершит процесс сразу
+		stopSignals()
+		logger.InfoKV(ctx, "Shutting down", "grace_period", l.grace.String(), "readiness_drain", l.drain.String())
+		drain = l.drain
+	}
+
+	if !l.shutdown(ctx, srv, drain) && code == ExitOK {
+		code = ExitShutdownError
+	}
+	return code
+}
+
+// shutdown снимает готовность, через drain останавливает сервер, фоновые задачи и выполняет хуки;
+// false - если что-то не удалось
+func (l *Lifecycle) shutdown(ctx context.Context, srv *http.Server, drain time.Duration) bool {
+	close(l.stopping)
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), l.grace)
+	defer cancel()
+	ok := true
+
+	// /readyz уже отвечает 503, но балансировщик узнаёт об этом при следующей проверке,
+	// а до тех пор продолжает присылать запросы, которые сервер должен принять
+	if drain > 0 {
+		timer := time.NewTimer(drain)
+		select {
+		case <-timer.C:
+		case <-shutdownCtx.Done():
+			timer.Stop()
+		}
+	}
+
+	// Сервер перестаёт принимать соединения и ждёт начатые запросы; по истечении времени они обрываются
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		logger.Error(ctx, "Server did not drain in-flight requests in time", err)
+		srv.Close()
+		ok = false
+	}
+
+	l.stop()
+	workersDone := make(chan struct{})
+	go func() {
+		l.workers.Wait()
+		close(workersDone)
+	}()
+	select {
+	case <-workersDone:
+	case <-shutdownCtx.Done():
+		logger.Error(ctx, "Background workers did not stop in time", shutdownCtx.Err())
+		ok = false
+	}
+
+	l.mu.Lock()
+	hooks := append([]Hook(nil), l.hooks...)
+	l.mu.Unlock()
+	// Последним обычно выполняется сброс логов, поэтому итог пишется в лог до хуков
+	logger.InfoKV(ctx, "Server stopped, running shutdown hooks", "hooks", len(hooks))
+	for i := len(hooks) - 1; i >= 0; i-- {
+		if err := hooks[i].Fn(shutdownCtx); err != nil {
+			logger.ErrorKV(ctx, "Shutdown hook failed", "hook", hooks[i].Name, "error", err)
+			ok = false
+		}
+	}
+	return ok
+}
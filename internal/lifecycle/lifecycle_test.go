@@ -0,0 +1,185 @@
+package lifecycle_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"music/internal/handlers"
+	"music/internal/health"
+	"music/internal/lifecycle"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serve запускает Serve на свободном порту и возвращает адрес сервера и канал с кодом завершения
+func serve(t *testing.T, ctx context.Context, lc *lifecycle.Lifecycle, handler http.Handler) (string, <-chan int) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	code := make(chan int, 1)
+	go func() { code <- lc.Serve(ctx, &http.Server{Handler: handler}, listener) }()
+	return "http://" + listener.Addr().String(), code
+}
+
+func TestLifecycle_DrainsInFlightRequests(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	lc := lifecycle.New(5*time.Second, 0)
+
+	var order []string
+	lc.OnShutdown("first", func(context.Context) error { order = append(order, "first"); return nil })
+	lc.OnShutdown("second", func(context.Context) error { order = append(order, "second"); return nil })
+
+	workerStopped := make(chan struct{})
+	lc.Go(ctx, "worker", func(ctx context.Context) {
+		<-ctx.Done()
+		close(workerStopped)
+	})
+
+	started, release := make(chan struct{}), make(chan struct{})
+	url, code := serve(t, ctx, lc, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		io.WriteString(w, "done")
+	}))
+
+	type result struct {
+		body string
+		err  error
+	}
+	response := make(chan result, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err != nil {
+			response <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		response <- result{body: string(body), err: err}
+	}()
+	<-started
+
+	// Остановка начинается, но ждёт начатый запрос
+	cancel()
+	select {
+	case <-code:
+		t.Fatal("server stopped before the in-flight request finished")
+	case <-time.After(100 * time.Millisecond):
+	}
+	select {
+	case <-lc.Stopping():
+	default:
+		t.Fatal("Stopping must be closed as soon as the shutdown begins")
+	}
+	_, err := http.Get(url)
+	assert.Error(t, err, "new connections must be refused while draining")
+
+	close(release)
+	got := <-response
+	require.NoError(t, got.err)
+	assert.Equal(t, "done", got.body)
+
+	assert.Equal(t, lifecycle.ExitOK, <-code)
+	<-workerStopped
+	assert.Equal(t, []string{"second", "first"}, order)
+}
+
+func TestLifecycle_ReadinessDrain(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	const drain = 300 * time.Millisecond
+	lc := lifecycle.New(5*time.Second, drain)
+
+	mux := http.NewServeMux()
+	mux.Handle("/readyz", handlers.GetReadyzHandler(health.NewChecker(time.Second, lc.Stopping())))
+	mux.HandleFunc("/songs", func(w http.ResponseWriter, r *http.Request) { io.WriteString(w, "songs") })
+	url, code := serve(t, ctx, lc, mux)
+
+	// Каждый запрос на новом соединении: балансировщик открывает их и во время паузы
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	get := func(path string) (int, error) {
+		resp, err := client.Get(url + path)
+		if err != nil {
+			return 0, err
+		}
+		resp.Body.Close()
+		return resp.StatusCode, nil
+	}
+	status, err := get("/readyz")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+
+	started := time.Now()
+	cancel()
+	<-lc.Stopping()
+
+	// Пока идёт пауза, готовность снята, но запросы ещё обслуживаются
+	status, err = get("/readyz")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	status, err = get("/songs")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+
+	assert.Equal(t, lifecycle.ExitOK, <-code)
+	assert.GreaterOrEqual(t, time.Since(started), drain, "the server must not stop before the drain delay")
+	_, err = get("/songs")
+	assert.Error(t, err, "new connections must be refused after the drain delay")
+}
+
+func TestLifecycle_GracePeriodExceeded(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	lc := lifecycle.New(50*time.Millisecond, 0)
+
+	hookCalled := false
+	lc.OnShutdown("database", func(context.Context) error { hookCalled = true; return nil })
+
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	url, code := serve(t, ctx, lc, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	}))
+	go http.Get(url)
+	<-started
+
+	cancel()
+	assert.Equal(t, lifecycle.ExitShutdownError, <-code)
+	assert.True(t, hookCalled, "hooks must run even when requests did not drain")
+}
+
+func TestLifecycle_HookError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	lc := lifecycle.New(time.Second, 0)
+
+	lastCalled := false
+	lc.OnShutdown("log flush", func(context.Context) error { lastCalled = true; return nil })
+	lc.OnShutdown("database", func(context.Context) error { return errors.New("close failed") })
+
+	_, code := serve(t, ctx, lc, http.NotFoundHandler())
+	cancel()
+	assert.Equal(t, lifecycle.ExitShutdownError, <-code)
+	assert.True(t, lastCalled, "a failed hook must not skip the rest")
+}
+
+func TestLifecycle_ListenError(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	lc := lifecycle.New(time.Second, 0)
+	hookCalled := false
+	lc.OnShutdown("database", func(context.Context) error { hookCalled = true; return nil })
+
+	code := lc.Run(context.Background(), &http.Server{Addr: listener.Addr().String()})
+	assert.Equal(t, lifecycle.ExitServerError, code)
+	assert.True(t, hookCalled)
+}
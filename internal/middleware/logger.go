@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"music/pkg/logger"
+
+	"github.com/go-chi/chi"
+	chimiddleware "github.com/go-chi/chi/middleware"
+)
+
+// AccessLog кладёт в контекст запроса дочерний логгер с идентификатором запроса, методом и адресом
+// клиента и после ответа пишет одну строку журнала доступа со статусом, размером ответа и длительностью.
+// Регистрируется после RequestID.
+func AccessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started := time.Now()
+
+		requestLogger := logger.FromContext(r.Context()).
+			WithField("request_id", RequestIDFromContext(r.Context())).
+			WithField("method", r.Method).
+			WithField("client_ip", clientIP(r))
+		ctx := logger.ToContext(r.Context(), requestLogger)
+
+		ww := chimiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		// Без вызова WriteHeader обработчик отвечает 200
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		// Шаблон маршрута известен только после маршрутизации; для ненайденных путей он пустой
+		route := ""
+		if rctx := chi.RouteContext(r.Context()); rctx != nil {
+			route = rctx.RoutePattern()
+		}
+
+		kvs := []interface{}{
+			"route", route,
+			"path", r.URL.Path,
+			"status", status,
+			"bytes", ww.BytesWritten(),
+			"duration", time.Since(started),
+		}
+		if status >= http.StatusInternalServerError {
+			logger.ErrorKV(ctx, "Request completed", kvs...)
+			return
+		}
+		logger.InfoKV(ctx, "Request completed", kvs...)
+	})
+}
+
+// RouteLogger добавляет в логгер запроса шаблон маршрута. Шаблон известен только после маршрутизации,
+// поэтому middleware регистрируется внутри r.Group, а не через r.Use корневого маршрутизатора.
+func RouteLogger(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rctx := chi.RouteContext(r.Context()); rctx != nil {
+			requestLogger := logger.FromContext(r.Context()).WithField("route", rctx.RoutePattern())
+			r = r.WithContext(logger.ToContext(r.Context(), requestLogger))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientIP возвращает адрес, с которого пришло соединение. Заголовкам вроде X-Forwarded-For
+// здесь не доверяем: их может подставить любой клиент.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,133 @@
+package middleware_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"music/internal/middleware"
+	"music/pkg/logger"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRequestID(t *testing.T) {
+	var seen string
+	handler := middleware.RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = middleware.RequestIDFromContext(r.Context())
+	}))
+
+	tests := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{name: "Generated when missing"},
+		{name: "Propagated from upstream", incoming: "edge-1f3a:42", keep: true},
+		{name: "Replaced when unsafe for logs", incoming: "id\nwith newline"},
+		{name: "Replaced when too long", incoming: strings.Repeat("a", 129)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.incoming != "" {
+				req.Header.Set(middleware.RequestIDHeader, tt.incoming)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			id := w.Header().Get(middleware.RequestIDHeader)
+			assert.Equal(t, id, seen)
+			if tt.keep {
+				assert.Equal(t, tt.incoming, id)
+			} else {
+				assert.Regexp(t, `^[0-9a-f]{32}$`, id)
+			}
+		})
+	}
+}
+
+// captureLogs подменяет глобальный логгер на запись в буфер до конца теста
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	previous := logger.Global()
+	logger.SetLogger(logger.NewWithSink(zap.NewAtomicLevelAt(zap.DebugLevel), &buf))
+	t.Cleanup(func() { logger.SetLogger(previous) })
+	return &buf
+}
+
+// logLines разбирает записанные в буфер строки JSON
+func logLines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var lines []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry), line)
+		lines = append(lines, entry)
+	}
+	buf.Reset()
+	return lines
+}
+
+func TestAccessLog(t *testing.T) {
+	buf := captureLogs(t)
+
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID, middleware.AccessLog)
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.RouteLogger)
+		r.Post("/songs/{id}", func(w http.ResponseWriter, r *http.Request) {
+			logger.Info(r.Context(), "Song saved")
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte("saved"))
+		})
+		r.Get("/broken", func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		})
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/songs/7", nil)
+	req.Header.Set(middleware.RequestIDHeader, "req-1")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	lines := logLines(t, buf)
+	require.Len(t, lines, 2)
+	handlerLine, accessLine := lines[0], lines[1]
+
+	// Логи обработчика несут поля запроса
+	assert.Equal(t, "Song saved", handlerLine["msg"])
+	assert.Equal(t, "req-1", handlerLine["request_id"])
+	assert.Equal(t, http.MethodPost, handlerLine["method"])
+	assert.Equal(t, "/songs/{id}", handlerLine["route"])
+	assert.Equal(t, "192.0.2.1", handlerLine["client_ip"])
+
+	assert.Equal(t, "Request completed", accessLine["msg"])
+	assert.Equal(t, "info", accessLine["lvl"])
+	assert.Equal(t, "req-1", accessLine["request_id"])
+	assert.Equal(t, "/songs/{id}", accessLine["route"])
+	assert.Equal(t, "/songs/7", accessLine["path"])
+	assert.Equal(t, float64(http.StatusCreated), accessLine["status"])
+	assert.Equal(t, float64(len("saved")), accessLine["bytes"])
+	assert.Contains(t, accessLine, "duration")
+
+	// Ошибки сервера пишутся с уровнем error, ненайденные маршруты тоже попадают в журнал
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/broken", nil))
+	lines = logLines(t, buf)
+	require.Len(t, lines, 1)
+	assert.Equal(t, "error", lines[0]["lvl"])
+	assert.Equal(t, float64(http.StatusInternalServerError), lines[0]["status"])
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+	lines = logLines(t, buf)
+	require.Len(t, lines, 1)
+	assert.Equal(t, float64(http.StatusNotFound), lines[0]["status"])
+	assert.Equal(t, "", lines[0]["route"])
+	assert.Regexp(t, `^[0-9a-f]{32}$`, lines[0]["request_id"])
+}
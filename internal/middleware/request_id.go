@@ -0,0 +1,61 @@
+// Package middleware содержит общие обработчики-обёртки для всех маршрутов API
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// RequestIDHeader - заголовок, в котором приходит и возвращается идентификатор запроса
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength ограничивает идентификатор, пришедший от клиента или прокси
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// RequestIDFromContext возвращает идентификатор текущего запроса; пустая строка вне запроса
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// RequestID берёт идентификатор запроса из заголовка X-Request-ID или создаёт новый, кладёт его
+// в контекст и возвращает в ответе. Пустой, слишком длинный или содержащий посторонние символы
+// идентификатор заменяется новым, чтобы его можно было без опаски писать в логи.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+	})
+}
+
+// validRequestID допускает латинские буквы, цифры и разделители, которые используют прокси и балансировщики
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':', c == '/', c == '+', c == '=':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID создаёт случайный идентификатор из 32 шестнадцатеричных символов
+func newRequestID() string {
+	var id [16]byte
+	// crypto/rand.Read не возвращает ошибок на поддерживаемых платформах
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
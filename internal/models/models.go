@@ -1,30 +1,284 @@
 package models
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"time"
+
+	"music/pkg/lyrics"
+
+	"gorm.io/gorm"
 )
 
 // Artist представляет исполнителя
 type Artist struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`             // Уникальный идентификатор исполнителя
-	Name      string    `json:"name" gorm:"unique"`               // Имя исполнителя
-	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"` // Дата создания записи
+	ID        uint           `json:"id" gorm:"primaryKey"`                                              // Уникальный идентификатор исполнителя
+	Name      string         `json:"name" gorm:"uniqueIndex:idx_artists_name,where:deleted_at IS NULL"` // Имя исполнителя, уникальное среди не удалённых
+	Version   int64          `json:"version" gorm:"not null;default:1"`                                 // Растёт при каждом изменении; отдаётся в ETag
+	CreatedAt time.Time      `json:"created_at" gorm:"autoCreateTime"`                                  // Дата создания записи
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`                                                    // Момент переноса в корзину
+}
+
+// ArtistInput - данные для создания или переименования исполнителя
+type ArtistInput struct {
+	Name string `json:"name" example:"Исполнитель"`
+}
+
+// Validate проверяет, что имя исполнителя не пустое.
+func (ai *ArtistInput) Validate() error {
+	if ai.Name == "" {
+		return errors.New("artist name cannot be empty")
+	}
+	return nil
+}
+
+// ArtistsResponse - страница списка исполнителей
+type ArtistsResponse struct {
+	TotalItems int64    `json:"total_items"`
+	Page       int      `json:"page"`
+	Limit      int      `json:"limit"`
+	Artists    []Artist `json:"artists"`
+}
+
+// AlbumTrackInput - позиция песни в запросе на создание или изменение релиза
+type AlbumTrackInput struct {
+	SongID      uint `json:"song_id"`
+	DiscNumber  int  `json:"disc_number"` // По умолчанию 1
+	TrackNumber int  `json:"track_number"`
+}
+
+// AlbumInput - данные для создания или полной замены релиза
+type AlbumInput struct {
+	Title       string            `json:"title" example:"Black Holes and Revelations"`
+	ReleaseDate string            `json:"release_date" example:"2006-07-03"` // Дата в формате 2006-01-02
+	Type        string            `json:"type" example:"album"`
+	ArtistID    uint              `json:"artist_id"`
+	Tracks      []AlbumTrackInput `json:"tracks"`
+}
+
+// Validate проверяет данные релиза и проставляет номер диска по умолчанию.
+func (ai *AlbumInput) Validate() error {
+	if ai.Title == "" {
+		return errors.New("album title cannot be empty")
+	}
+	if ai.ArtistID == 0 {
+		return errors.New("artist_id is required")
+	}
+	switch ai.Type {
+	case "":
+		ai.Type = AlbumTypeAlbum
+	case AlbumTypeAlbum, AlbumTypeSingle, AlbumTypeEP, AlbumTypeCompilation:
+	default:
+		return fmt.Errorf("unknown album type %q", ai.Type)
+	}
+
+	type position struct{ disc, track int }
+	seen := make(map[position]bool, len(ai.Tracks))
+	for i := range ai.Tracks {
+		track := &ai.Tracks[i]
+		if track.DiscNumber == 0 {
+			track.DiscNumber = 1
+		}
+		if track.SongID == 0 || track.DiscNumber < 1 || track.TrackNumber < 1 {
+			return fmt.Errorf("track %d: song_id, disc_number and track_number must be positive", i+1)
+		}
+		pos := position{track.DiscNumber, track.TrackNumber}
+		if seen[pos] {
+			return fmt.Errorf("duplicate track position %d-%d", pos.disc, pos.track)
+		}
+		seen[pos] = true
+	}
+	return nil
+}
+
+// AlbumsResponse - страница списка релизов
+type AlbumsResponse struct {
+	TotalItems int64   `json:"total_items"`
+	Page       int     `json:"page"`
+	Limit      int     `json:"limit"`
+	Albums     []Album `json:"albums"`
+}
+
+// PlaylistInput - данные для создания или переименования плейлиста
+type PlaylistInput struct {
+	Name  string `json:"name" example:"Дорожный"`
+	Owner string `json:"owner" example:"user-1"`
+}
+
+// Validate проверяет, что название плейлиста не пустое.
+func (pi *PlaylistInput) Validate() error {
+	if pi.Name == "" {
+		return errors.New("playlist name cannot be empty")
+	}
+	return nil
+}
+
+// PlaylistSongInput - песня, добавляемая в плейлист, или новая позиция песни
+type PlaylistSongInput struct {
+	SongID   uint `json:"song_id"`
+	Position int  `json:"position"` // Начиная с 1; 0 - в конец плейлиста
+}
+
+// PlaylistEntry - песня плейлиста вместе с её позицией
+type PlaylistEntry struct {
+	Position int        `json:"position"`
+	AddedAt  time.Time  `json:"added_at"`
+	Song     SongDetail `json:"song"`
+}
+
+// PlaylistResponse - плейлист с постраничным списком песен
+type PlaylistResponse struct {
+	Playlist
+	TotalItems int64           `json:"total_items"`
+	Page       int             `json:"page"`
+	Limit      int             `json:"limit"`
+	Items      []PlaylistEntry `json:"items"`
+}
+
+// PlaylistsResponse - страница списка плейлистов
+type PlaylistsResponse struct {
+	TotalItems int64      `json:"total_items"`
+	Page       int        `json:"page"`
+	Limit      int        `json:"limit"`
+	Playlists  []Playlist `json:"playlists"`
 }
 
+// SongText - текст песни, разбитый на разделы со строками.
+// Записи, сохранённые до появления разделов, хранят только плоский список куплетов Verses;
+// он же принимается в запросах на изменение текста.
 type SongText struct {
-	Verses []string `json:"verses"` // Срез для хранения куплетов
+	Sections []lyrics.Section `json:"sections,omitempty"` // Разделы по строкам с числом повторов
+	Verses   []string         `json:"verses,omitempty"`   // Плоский формат: куплет на строку, без пометок о повторе
+}
+
+// NewSongText собирает текст песни из разобранных разделов
+func NewSongText(sections []lyrics.Section) SongText {
+	return SongText{Sections: sections}
+}
+
+// WithSections возвращает текст в виде разделов. У текстов в плоском формате
+// заголовки и пометки о повторе разбираются из куплетов.
+func (st SongText) WithSections() SongText {
+	if len(st.Sections) > 0 {
+		return SongText{Sections: st.Sections}
+	}
+	return NewSongText(lyrics.ParseVerses(st.Verses))
 }
 
+// ParseSongText разбирает текст, сохранённый в SongDetail.Text, и возвращает его в виде разделов.
+// Пустая строка означает, что текста у песни нет.
+func ParseSongText(raw string) (SongText, error) {
+	if raw == "" {
+		return SongText{}, nil
+	}
+	var text SongText
+	if err := json.Unmarshal([]byte(raw), &text); err != nil {
+		return SongText{}, err
+	}
+	return text.WithSections(), nil
+}
+
+// FlatVerses возвращает текст в плоском формате: по строке на раздел
+func (st SongText) FlatVerses() []string {
+	return lyrics.Verses(st.WithSections().Sections)
+}
+
+// SongVerse - куплет песни в поисковом индексе. Пересобирается при каждом сохранении песни.
+type SongVerse struct {
+	SongID     uint   `gorm:"primaryKey"`
+	VerseIndex int    `gorm:"primaryKey"` // Номер куплета, начиная с 0
+	Text       string `gorm:"not null"`
+}
+
+// SearchHit - песня, найденная полнотекстовым поиском
+type SearchHit struct {
+	Song       SongDetail `json:"song"`
+	Rank       float64    `json:"rank"`              // Релевантность; совпадение в названии весит больше, чем в тексте
+	VerseIndex *int       `json:"verse_index"`       // Наиболее релевантный куплет, начиная с 0; null, если совпало только название или исполнитель
+	Snippet    string     `json:"snippet,omitempty"` // Фрагмент куплета с совпадениями, выделенными <mark>
+}
+
+// SearchResponse - страница результатов поиска
+type SearchResponse struct {
+	Query      string      `json:"query"`
+	TotalItems int64       `json:"total_items"`
+	Page       int         `json:"page"`
+	Limit      int         `json:"limit"`
+	Results    []SearchHit `json:"results"`
+}
+
+// SameDate сообщает, совпадают ли две необязательные даты; отсутствующие даты равны только друг другу
+func SameDate(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// Статусы обогащения песни данными из внешнего API
+const (
+	EnrichmentPending = "pending" // Данные ещё не получены
+	EnrichmentDone    = "done"    // Данные успешно получены
+)
+
 type SongDetail struct {
-	ID          uint `gorm:"primaryKey"`
-	ArtistID    uint
-	GroupName   string
-	SongName    string
-	ReleaseDate time.Time `gorm:"type:date"` // Указываем тип поля в базе данных
-	Text        string
-	SongURL     string    `gorm:"column:song_url"` // Убедитесь, что это поле присутствует
-	CreatedAt   time.Time `gorm:"autoCreateTime"`
+	ID               uint `gorm:"primaryKey"`
+	ArtistID         uint `gorm:"uniqueIndex:idx_song_details_song_artist,priority:2,where:deleted_at IS NULL"`
+	GroupName        string
+	SongName         string     `gorm:"uniqueIndex:idx_song_details_song_artist,priority:1,where:deleted_at IS NULL"`
+	ReleaseDate      *time.Time `gorm:"type:date"` // Дата релиза; nil, если неизвестна
+	Text             string
+	SongURL          string         `gorm:"column:song_url"`    // Убедитесь, что это поле присутствует
+	EnrichmentStatus string         `gorm:"default:pending"`    // Статус обогащения из внешнего API
+	Version          int64          `gorm:"not null;default:1"` // Растёт при каждом изменении; отдаётся в ETag
+	CreatedAt        time.Time      `gorm:"autoCreateTime"`
+	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"` // Момент переноса в корзину; удалённые песни скрыты из всех запросов
+}
+
+// Типы релизов
+const (
+	AlbumTypeAlbum       = "album"
+	AlbumTypeSingle      = "single"
+	AlbumTypeEP          = "ep"
+	AlbumTypeCompilation = "compilation"
+)
+
+// Album представляет релиз исполнителя с упорядоченным списком треков
+type Album struct {
+	ID          uint           `json:"id" gorm:"primaryKey"`               // Уникальный идентификатор релиза
+	Title       string         `json:"title" gorm:"not null"`              // Название релиза
+	ReleaseDate *time.Time     `json:"release_date" gorm:"type:date"`      // Дата выхода
+	Type        string         `json:"type" gorm:"not null;default:album"` // album, single, ep или compilation
+	ArtistID    uint           `json:"artist_id" gorm:"not null;index"`    // Основной исполнитель
+	Tracks      []AlbumTrack   `json:"tracks" gorm:"foreignKey:AlbumID;constraint:OnDelete:CASCADE"`
+	CreatedAt   time.Time      `json:"created_at" gorm:"autoCreateTime"` // Дата создания записи
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`                   // Момент переноса в корзину вместе с исполнителем
+}
+
+// AlbumTrack - позиция песни в релизе. Одна песня может входить в несколько релизов.
+type AlbumTrack struct {
+	AlbumID     uint `json:"-" gorm:"primaryKey"`
+	DiscNumber  int  `json:"disc_number" gorm:"primaryKey"`  // Номер диска, начиная с 1
+	TrackNumber int  `json:"track_number" gorm:"primaryKey"` // Номер трека на диске, начиная с 1
+	SongID      uint `json:"song_id" gorm:"not null;index"`
+}
+
+// Playlist - пользовательский плейлист
+type Playlist struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name" gorm:"not null"`
+	Owner     string    `json:"owner" gorm:"index"`               // Владелец плейлиста
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"` // Дата создания записи
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"` // Дата последнего изменения, в том числе состава
+}
+
+// PlaylistItem - песня в плейлисте. Позиции идут подряд начиная с 1.
+type PlaylistItem struct {
+	PlaylistID uint      `json:"playlist_id" gorm:"primaryKey"`
+	SongID     uint      `json:"song_id" gorm:"primaryKey;index"`
+	Position   int       `json:"position" gorm:"not null"`
+	AddedAt    time.Time `json:"added_at" gorm:"autoCreateTime"`
 }
 
 // Song представляет минимальную информацию о песне для создания
@@ -49,21 +303,243 @@ type SongUpdateResponse struct {
 	Text        SongText `json:"text"`
 }
 
+// PatchField - поле документа JSON Merge Patch (RFC 7396). Отличает ключ, которого нет в документе,
+// от ключа со значением null, который очищает поле.
+type PatchField[T any] struct {
+	Set   bool // Ключ есть в документе
+	Null  bool // Значение null
+	Value T
+}
+
+// UnmarshalJSON вызывается только для ключей, которые есть в документе, в том числе для null
+func (f *PatchField[T]) UnmarshalJSON(data []byte) error {
+	f.Set = true
+	if string(data) == "null" {
+		f.Null = true
+		return nil
+	}
+	return json.Unmarshal(data, &f.Value)
+}
+
+// SongPatch - изменения песни в формате JSON Merge Patch с теми же полями, что и в SongUpdateResponse.
+// Отсутствующие поля не меняются, null очищает поле, значение заменяет его; текст заменяется целиком.
+type SongPatch struct {
+	ArtistName  PatchField[string]   `json:"artist_name" swaggertype:"string" example:"Исполнитель"`
+	SongName    PatchField[string]   `json:"song_name" swaggertype:"string" example:"Название песни"`
+	ReleaseDate PatchField[string]   `json:"release_date" swaggertype:"string" example:"1985.02.05"` // null очищает дату релиза
+	GroupLink   PatchField[string]   `json:"group_link" swaggertype:"string" example:"http://example.com"`
+	Text        PatchField[SongText] `json:"text" swaggertype:"object"` // null удаляет текст
+}
+
+// Форматы ответа с текстом песни
+const (
+	LyricsFormatSections = "sections" // Разделы со строками и числом повторов
+	LyricsFormatLegacy   = "legacy"   // Плоский список куплетов, как до появления разделов
+)
+
 type PaginatedLyricsRespons struct {
-	SongName    string   `json:"song_name"`    // Название песни
-	VersePage   int      `json:"verse_page"`   // Номер страницы куплетов
-	VerseLimit  int      `json:"verse_limit"`  // Количество куплетов на странице
-	TotalVerses int      `json:"total_verses"` // Общее количество куплетов
-	Verses      []string `json:"verses"`       // Пагинированные куплеты
+	SongName    string `json:"song_name"`    // Название песни
+	VersePage   int    `json:"verse_page"`   // Номер страницы разделов
+	VerseLimit  int    `json:"verse_limit"`  // Количество разделов на странице
+	TotalVerses int    `json:"total_verses"` // Общее количество разделов
+	Format      string `json:"format" enums:"sections,legacy"`
+	Expanded    bool   `json:"expanded"` // Повторы выписаны полностью
+	// Разделы страницы; заполняется в формате sections
+	Sections []lyrics.Section `json:"sections,omitempty"`
+	// Куплеты страницы в плоском формате; заполняется в формате legacy
+	Verses []string `json:"verses,omitempty"`
+}
+
+// LyricsAtResponse - строка, которая звучит в заданный момент песни, и следующие за ней
+type LyricsAtResponse struct {
+	SongName string             `json:"song_name"`
+	Position int64              `json:"position_ms"` // Запрошенный момент в миллисекундах
+	Current  *lyrics.TimedLine  `json:"current"`     // null, если первая строка ещё не началась
+	Next     []lyrics.TimedLine `json:"next"`
+}
+
+// CatalogRecord - запись выгрузки каталога. Запись без названия песни описывает исполнителя,
+// чтобы при переносе не потерялись исполнители без песен.
+type CatalogRecord struct {
+	Artist      string    `json:"artist" example:"Любэ"`
+	Song        string    `json:"song,omitempty" example:"Конь"`
+	ReleaseDate string    `json:"release_date,omitempty" format:"date" example:"1994-01-01"` // Дата релиза в формате YYYY-MM-DD
+	Link        string    `json:"link,omitempty" example:"http://example.com"`
+	Text        *SongText `json:"text,omitempty"`
+}
+
+// Результаты импорта записи каталога
+const (
+	ImportCreated = "created"
+	ImportUpdated = "updated"
+	ImportSkipped = "skipped" // Запись совпадает с сохранённой
+	ImportFailed  = "failed"
+)
+
+// ImportRowResult - результат импорта одной записи
+type ImportRowResult struct {
+	Row    int    `json:"row"` // Номер записи, начиная с 1
+	Artist string `json:"artist"`
+	Song   string `json:"song,omitempty"`
+	Status string `json:"status" enums:"created,updated,skipped,failed"`
+	Error  string `json:"error,omitempty"` // Причина для failed
+}
+
+// ImportReport - отчёт об импорте каталога. При dry_run изменения не сохраняются.
+type ImportReport struct {
+	DryRun  bool              `json:"dry_run"`
+	Created int               `json:"created"`
+	Updated int               `json:"updated"`
+	Skipped int               `json:"skipped"`
+	Failed  int               `json:"failed"`
+	Rows    []ImportRowResult `json:"rows"`
+}
+
+// Add добавляет результат записи в отчёт и учитывает его в счётчиках
+func (r *ImportReport) Add(result ImportRowResult) {
+	switch result.Status {
+	case ImportCreated:
+		r.Created++
+	case ImportUpdated:
+		r.Updated++
+	case ImportSkipped:
+		r.Skipped++
+	case ImportFailed:
+		r.Failed++
+	}
+	r.Rows = append(r.Rows, result)
+}
+
+// Результаты добавления песни в пакете
+const (
+	BatchCreated  = "created"
+	BatchConflict = "conflict" // Песня уже есть у исполнителя или повторяется в пакете
+	BatchInvalid  = "invalid"
+	BatchAborted  = "aborted" // Атомарный пакет отклонён из-за ошибок в других песнях
+)
+
+// BatchSongResult - результат добавления одной песни пакета
+type BatchSongResult struct {
+	Index  int         `json:"index"` // Номер песни в запросе, начиная с 0
+	Status string      `json:"status" enums:"created,conflict,invalid,aborted"`
+	Error  string      `json:"error,omitempty"` // Причина для conflict и invalid
+	Song   *SongDetail `json:"song,omitempty"`  // Добавленная песня
+}
+
+// BatchSongsResponse - итог пакетного добавления песен
+type BatchSongsResponse struct {
+	Atomic    bool              `json:"atomic"`
+	Created   int               `json:"created"`
+	Conflicts int               `json:"conflicts"`
+	Invalid   int               `json:"invalid"`
+	Results   []BatchSongResult `json:"results"`
+}
+
+// Типы записей в корзине
+const (
+	TrashSong   = "song"
+	TrashArtist = "artist"
+)
+
+// TrashItem - удалённая песня или исполнитель в корзине
+type TrashItem struct {
+	Type      string    `json:"type" enums:"song,artist"`
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`                // Название песни или имя исполнителя
+	ArtistID  uint      `json:"artist_id,omitempty"` // Исполнитель песни
+	Artist    string    `json:"artist,omitempty"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// TrashResponse - страница корзины, от недавно удалённых к давним
+type TrashResponse struct {
+	TotalItems int64       `json:"total_items"`
+	Page       int         `json:"page"`
+	Limit      int         `json:"limit"`
+	Items      []TrashItem `json:"items"`
+}
+
+// TrashPurgeResult - количество записей, удалённых из корзины навсегда
+type TrashPurgeResult struct {
+	Songs   int64 `json:"songs"`
+	Artists int64 `json:"artists"`
+}
+
+// SongRevision - состояние песни после очередной правки. Номера правок у каждой песни идут подряд начиная с 1.
+type SongRevision struct {
+	ID          uint       `json:"-" gorm:"primaryKey"`
+	SongID      uint       `json:"song_id" gorm:"not null;uniqueIndex:idx_song_revisions_song_number,priority:1"`
+	Number      int        `json:"number" gorm:"not null;uniqueIndex:idx_song_revisions_song_number,priority:2"`
+	ClientID    string     `json:"client_id,omitempty"` // Клиент из заголовка X-Client-ID; пусто, если клиент не представился
+	CreatedAt   time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	ArtistID    uint       `json:"artist_id"`
+	GroupName   string     `json:"group_name"`
+	SongName    string     `json:"song_name"`
+	ReleaseDate *time.Time `json:"release_date" gorm:"type:date"`
+	SongURL     string     `json:"song_url" gorm:"column:song_url"`
+	Text        string     `json:"-"`
+}
+
+// SongRevisionResponse - правка песни вместе с текстом
+type SongRevisionResponse struct {
+	SongRevision
+	Text SongText `json:"text"`
+}
+
+// RevisionsResponse - страница истории правок песни, от новых к старым
+type RevisionsResponse struct {
+	TotalItems int64          `json:"total_items"`
+	Page       int            `json:"page"`
+	Limit      int            `json:"limit"`
+	Revisions  []SongRevision `json:"revisions"`
+}
+
+// FieldChange - поле песни, изменившееся между правками
+type FieldChange struct {
+	Field string `json:"field" enums:"song_name,artist,release_date,song_url"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// RevisionDiff - различия между двумя правками песни
+type RevisionDiff struct {
+	SongID uint                 `json:"song_id"`
+	From   int                  `json:"from"`
+	To     int                  `json:"to"`
+	Fields []FieldChange        `json:"fields"`
+	Lyrics []lyrics.VerseChange `json:"lyrics"` // Куплеты обеих правок по порядку; пусто, если текст не менялся
+}
+
+// AmbiguousSongResponse возвращается, если по названию найдено несколько песен
+type AmbiguousSongResponse struct {
+	Error        string `json:"error"`
+	SongName     string `json:"song_name"`
+	CandidateIDs []uint `json:"candidate_ids"` // ID песен с таким названием
 }
 
 type SongsResponse struct {
-	TotalItems int          `json:"total_items"`
-	Page       int          `json:"page"`
+	TotalItems *int64       `json:"total_items,omitempty"` // Не заполняется при count=false
+	TotalPages *int         `json:"total_pages,omitempty"` // Не заполняется при count=false
+	Page       int          `json:"page,omitempty"`        // Не заполняется в режиме курсора
 	Limit      int          `json:"limit"`
+	HasNext    bool         `json:"has_next"`
+	NextCursor string       `json:"next_cursor,omitempty"` // Значение параметра after для следующей страницы
 	Songs      []SongDetail `json:"songs"`
 }
 
+// DBPoolStats - состояние пула соединений с базой данных
+type DBPoolStats struct {
+	MaxOpenConnections int     `json:"max_open_connections"` // Ограничение пула; 0 - без ограничения
+	OpenConnections    int     `json:"open_connections"`     // Открыто сейчас, занятых и простаивающих
+	InUse              int     `json:"in_use"`
+	Idle               int     `json:"idle"`
+	WaitCount          int64   `json:"wait_count"`           // Сколько раз запрос ждал свободного соединения
+	WaitDurationMS     float64 `json:"wait_duration_ms"`     // Сколько всего запросы ждали соединения
+	MaxIdleClosed      int64   `json:"max_idle_closed"`      // Закрыто из-за ограничения простаивающих соединений
+	MaxIdleTimeClosed  int64   `json:"max_idle_time_closed"` // Закрыто из-за времени простоя
+	MaxLifetimeClosed  int64   `json:"max_lifetime_closed"`  // Закрыто из-за времени жизни
+}
+
 // Validate проверяет, что поля в SongInput не пустые.
 func (si *SongInput) Validate() error {
 	if si.Group == "" {
@@ -64,3 +64,68 @@ func TestSongInput_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestAlbumInput_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    models.AlbumInput
+		wantErr  string
+		wantType string
+	}{
+		{
+			name: "Valid input with defaults",
+			input: models.AlbumInput{
+				Title:    "Black Holes and Revelations",
+				ArtistID: 1,
+				Tracks:   []models.AlbumTrackInput{{SongID: 1, TrackNumber: 1}, {SongID: 2, TrackNumber: 2}},
+			},
+			wantType: models.AlbumTypeAlbum,
+		},
+		{
+			name:     "Compilation with the same song on two discs",
+			input:    models.AlbumInput{Title: "Best of", ArtistID: 1, Type: models.AlbumTypeCompilation, Tracks: []models.AlbumTrackInput{{SongID: 1, DiscNumber: 1, TrackNumber: 1}, {SongID: 1, DiscNumber: 2, TrackNumber: 1}}},
+			wantType: models.AlbumTypeCompilation,
+		},
+		{
+			name:    "Empty title",
+			input:   models.AlbumInput{ArtistID: 1},
+			wantErr: "album title cannot be empty",
+		},
+		{
+			name:    "Missing artist",
+			input:   models.AlbumInput{Title: "Title"},
+			wantErr: "artist_id is required",
+		},
+		{
+			name:    "Unknown type",
+			input:   models.AlbumInput{Title: "Title", ArtistID: 1, Type: "mixtape"},
+			wantErr: `unknown album type "mixtape"`,
+		},
+		{
+			name:    "Duplicate position",
+			input:   models.AlbumInput{Title: "Title", ArtistID: 1, Tracks: []models.AlbumTrackInput{{SongID: 1, TrackNumber: 1}, {SongID: 2, DiscNumber: 1, TrackNumber: 1}}},
+			wantErr: "duplicate track position 1-1",
+		},
+		{
+			name:    "Missing track number",
+			input:   models.AlbumInput{Title: "Title", ArtistID: 1, Tracks: []models.AlbumTrackInput{{SongID: 1}}},
+			wantErr: "track 1: song_id, disc_number and track_number must be positive",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.input.Validate()
+
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantType, tt.input.Type)
+			for _, track := range tt.input.Tracks {
+				assert.Positive(t, track.DiscNumber)
+			}
+		})
+	}
+}
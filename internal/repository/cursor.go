@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"music/internal/models"
+)
+
+// ErrInvalidCursor возвращается, если курсор повреждён или выдан для другой сортировки
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// SongCursor - позиция в списке песен для постраничного обхода по ключу.
+// Хранит значения полей сортировки последней выданной песни, поэтому вставки и удаления
+// не сдвигают следующие страницы, а запрос не зависит от номера страницы.
+type SongCursor struct {
+	Sort        string    `json:"s,omitempty"` // Сортировка, для которой выдан курсор
+	ID          uint      `json:"id"`
+	ReleaseDate time.Time `json:"rd"`
+	Title       string    `json:"t"`
+	Artist      string    `json:"a"`
+	CreatedAt   time.Time `json:"ca"`
+}
+
+// NewSongCursor создаёт курсор, указывающий на место сразу после песни
+func NewSongCursor(song models.SongDetail, sorts []SongSort) SongCursor {
+	return SongCursor{
+		Sort:        FormatSongSort(sorts),
+		ID:          song.ID,
+		ReleaseDate: releaseDay(song),
+		Title:       song.SongName,
+		Artist:      song.GroupName,
+		CreatedAt:   song.CreatedAt,
+	}
+}
+
+// Encode возвращает непрозрачное представление курсора для передачи клиенту
+func (c SongCursor) Encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeSongCursor разбирает курсор и проверяет, что он выдан для той же сортировки
+func DecodeSongCursor(raw string, sorts []SongSort) (*SongCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor SongCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == 0 {
+		return nil, ErrInvalidCursor
+	}
+	if cursor.Sort != FormatSongSort(sorts) {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// FormatSongSort записывает сортировку в том же виде, в котором её принимает ParseSongSort
+func FormatSongSort(sorts []SongSort) string {
+	parts := make([]string, 0, len(sorts))
+	for _, sort := range sorts {
+		if sort.Desc {
+			parts = append(parts, "-"+sort.Field)
+		} else {
+			parts = append(parts, sort.Field)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// releaseDay возвращает дату релиза для сортировки и курсора. Песни без даты идут как песни
+// с нулевой датой - раньше всех, так же, как их упорядочивает COALESCE в запросах Postgres.
+func releaseDay(song models.SongDetail) time.Time {
+	if song.ReleaseDate == nil {
+		return time.Time{}
+	}
+	return *song.ReleaseDate
+}
+
+// song возвращает песню с сохранёнными в курсоре значениями для сравнения с другими песнями
+func (c SongCursor) song() models.SongDetail {
+	return models.SongDetail{
+		ID:          c.ID,
+		ReleaseDate: &c.ReleaseDate,
+		SongName:    c.Title,
+		GroupName:   c.Artist,
+		CreatedAt:   c.CreatedAt,
+	}
+}
@@ -0,0 +1,507 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"music/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// NewMemory создаёт потокобезопасные хранилища в памяти, используемые в тестах и для запуска без базы данных
+func NewMemory() Repositories {
+	store := &memoryStore{
+		artists:        make(map[uint]models.Artist),
+		songs:          make(map[uint]models.SongDetail),
+		albums:         make(map[uint]models.Album),
+		playlists:      make(map[uint]models.Playlist),
+		playlistItems:  make(map[uint][]models.PlaylistItem),
+		trashedArtists: make(map[uint]models.Artist),
+		trashedSongs:   make(map[uint]models.SongDetail),
+		trashedAlbums:  make(map[uint]models.Album),
+		trashedTracks:  make(map[uint][]models.AlbumTrack),
+		trashedItems:   make(map[uint][]models.PlaylistItem),
+		revisions:      make(map[uint][]models.SongRevision),
+	}
+	return store.repositories()
+}
+
+func (s *memoryStore) repositories() Repositories {
+	return Repositories{
+		Songs:     &memorySongs{store: s},
+		Artists:   &memoryArtists{store: s},
+		Albums:    &memoryAlbums{store: s},
+		Playlists: &memoryPlaylists{store: s},
+		Trash:     &memoryTrash{store: s},
+		Revisions: &memoryRevisions{store: s},
+		Tx:        &memoryTx{store: s},
+	}
+}
+
+// memoryStore хранит все сущности под одной блокировкой, чтобы операции над связанными данными были согласованы.
+// Удалённые песни, исполнители и релизы лежат в отдельных картах и поэтому не видны остальным операциям.
+type memoryStore struct {
+	mu             sync.RWMutex
+	artists        map[uint]models.Artist
+	songs          map[uint]models.SongDetail
+	albums         map[uint]models.Album
+	playlists      map[uint]models.Playlist
+	playlistItems  map[uint][]models.PlaylistItem // Позиции плейлиста в порядке воспроизведения
+	trashedArtists map[uint]models.Artist
+	trashedSongs   map[uint]models.SongDetail
+	trashedAlbums  map[uint]models.Album
+	trashedTracks  map[uint][]models.AlbumTrack   // Треки песен из корзины по ID песни
+	trashedItems   map[uint][]models.PlaylistItem // Позиции в плейлистах песен из корзины по ID песни
+	revisions      map[uint][]models.SongRevision // История правок песни в порядке номеров
+	nextArtistID   uint
+	nextSongID     uint
+	nextAlbumID    uint
+	nextPlaylistID uint
+}
+
+// sortedSongs возвращает копии песен, упорядоченные по ID. Вызывается под блокировкой.
+func (s *memoryStore) sortedSongs(match func(models.SongDetail) bool) []models.SongDetail {
+	songs := make([]models.SongDetail, 0, len(s.songs))
+	for _, song := range s.songs {
+		if match(song) {
+			songs = append(songs, song)
+		}
+	}
+	sort.Slice(songs, func(i, j int) bool { return songs[i].ID < songs[j].ID })
+	return songs
+}
+
+// songExists проверяет уникальность пары (название, исполнитель). Вызывается под блокировкой.
+func (s *memoryStore) songExists(song *models.SongDetail) bool {
+	for _, existing := range s.songs {
+		if existing.ID != song.ID && existing.ArtistID == song.ArtistID && existing.SongName == song.SongName {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSong проверяет песню на соответствие фильтру, кроме пагинации и релиза. Вызывается под блокировкой.
+func (s *memoryStore) matchSong(song models.SongDetail, filter SongFilter) bool {
+	if filter.ArtistID != 0 && song.ArtistID != filter.ArtistID {
+		return false
+	}
+	if filter.SongName != "" && !strings.EqualFold(song.SongName, filter.SongName) {
+		return false
+	}
+	if filter.ArtistName != "" &&
+		!strings.Contains(strings.ToLower(s.artists[song.ArtistID].Name), strings.ToLower(filter.ArtistName)) {
+		return false
+	}
+	if filter.Title != "" && !strings.Contains(strings.ToLower(song.SongName), strings.ToLower(filter.Title)) {
+		return false
+	}
+	// Как и в SQL, песня без даты релиза не проходит ни одно условие на дату
+	if (filter.ReleaseDate != nil || filter.ReleasedFrom != nil || filter.ReleasedTo != nil) && song.ReleaseDate == nil {
+		return false
+	}
+	if filter.ReleaseDate != nil && !song.ReleaseDate.Equal(*filter.ReleaseDate) {
+		return false
+	}
+	if filter.ReleasedFrom != nil && song.ReleaseDate.Before(*filter.ReleasedFrom) {
+		return false
+	}
+	if filter.ReleasedTo != nil && song.ReleaseDate.After(*filter.ReleasedTo) {
+		return false
+	}
+	if filter.CreatedFrom != nil && song.CreatedAt.Before(*filter.CreatedFrom) {
+		return false
+	}
+	if filter.CreatedTo != nil && song.CreatedAt.After(*filter.CreatedTo) {
+		return false
+	}
+	if filter.HasLyrics != nil && (len(songVerses(song.Text)) > 0) != *filter.HasLyrics {
+		return false
+	}
+	return true
+}
+
+// sortSongs упорядочивает песни по полям сортировки фильтра. Без сортировки исходный порядок не меняется.
+func sortSongs(songs []models.SongDetail, sorts []SongSort) {
+	if len(sorts) == 0 {
+		return
+	}
+	sort.Slice(songs, func(i, j int) bool { return compareSongsBy(songs[i], songs[j], sorts) < 0 })
+}
+
+// compareSongsBy сравнивает песни по полям сортировки, а при равенстве - по ID
+func compareSongsBy(a, b models.SongDetail, sorts []SongSort) int {
+	for _, s := range sorts {
+		if result := compareSongs(a, b, s.Field); result != 0 {
+			if s.Desc {
+				return -result
+			}
+			return result
+		}
+	}
+	return cmp.Compare(a.ID, b.ID)
+}
+
+func compareSongs(a, b models.SongDetail, field string) int {
+	switch field {
+	case SongSortReleaseDate:
+		return releaseDay(a).Compare(releaseDay(b))
+	case SongSortTitle:
+		return strings.Compare(strings.ToLower(a.SongName), strings.ToLower(b.SongName))
+	case SongSortArtist:
+		return strings.Compare(strings.ToLower(a.GroupName), strings.ToLower(b.GroupName))
+	case SongSortCreatedAt:
+		return a.CreatedAt.Compare(b.CreatedAt)
+	}
+	return 0
+}
+
+// albumSongs возвращает песни релиза в порядке треклиста с учётом остальных условий фильтра, кроме пагинации. Вызывается под блокировкой.
+func (s *memoryStore) albumSongs(filter SongFilter) []models.SongDetail {
+	album, ok := s.albums[filter.AlbumID]
+	if !ok {
+		return []models.SongDetail{}
+	}
+
+	songs := make([]models.SongDetail, 0, len(album.Tracks))
+	for _, track := range album.Tracks {
+		song, ok := s.songs[track.SongID]
+		if !ok || !s.matchSong(song, filter) {
+			continue
+		}
+		songs = append(songs, song)
+	}
+	return songs
+}
+
+type memorySongs struct {
+	store *memoryStore
+}
+
+func (m *memorySongs) List(_ context.Context, filter SongFilter) ([]models.SongDetail, int64, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	var songs []models.SongDetail
+	if filter.AlbumID != 0 {
+		songs = m.store.albumSongs(filter)
+	} else {
+		songs = m.store.sortedSongs(func(song models.SongDetail) bool { return m.store.matchSong(song, filter) })
+	}
+	sortSongs(songs, filter.Sort)
+	total := int64(len(songs))
+
+	if filter.After != nil {
+		if filter.AlbumID != 0 && len(filter.Sort) == 0 {
+			return nil, 0, ErrInvalidCursor
+		}
+		after := filter.After.song()
+		index := sort.Search(len(songs), func(i int) bool { return compareSongsBy(songs[i], after, filter.Sort) > 0 })
+		songs = songs[index:]
+	}
+
+	return paginate(songs, filter.Limit, filter.Offset), total, nil
+}
+
+func (m *memorySongs) GetByID(_ context.Context, id uint) (*models.SongDetail, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	song, ok := m.store.songs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &song, nil
+}
+
+func (m *memorySongs) FindByName(_ context.Context, name string) ([]models.SongDetail, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	return m.store.sortedSongs(func(song models.SongDetail) bool { return song.SongName == name }), nil
+}
+
+func (m *memorySongs) FindByArtistAndName(_ context.Context, artistID uint, name string) (*models.SongDetail, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	for _, song := range m.store.songs {
+		if song.ArtistID == artistID && song.SongName == name {
+			return &song, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (m *memorySongs) Create(ctx context.Context, song *models.SongDetail) error {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	if m.store.songExists(song) {
+		return ErrAlreadyExists
+	}
+
+	m.store.nextSongID++
+	song.ID = m.store.nextSongID
+	if song.CreatedAt.IsZero() {
+		song.CreatedAt = time.Now()
+	}
+	if song.EnrichmentStatus == "" {
+		song.EnrichmentStatus = models.EnrichmentPending
+	}
+	song.Version = 1
+	m.store.songs[song.ID] = *song
+	m.store.addRevision(ctx, song)
+	return nil
+}
+
+func (m *memorySongs) CreateMany(ctx context.Context, songs []*models.SongDetail) ([]bool, error) {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	created := make([]bool, len(songs))
+	now := time.Now()
+	for i, song := range songs {
+		if m.store.songExists(song) {
+			continue
+		}
+		m.store.nextSongID++
+		song.ID = m.store.nextSongID
+		if song.CreatedAt.IsZero() {
+			song.CreatedAt = now
+		}
+		if song.EnrichmentStatus == "" {
+			song.EnrichmentStatus = models.EnrichmentPending
+		}
+		song.Version = 1
+		m.store.songs[song.ID] = *song
+		m.store.addRevision(ctx, song)
+		created[i] = true
+	}
+	return created, nil
+}
+
+func (m *memorySongs) Update(ctx context.Context, song *models.SongDetail) error {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	previous, ok := m.store.songs[song.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if previous.Version != song.Version {
+		return ErrVersionConflict
+	}
+	if m.store.songExists(song) {
+		return ErrAlreadyExists
+	}
+	song.Version++
+	m.store.songs[song.ID] = *song
+	revisions := updateRevisions(ctx, &previous, song, len(m.store.revisions[song.ID]))
+	m.store.revisions[song.ID] = append(m.store.revisions[song.ID], revisions...)
+	return nil
+}
+
+func (m *memorySongs) Delete(_ context.Context, id uint, version int64) error {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	song, ok := m.store.songs[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if version != 0 && song.Version != version {
+		return ErrVersionConflict
+	}
+	m.store.trashSong(song, time.Now())
+	return nil
+}
+
+type memoryArtists struct {
+	store *memoryStore
+}
+
+func (m *memoryArtists) List(_ context.Context, limit, offset int) ([]models.Artist, int64, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	artists := make([]models.Artist, 0, len(m.store.artists))
+	for _, artist := range m.store.artists {
+		artists = append(artists, artist)
+	}
+	sort.Slice(artists, func(i, j int) bool { return artists[i].ID < artists[j].ID })
+
+	return paginate(artists, limit, offset), int64(len(artists)), nil
+}
+
+func (m *memoryArtists) GetByID(_ context.Context, id uint) (*models.Artist, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	artist, ok := m.store.artists[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &artist, nil
+}
+
+func (m *memoryArtists) GetByName(_ context.Context, name string) (*models.Artist, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	for _, artist := range m.store.artists {
+		if artist.Name == name {
+			return &artist, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (m *memoryArtists) Create(_ context.Context, artist *models.Artist) error {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	for _, existing := range m.store.artists {
+		if existing.Name == artist.Name {
+			return ErrAlreadyExists
+		}
+	}
+
+	m.store.nextArtistID++
+	artist.ID = m.store.nextArtistID
+	artist.Version = 1
+	if artist.CreatedAt.IsZero() {
+		artist.CreatedAt = time.Now()
+	}
+	m.store.artists[artist.ID] = *artist
+	return nil
+}
+
+func (m *memoryArtists) EnsureNames(_ context.Context, names []string) (map[string]models.Artist, error) {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	byName := make(map[string]models.Artist, len(m.store.artists))
+	for _, artist := range m.store.artists {
+		byName[artist.Name] = artist
+	}
+
+	result := make(map[string]models.Artist, len(names))
+	now := time.Now()
+	for _, name := range names {
+		artist, ok := byName[name]
+		if !ok {
+			m.store.nextArtistID++
+			artist = models.Artist{ID: m.store.nextArtistID, Name: name, Version: 1, CreatedAt: now}
+			m.store.artists[artist.ID] = artist
+			byName[name] = artist
+		}
+		result[name] = artist
+	}
+	return result, nil
+}
+
+func (m *memoryArtists) Rename(_ context.Context, id uint, version int64, name string) (*models.Artist, error) {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	artist, ok := m.store.artists[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if version != 0 && artist.Version != version {
+		return nil, ErrVersionConflict
+	}
+	for _, existing := range m.store.artists {
+		if existing.ID != id && existing.Name == name {
+			return nil, ErrAlreadyExists
+		}
+	}
+
+	artist.Name = name
+	artist.Version++
+	m.store.artists[id] = artist
+	for songID, song := range m.store.songs {
+		if song.ArtistID == id {
+			song.GroupName = name
+			song.Version++
+			m.store.songs[songID] = song
+		}
+	}
+	// Песни в корзине тоже переименовываются, чтобы после восстановления имя было актуальным
+	for songID, song := range m.store.trashedSongs {
+		if song.ArtistID == id {
+			song.GroupName = name
+			song.Version++
+			m.store.trashedSongs[songID] = song
+		}
+	}
+	return &artist, nil
+}
+
+func (m *memoryArtists) Delete(_ context.Context, id uint, version int64, cascade bool) error {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	artist, ok := m.store.artists[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if version != 0 && artist.Version != version {
+		return ErrVersionConflict
+	}
+
+	var songIDs []uint
+	for songID, song := range m.store.songs {
+		if song.ArtistID == id {
+			songIDs = append(songIDs, songID)
+		}
+	}
+	var albumIDs []uint
+	for albumID, album := range m.store.albums {
+		if album.ArtistID == id {
+			albumIDs = append(albumIDs, albumID)
+		}
+	}
+	if !cascade {
+		switch {
+		case len(songIDs) > 0:
+			return ErrArtistHasSongs
+		case len(albumIDs) > 0:
+			return ErrArtistHasAlbums
+		}
+	}
+
+	// Релизы и песни получают тот же момент удаления, что и исполнитель, чтобы восстановиться вместе с ним
+	now := time.Now()
+	for _, albumID := range albumIDs {
+		album := m.store.albums[albumID]
+		delete(m.store.albums, albumID)
+		album.DeletedAt = gorm.DeletedAt{Time: now, Valid: true}
+		m.store.trashedAlbums[albumID] = album
+	}
+	for _, songID := range songIDs {
+		m.store.trashSong(m.store.songs[songID], now)
+	}
+	delete(m.store.artists, id)
+	artist.DeletedAt = gorm.DeletedAt{Time: now, Valid: true}
+	m.store.trashedArtists[id] = artist
+	return nil
+}
+
+// paginate применяет смещение и лимит к уже отфильтрованному срезу
+func paginate[T any](items []T, limit, offset int) []T {
+	if offset >= len(items) {
+		return []T{}
+	}
+	items = items[offset:]
+	if limit > 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"music/internal/models"
+)
+
+type memoryAlbums struct {
+	store *memoryStore
+}
+
+func (m *memoryAlbums) List(_ context.Context, filter AlbumFilter) ([]models.Album, int64, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	albums := make([]models.Album, 0, len(m.store.albums))
+	for _, album := range m.store.albums {
+		if filter.ArtistID == 0 || album.ArtistID == filter.ArtistID {
+			albums = append(albums, cloneAlbum(album))
+		}
+	}
+	sort.Slice(albums, func(i, j int) bool { return albums[i].ID < albums[j].ID })
+
+	return paginate(albums, filter.Limit, filter.Offset), int64(len(albums)), nil
+}
+
+func (m *memoryAlbums) GetByID(_ context.Context, id uint) (*models.Album, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	album, ok := m.store.albums[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	album = cloneAlbum(album)
+	return &album, nil
+}
+
+func (m *memoryAlbums) Create(_ context.Context, album *models.Album) error {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	if err := m.store.checkAlbumReferences(album); err != nil {
+		return err
+	}
+
+	m.store.nextAlbumID++
+	album.ID = m.store.nextAlbumID
+	if album.CreatedAt.IsZero() {
+		album.CreatedAt = time.Now()
+	}
+	m.store.saveAlbum(album)
+	return nil
+}
+
+func (m *memoryAlbums) Update(_ context.Context, album *models.Album) error {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	existing, ok := m.store.albums[album.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if err := m.store.checkAlbumReferences(album); err != nil {
+		return err
+	}
+
+	album.CreatedAt = existing.CreatedAt
+	m.store.saveAlbum(album)
+	return nil
+}
+
+func (m *memoryAlbums) Delete(_ context.Context, id uint) error {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	if _, ok := m.store.albums[id]; !ok {
+		return ErrNotFound
+	}
+	delete(m.store.albums, id)
+	return nil
+}
+
+// checkAlbumReferences проверяет, что исполнитель и песни релиза существуют. Вызывается под блокировкой.
+func (s *memoryStore) checkAlbumReferences(album *models.Album) error {
+	if _, ok := s.artists[album.ArtistID]; !ok {
+		return ErrInvalidReference
+	}
+	for _, track := range album.Tracks {
+		if _, ok := s.songs[track.SongID]; !ok {
+			return ErrInvalidReference
+		}
+	}
+	return nil
+}
+
+// saveAlbum сохраняет копию релиза с упорядоченным треклистом. Вызывается под блокировкой.
+func (s *memoryStore) saveAlbum(album *models.Album) {
+	for i := range album.Tracks {
+		album.Tracks[i].AlbumID = album.ID
+	}
+	sortTracks(album.Tracks)
+	s.albums[album.ID] = cloneAlbum(*album)
+}
+
+// removeSongFromAlbums убирает песню из всех треклистов и возвращает убранные треки. Вызывается под блокировкой.
+func (s *memoryStore) removeSongFromAlbums(songID uint) []models.AlbumTrack {
+	var removed []models.AlbumTrack
+	for id, album := range s.albums {
+		tracks := album.Tracks[:0:0]
+		for _, track := range album.Tracks {
+			if track.SongID != songID {
+				tracks = append(tracks, track)
+			} else {
+				removed = append(removed, track)
+			}
+		}
+		album.Tracks = tracks
+		s.albums[id] = album
+	}
+	return removed
+}
+
+// addAlbumTrack возвращает трек в треклист релиза. Если место занято, трек ставится в конец диска.
+// Вызывается под блокировкой.
+func (s *memoryStore) addAlbumTrack(track models.AlbumTrack) {
+	album := s.albums[track.AlbumID]
+	last := 0
+	taken := false
+	for _, existing := range album.Tracks {
+		if existing.DiscNumber != track.DiscNumber {
+			continue
+		}
+		last = max(last, existing.TrackNumber)
+		taken = taken || existing.TrackNumber == track.TrackNumber
+	}
+	if taken {
+		track.TrackNumber = last + 1
+	}
+	album.Tracks = append(album.Tracks, track)
+	sortTracks(album.Tracks)
+	s.albums[track.AlbumID] = album
+}
+
+// cloneAlbum копирует релиз вместе с треклистом, чтобы вызывающий код не менял данные хранилища
+func cloneAlbum(album models.Album) models.Album {
+	album.Tracks = append([]models.AlbumTrack{}, album.Tracks...)
+	return album
+}
+
+func sortTracks(tracks []models.AlbumTrack) {
+	sort.Slice(tracks, func(i, j int) bool {
+		if tracks[i].DiscNumber != tracks[j].DiscNumber {
+			return tracks[i].DiscNumber < tracks[j].DiscNumber
+		}
+		return tracks[i].TrackNumber < tracks[j].TrackNumber
+	})
+}
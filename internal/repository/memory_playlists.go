@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"music/internal/models"
+)
+
+type memoryPlaylists struct {
+	store *memoryStore
+}
+
+func (m *memoryPlaylists) List(_ context.Context, filter PlaylistFilter) ([]models.Playlist, int64, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	playlists := make([]models.Playlist, 0, len(m.store.playlists))
+	for _, playlist := range m.store.playlists {
+		if filter.Owner == "" || playlist.Owner == filter.Owner {
+			playlists = append(playlists, playlist)
+		}
+	}
+	sort.Slice(playlists, func(i, j int) bool { return playlists[i].ID < playlists[j].ID })
+
+	return paginate(playlists, filter.Limit, filter.Offset), int64(len(playlists)), nil
+}
+
+func (m *memoryPlaylists) GetByID(_ context.Context, id uint) (*models.Playlist, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	playlist, ok := m.store.playlists[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &playlist, nil
+}
+
+func (m *memoryPlaylists) Create(_ context.Context, playlist *models.Playlist) error {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	m.store.nextPlaylistID++
+	playlist.ID = m.store.nextPlaylistID
+	now := time.Now()
+	if playlist.CreatedAt.IsZero() {
+		playlist.CreatedAt = now
+	}
+	playlist.UpdatedAt = now
+	m.store.playlists[playlist.ID] = *playlist
+	return nil
+}
+
+func (m *memoryPlaylists) Rename(_ context.Context, id uint, name string) (*models.Playlist, error) {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	playlist, ok := m.store.playlists[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	playlist.Name = name
+	playlist.UpdatedAt = time.Now()
+	m.store.playlists[id] = playlist
+	return &playlist, nil
+}
+
+func (m *memoryPlaylists) Delete(_ context.Context, id uint) error {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	if _, ok := m.store.playlists[id]; !ok {
+		return ErrNotFound
+	}
+	delete(m.store.playlists, id)
+	delete(m.store.playlistItems, id)
+	return nil
+}
+
+func (m *memoryPlaylists) Entries(_ context.Context, id uint, limit, offset int) ([]models.PlaylistEntry, int64, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	if _, ok := m.store.playlists[id]; !ok {
+		return nil, 0, ErrNotFound
+	}
+
+	items := m.store.playlistItems[id]
+	page := paginate(items, limit, offset)
+	entries := make([]models.PlaylistEntry, 0, len(page))
+	for _, item := range page {
+		entries = append(entries, models.PlaylistEntry{Position: item.Position, AddedAt: item.AddedAt, Song: m.store.songs[item.SongID]})
+	}
+	return entries, int64(len(items)), nil
+}
+
+func (m *memoryPlaylists) AddSong(_ context.Context, playlistID, songID uint, position int) (*models.PlaylistItem, error) {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	if _, ok := m.store.playlists[playlistID]; !ok {
+		return nil, ErrNotFound
+	}
+	if _, ok := m.store.songs[songID]; !ok {
+		return nil, ErrInvalidReference
+	}
+	items := m.store.playlistItems[playlistID]
+	if indexOfSong(items, songID) >= 0 {
+		return nil, ErrAlreadyExists
+	}
+
+	item := m.store.insertPlaylistItem(models.PlaylistItem{
+		PlaylistID: playlistID,
+		SongID:     songID,
+		Position:   position,
+		AddedAt:    time.Now(),
+	})
+	return &item, nil
+}
+
+func (m *memoryPlaylists) RemoveSong(_ context.Context, playlistID, songID uint) error {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	if _, ok := m.store.playlists[playlistID]; !ok {
+		return ErrNotFound
+	}
+	items := m.store.playlistItems[playlistID]
+	index := indexOfSong(items, songID)
+	if index < 0 {
+		return ErrNotFound
+	}
+
+	m.store.setPlaylistItems(playlistID, append(items[:index:index], items[index+1:]...))
+	return nil
+}
+
+func (m *memoryPlaylists) MoveSong(_ context.Context, playlistID, songID uint, position int) (*models.PlaylistItem, error) {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	if _, ok := m.store.playlists[playlistID]; !ok {
+		return nil, ErrNotFound
+	}
+	items := m.store.playlistItems[playlistID]
+	index := indexOfSong(items, songID)
+	if index < 0 {
+		return nil, ErrNotFound
+	}
+
+	item := items[index]
+	rest := append(items[:index:index], items[index+1:]...)
+	target := movePosition(position, len(items)) - 1
+	moved := make([]models.PlaylistItem, 0, len(items))
+	moved = append(moved, rest[:target]...)
+	moved = append(moved, item)
+	moved = append(moved, rest[target:]...)
+
+	m.store.setPlaylistItems(playlistID, moved)
+	item.Position = target + 1
+	return &item, nil
+}
+
+// setPlaylistItems сохраняет состав плейлиста, перенумеровывая позиции подряд. Вызывается под блокировкой.
+func (s *memoryStore) setPlaylistItems(playlistID uint, items []models.PlaylistItem) {
+	for i := range items {
+		items[i].Position = i + 1
+	}
+	s.playlistItems[playlistID] = items
+
+	playlist := s.playlists[playlistID]
+	playlist.UpdatedAt = time.Now()
+	s.playlists[playlistID] = playlist
+}
+
+// removeSongFromPlaylists убирает песню из всех плейлистов без пропусков в нумерации и возвращает
+// убранные позиции. Вызывается под блокировкой.
+func (s *memoryStore) removeSongFromPlaylists(songID uint) []models.PlaylistItem {
+	var removed []models.PlaylistItem
+	for playlistID, items := range s.playlistItems {
+		if index := indexOfSong(items, songID); index >= 0 {
+			removed = append(removed, items[index])
+			s.setPlaylistItems(playlistID, append(items[:index:index], items[index+1:]...))
+		}
+	}
+	return removed
+}
+
+// insertPlaylistItem вставляет песню на позицию item.Position, приведённую к размеру плейлиста.
+// Вызывается под блокировкой.
+func (s *memoryStore) insertPlaylistItem(item models.PlaylistItem) models.PlaylistItem {
+	items := s.playlistItems[item.PlaylistID]
+	item.Position = insertPosition(item.Position, len(items))
+	index := item.Position - 1
+	items = append(items, models.PlaylistItem{})
+	copy(items[index+1:], items[index:])
+	items[index] = item
+
+	s.setPlaylistItems(item.PlaylistID, items)
+	return item
+}
+
+func indexOfSong(items []models.PlaylistItem, songID uint) int {
+	for i, item := range items {
+		if item.SongID == songID {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"music/internal/models"
+)
+
+type memoryRevisions struct {
+	store *memoryStore
+}
+
+// addRevision записывает первую правку добавленной песни. Вызывается под блокировкой.
+func (s *memoryStore) addRevision(ctx context.Context, song *models.SongDetail) {
+	revision := songRevision(ctx, song)
+	revision.Number, revision.CreatedAt = 1, time.Now()
+	s.revisions[song.ID] = []models.SongRevision{revision}
+}
+
+func (m *memoryRevisions) List(_ context.Context, songID uint, limit, offset int) ([]models.SongRevision, int64, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	revisions := slices.Clone(m.store.revisions[songID])
+	slices.Reverse(revisions)
+	return paginate(revisions, limit, offset), int64(len(revisions)), nil
+}
+
+func (m *memoryRevisions) Get(_ context.Context, songID uint, number int) (*models.SongRevision, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	revisions := m.store.revisions[songID]
+	if number < 1 || number > len(revisions) {
+		return nil, ErrNotFound
+	}
+	revision := revisions[number-1]
+	return &revision, nil
+}
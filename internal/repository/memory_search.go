@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"music/internal/models"
+)
+
+// Веса совпадений в памяти повторяют порядок весов Postgres: название важнее исполнителя, исполнитель важнее текста
+const (
+	memoryTitleRank  = 1.0
+	memoryArtistRank = 0.4
+	memoryVerseRank  = 0.1
+)
+
+// Search ищет без морфологии: песня подходит, если каждое слово запроса входит в название и исполнителя или в один куплет
+func (m *memorySongs) Search(_ context.Context, query string, limit, offset int) ([]models.SearchHit, int64, error) {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return []models.SearchHit{}, 0, nil
+	}
+	highlight := highlighter(terms)
+
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	var hits []models.SearchHit
+	for _, song := range m.store.sortedSongs(func(models.SongDetail) bool { return true }) {
+		hit := models.SearchHit{Song: song}
+
+		title, artist := strings.ToLower(song.SongName), strings.ToLower(song.GroupName)
+		if containsAll(title+" "+artist, terms) {
+			for _, term := range terms {
+				if strings.Contains(title, term) {
+					hit.Rank += memoryTitleRank
+				} else {
+					hit.Rank += memoryArtistRank
+				}
+			}
+		}
+
+		for i, verse := range songVerses(song.Text) {
+			if containsAll(strings.ToLower(verse), terms) {
+				index := i
+				hit.VerseIndex = &index
+				hit.Snippet = highlight.ReplaceAllString(verse, "<mark>$0</mark>")
+				hit.Rank += memoryVerseRank
+				break
+			}
+		}
+
+		if hit.Rank > 0 {
+			hits = append(hits, hit)
+		}
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Rank > hits[j].Rank })
+	return paginate(hits, limit, offset), int64(len(hits)), nil
+}
+
+// searchTerms разбивает запрос на слова в нижнем регистре, отбрасывая знаки препинания
+func searchTerms(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func containsAll(text string, terms []string) bool {
+	for _, term := range terms {
+		if !strings.Contains(text, term) {
+			return false
+		}
+	}
+	return true
+}
+
+// highlighter находит вхождения слов запроса без учёта регистра; длинные слова проверяются первыми
+func highlighter(terms []string) *regexp.Regexp {
+	sorted := append([]string(nil), terms...)
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+	for i, term := range sorted {
+		sorted[i] = regexp.QuoteMeta(term)
+	}
+	return regexp.MustCompile(`(?i)` + strings.Join(sorted, "|"))
+}
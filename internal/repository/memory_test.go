@@ -0,0 +1,431 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"music/internal/actor"
+	"music/internal/models"
+	"music/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemory_SongUniqueness(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemory()
+
+	artist := &models.Artist{Name: "Любэ"}
+	require.NoError(t, repos.Artists.Create(ctx, artist))
+	assert.ErrorIs(t, repos.Artists.Create(ctx, &models.Artist{Name: "Любэ"}), repository.ErrAlreadyExists)
+
+	song := &models.SongDetail{ArtistID: artist.ID, GroupName: artist.Name, SongName: "Конь"}
+	require.NoError(t, repos.Songs.Create(ctx, song))
+	assert.NotZero(t, song.ID)
+	assert.Equal(t, models.EnrichmentPending, song.EnrichmentStatus)
+
+	duplicate := &models.SongDetail{ArtistID: artist.ID, GroupName: artist.Name, SongName: "Конь"}
+	assert.ErrorIs(t, repos.Songs.Create(ctx, duplicate), repository.ErrAlreadyExists)
+
+	found, err := repos.Songs.FindByArtistAndName(ctx, artist.ID, "Конь")
+	require.NoError(t, err)
+	assert.Equal(t, song.ID, found.ID)
+
+	require.NoError(t, repos.Songs.Delete(ctx, song.ID, 0))
+	_, err = repos.Songs.GetByID(ctx, song.ID)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	assert.ErrorIs(t, repos.Songs.Delete(ctx, song.ID, 0), repository.ErrNotFound)
+}
+
+func TestMemory_ListFilters(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemory()
+
+	// Песни Muse выходили в 2001-2003 годах, песни Любэ - в 1991-1992, у третьей дата неизвестна;
+	// текст есть только у первых песен
+	for _, name := range []string{"Muse", "Любэ"} {
+		artist := &models.Artist{Name: name}
+		require.NoError(t, repos.Artists.Create(ctx, artist))
+		for i := 1; i <= 3; i++ {
+			releaseDate := time.Date(2010-10*int(artist.ID)+i, 1, 1, 0, 0, 0, 0, time.UTC)
+			song := &models.SongDetail{
+				ArtistID:    artist.ID,
+				GroupName:   name,
+				SongName:    fmt.Sprintf("Song %d", i),
+				ReleaseDate: &releaseDate,
+			}
+			if name == "Любэ" && i == 3 {
+				song.ReleaseDate = nil
+			}
+			if i == 1 {
+				song.Text = `{"verses":["Куплет"]}`
+			}
+			require.NoError(t, repos.Songs.Create(ctx, song))
+		}
+	}
+
+	from := time.Date(1992, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2001, 6, 1, 0, 0, 0, 0, time.UTC)
+	withLyrics, withoutLyrics := true, false
+
+	tests := []struct {
+		name    string
+		filter  repository.SongFilter
+		wantIDs []uint
+	}{
+		{name: "No filter", filter: repository.SongFilter{}, wantIDs: []uint{1, 2, 3, 4, 5, 6}},
+		{name: "Pagination", filter: repository.SongFilter{Limit: 2, Offset: 3}, wantIDs: []uint{4, 5}},
+		{name: "Offset past end", filter: repository.SongFilter{Limit: 2, Offset: 10}, wantIDs: []uint{}},
+		{name: "Song name ignores case", filter: repository.SongFilter{SongName: "song 2"}, wantIDs: []uint{2, 5}},
+		{name: "Artist substring", filter: repository.SongFilter{ArtistName: "us"}, wantIDs: []uint{1, 2, 3}},
+		{name: "Title substring", filter: repository.SongFilter{Title: "G 2"}, wantIDs: []uint{2, 5}},
+		{name: "Release date range", filter: repository.SongFilter{ReleasedFrom: &from, ReleasedTo: &to}, wantIDs: []uint{1, 5}},
+		{name: "Has lyrics", filter: repository.SongFilter{HasLyrics: &withLyrics}, wantIDs: []uint{1, 4}},
+		{name: "Filters combine", filter: repository.SongFilter{ArtistName: "любэ", HasLyrics: &withoutLyrics}, wantIDs: []uint{5, 6}},
+		{
+			name:   "Sort by release date descending",
+			filter: repository.SongFilter{Sort: []repository.SongSort{{Field: repository.SongSortReleaseDate, Desc: true}}},
+			// Песня без даты считается самой ранней
+			wantIDs: []uint{3, 2, 1, 5, 4, 6},
+		},
+		{
+			name: "Sort by artist descending, then title",
+			filter: repository.SongFilter{Sort: []repository.SongSort{
+				{Field: repository.SongSortArtist, Desc: true},
+				{Field: repository.SongSortTitle},
+			}, Limit: 4},
+			wantIDs: []uint{4, 5, 6, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			songs, _, err := repos.Songs.List(ctx, tt.filter)
+			require.NoError(t, err)
+
+			ids := make([]uint, 0, len(songs))
+			for _, song := range songs {
+				ids = append(ids, song.ID)
+			}
+			assert.Equal(t, tt.wantIDs, ids)
+		})
+	}
+}
+
+func TestMemory_ConcurrentCreate(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemory()
+
+	artist := &models.Artist{Name: "Группа"}
+	require.NoError(t, repos.Artists.Create(ctx, artist))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			song := &models.SongDetail{ArtistID: artist.ID, SongName: fmt.Sprintf("Song %d", i)}
+			assert.NoError(t, repos.Songs.Create(ctx, song))
+		}(i)
+	}
+	wg.Wait()
+
+	songs, total, err := repos.Songs.List(ctx, repository.SongFilter{})
+	require.NoError(t, err)
+	assert.Len(t, songs, 50)
+	assert.EqualValues(t, 50, total)
+}
+
+func TestMemory_ConcurrentPlaylistEdits(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemory()
+
+	artist := &models.Artist{Name: "Группа"}
+	require.NoError(t, repos.Artists.Create(ctx, artist))
+	playlist := &models.Playlist{Name: "Смесь"}
+	require.NoError(t, repos.Playlists.Create(ctx, playlist))
+
+	const count = 30
+	for i := 0; i < count; i++ {
+		require.NoError(t, repos.Songs.Create(ctx, &models.SongDetail{ArtistID: artist.ID, SongName: fmt.Sprintf("Song %d", i)}))
+	}
+
+	var wg sync.WaitGroup
+	for i := 1; i <= count; i++ {
+		wg.Add(1)
+		go func(songID uint) {
+			defer wg.Done()
+			_, err := repos.Playlists.AddSong(ctx, playlist.ID, songID, 1)
+			assert.NoError(t, err)
+			_, err = repos.Playlists.MoveSong(ctx, playlist.ID, songID, int(songID)%5+1)
+			assert.NoError(t, err)
+		}(uint(i))
+	}
+	wg.Wait()
+
+	entries, total, err := repos.Playlists.Entries(ctx, playlist.ID, 0, 0)
+	require.NoError(t, err)
+	assert.EqualValues(t, count, total)
+	seen := make(map[uint]bool, count)
+	for i, entry := range entries {
+		assert.Equal(t, i+1, entry.Position)
+		seen[entry.Song.ID] = true
+	}
+	assert.Len(t, seen, count)
+}
+
+func TestMemory_Transaction(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemory()
+	failure := errors.New("откат")
+
+	err := repos.Tx.InTx(ctx, func(tx repository.Repositories) error {
+		artist := &models.Artist{Name: "Кино"}
+		require.NoError(t, tx.Artists.Create(ctx, artist))
+		require.NoError(t, tx.Songs.Create(ctx, &models.SongDetail{ArtistID: artist.ID, SongName: "Кукушка"}))
+
+		// Вложенная транзакция откатывает только свои изменения
+		assert.ErrorIs(t, tx.Tx.InTx(ctx, func(nested repository.Repositories) error {
+			require.NoError(t, nested.Songs.Create(ctx, &models.SongDetail{ArtistID: artist.ID, SongName: "Звезда"}))
+			return failure
+		}), failure)
+		_, err := tx.Songs.FindByArtistAndName(ctx, artist.ID, "Звезда")
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+		return nil
+	})
+	require.NoError(t, err)
+	songs, total, err := repos.Songs.List(ctx, repository.SongFilter{})
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, total)
+	assert.Equal(t, "Кукушка", songs[0].SongName)
+
+	err = repos.Tx.InTx(ctx, func(tx repository.Repositories) error {
+		require.NoError(t, tx.Artists.Create(ctx, &models.Artist{Name: "Любэ"}))
+		return failure
+	})
+	assert.ErrorIs(t, err, failure)
+	_, err = repos.Artists.GetByName(ctx, "Любэ")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	// После отката идентификаторы не расходуются
+	artist := &models.Artist{Name: "Любэ"}
+	require.NoError(t, repos.Artists.Create(ctx, artist))
+	assert.EqualValues(t, 2, artist.ID)
+}
+
+func TestMemory_Trash(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemory()
+
+	artist := &models.Artist{Name: "Кино"}
+	require.NoError(t, repos.Artists.Create(ctx, artist))
+	var songs []*models.SongDetail
+	for _, name := range []string{"Кукушка", "Звезда", "Группа крови"} {
+		song := &models.SongDetail{ArtistID: artist.ID, GroupName: artist.Name, SongName: name}
+		require.NoError(t, repos.Songs.Create(ctx, song))
+		songs = append(songs, song)
+	}
+
+	// Песня, удалённая раньше исполнителя, не восстанавливается вместе с ним
+	require.NoError(t, repos.Songs.Delete(ctx, songs[0].ID, 0))
+	time.Sleep(time.Millisecond)
+	require.NoError(t, repos.Artists.Delete(ctx, artist.ID, 0, true))
+	_, err := repos.Artists.GetByName(ctx, "Кино")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	items, total, err := repos.Trash.List(ctx, repository.TrashFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), total)
+	assert.Equal(t, models.TrashArtist, items[0].Type)
+	assert.Equal(t, songs[0].ID, items[3].ID)
+
+	// Удалённое имя можно занять заново, и тогда восстановить исполнителя нельзя
+	duplicate := &models.Artist{Name: "Кино"}
+	require.NoError(t, repos.Artists.Create(ctx, duplicate))
+	_, err = repos.Trash.RestoreArtist(ctx, artist.ID)
+	assert.ErrorIs(t, err, repository.ErrAlreadyExists)
+	require.NoError(t, repos.Artists.Delete(ctx, duplicate.ID, 0, false))
+	require.NoError(t, repos.Trash.PurgeArtist(ctx, duplicate.ID))
+
+	restored, err := repos.Trash.RestoreArtist(ctx, artist.ID)
+	require.NoError(t, err)
+	assert.False(t, restored.DeletedAt.Valid)
+	list, _, err := repos.Songs.List(ctx, repository.SongFilter{ArtistID: artist.ID})
+	require.NoError(t, err)
+	assert.Len(t, list, 2)
+	_, err = repos.Songs.GetByID(ctx, songs[0].ID)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	result, err := repos.Trash.Purge(ctx, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, models.TrashPurgeResult{Songs: 1}, result)
+	_, err = repos.Trash.RestoreSong(ctx, songs[0].ID)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
+
+func TestMemory_Revisions(t *testing.T) {
+	ctx := actor.ToContext(context.Background(), "editor")
+	repos := repository.NewMemory()
+
+	song := &models.SongDetail{ArtistID: 1, GroupName: "Кино", SongName: "Кукушка"}
+	require.NoError(t, repos.Songs.Create(ctx, song))
+
+	// Изменение неотслеживаемых полей правку не создаёт
+	song.EnrichmentStatus = models.EnrichmentDone
+	require.NoError(t, repos.Songs.Update(ctx, song))
+	song.SongURL = "https://example.com/kukushka"
+	require.NoError(t, repos.Songs.Update(context.Background(), song))
+
+	// Правки откаченной транзакции не сохраняются
+	err := repos.Tx.InTx(ctx, func(tx repository.Repositories) error {
+		changed := *song
+		changed.SongName = "Звезда"
+		require.NoError(t, tx.Songs.Update(ctx, &changed))
+		return errors.New("rollback")
+	})
+	require.Error(t, err)
+
+	revisions, total, err := repos.Revisions.List(ctx, song.ID, 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	require.Len(t, revisions, 2)
+	assert.Equal(t, 2, revisions[0].Number)
+	assert.Empty(t, revisions[0].ClientID)
+	assert.Equal(t, "https://example.com/kukushka", revisions[0].SongURL)
+	assert.Equal(t, "editor", revisions[1].ClientID)
+	assert.Empty(t, revisions[1].SongURL)
+
+	_, err = repos.Revisions.Get(ctx, song.ID, 3)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	// История удаляется вместе с песней при очистке корзины
+	require.NoError(t, repos.Songs.Delete(ctx, song.ID, 0))
+	_, total, err = repos.Revisions.List(ctx, song.ID, 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	require.NoError(t, repos.Trash.PurgeSong(ctx, song.ID))
+	_, total, err = repos.Revisions.List(ctx, song.ID, 0, 0)
+	require.NoError(t, err)
+	assert.Zero(t, total)
+}
+
+func TestMemory_VersionConflict(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemory()
+
+	song := &models.SongDetail{ArtistID: 1, GroupName: "Кино", SongName: "Кукушка"}
+	require.NoError(t, repos.Songs.Create(ctx, song))
+	assert.Equal(t, int64(1), song.Version)
+
+	// Два редактора прочитали одну и ту же версию; второй не затирает правку первого
+	first, second := *song, *song
+	first.SongURL = "https://example.com/1"
+	require.NoError(t, repos.Songs.Update(ctx, &first))
+	assert.Equal(t, int64(2), first.Version)
+	second.SongURL = "https://example.com/2"
+	assert.ErrorIs(t, repos.Songs.Update(ctx, &second), repository.ErrVersionConflict)
+	assert.Equal(t, int64(1), second.Version)
+
+	stored, err := repos.Songs.GetByID(ctx, song.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/1", stored.SongURL)
+
+	assert.ErrorIs(t, repos.Songs.Delete(ctx, song.ID, 1), repository.ErrVersionConflict)
+	require.NoError(t, repos.Songs.Delete(ctx, song.ID, 2))
+}
+
+func TestMemory_TrashKeepsMemberships(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemory()
+
+	artists := map[string]*models.Artist{}
+	for _, name := range []string{"Кино", "Сплин"} {
+		artists[name] = &models.Artist{Name: name}
+		require.NoError(t, repos.Artists.Create(ctx, artists[name]))
+	}
+	songs := map[string]uint{}
+	for _, input := range []struct{ artist, song string }{{"Кино", "Кукушка"}, {"Кино", "Звезда"}, {"Сплин", "Романс"}, {"Сплин", "Выхода нет"}} {
+		song := &models.SongDetail{ArtistID: artists[input.artist].ID, GroupName: input.artist, SongName: input.song}
+		require.NoError(t, repos.Songs.Create(ctx, song))
+		songs[input.song] = song.ID
+	}
+	track := func(number int, song string) models.AlbumTrack {
+		return models.AlbumTrack{DiscNumber: 1, TrackNumber: number, SongID: songs[song]}
+	}
+	own := &models.Album{Title: "Звезда по имени Солнце", ArtistID: artists["Кино"].ID,
+		Tracks: []models.AlbumTrack{track(1, "Кукушка"), track(2, "Звезда"), track(3, "Романс")}}
+	other := &models.Album{Title: "Сборник", ArtistID: artists["Сплин"].ID,
+		Tracks: []models.AlbumTrack{track(1, "Романс"), track(2, "Кукушка")}}
+	require.NoError(t, repos.Albums.Create(ctx, own))
+	require.NoError(t, repos.Albums.Create(ctx, other))
+	playlist := &models.Playlist{Name: "Дорога"}
+	require.NoError(t, repos.Playlists.Create(ctx, playlist))
+	for _, name := range []string{"Звезда", "Кукушка", "Романс"} {
+		_, err := repos.Playlists.AddSong(ctx, playlist.ID, songs[name], 0)
+		require.NoError(t, err)
+	}
+
+	albumSongs := func(id uint) []uint {
+		album, err := repos.Albums.GetByID(ctx, id)
+		require.NoError(t, err)
+		ids := make([]uint, 0, len(album.Tracks))
+		for _, track := range album.Tracks {
+			ids = append(ids, track.SongID)
+		}
+		return ids
+	}
+	playlistSongs := func() []uint {
+		entries, _, err := repos.Playlists.Entries(ctx, playlist.ID, 0, 0)
+		require.NoError(t, err)
+		ids := make([]uint, 0, len(entries))
+		for i, entry := range entries {
+			assert.Equal(t, i+1, entry.Position)
+			ids = append(ids, entry.Song.ID)
+		}
+		return ids
+	}
+	ids := func(names ...string) []uint {
+		result := make([]uint, 0, len(names))
+		for _, name := range names {
+			result = append(result, songs[name])
+		}
+		return result
+	}
+
+	// Удалённая песня пропадает из релизов и плейлистов и возвращается на прежние места
+	require.NoError(t, repos.Songs.Delete(ctx, songs["Кукушка"], 0))
+	assert.Equal(t, ids("Звезда", "Романс"), albumSongs(own.ID))
+	assert.Equal(t, ids("Романс"), albumSongs(other.ID))
+	assert.Equal(t, ids("Звезда", "Романс"), playlistSongs())
+	_, err := repos.Trash.RestoreSong(ctx, songs["Кукушка"])
+	require.NoError(t, err)
+	assert.Equal(t, ids("Кукушка", "Звезда", "Романс"), albumSongs(own.ID))
+	assert.Equal(t, ids("Романс", "Кукушка"), albumSongs(other.ID))
+	assert.Equal(t, ids("Звезда", "Кукушка", "Романс"), playlistSongs())
+
+	// Релизы исполнителя уходят в корзину вместе с ним и восстанавливаются с треклистами;
+	// трек, место которого заняли, ставится в конец диска
+	require.NoError(t, repos.Artists.Delete(ctx, artists["Кино"].ID, 0, true))
+	_, err = repos.Albums.GetByID(ctx, own.ID)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	assert.Equal(t, ids("Романс"), playlistSongs())
+	other.Tracks = []models.AlbumTrack{track(1, "Романс"), track(2, "Выхода нет")}
+	require.NoError(t, repos.Albums.Update(ctx, other))
+
+	_, err = repos.Trash.RestoreArtist(ctx, artists["Кино"].ID)
+	require.NoError(t, err)
+	assert.Equal(t, ids("Кукушка", "Звезда", "Романс"), albumSongs(own.ID))
+	assert.Equal(t, ids("Романс", "Выхода нет", "Кукушка"), albumSongs(other.ID))
+	assert.Equal(t, ids("Звезда", "Кукушка", "Романс"), playlistSongs())
+
+	// После очистки корзины восстанавливать нечего
+	require.NoError(t, repos.Songs.Delete(ctx, songs["Кукушка"], 0))
+	require.NoError(t, repos.Trash.PurgeSong(ctx, songs["Кукушка"]))
+	assert.Equal(t, ids("Звезда", "Романс"), playlistSongs())
+	assert.Equal(t, ids("Звезда", "Романс"), albumSongs(own.ID))
+}
@@ -0,0 +1,274 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"sort"
+	"time"
+
+	"music/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type memoryTrash struct {
+	store *memoryStore
+}
+
+// trashSong переносит песню в корзину вместе с её треками в релизах и позициями в плейлистах.
+// Треки в релизах, которые уже в корзине, остаются в их треклистах. Вызывается под блокировкой.
+func (s *memoryStore) trashSong(song models.SongDetail, at time.Time) {
+	s.trashedTracks[song.ID] = append(s.trashedTracks[song.ID], s.removeSongFromAlbums(song.ID)...)
+	s.trashedItems[song.ID] = append(s.trashedItems[song.ID], s.removeSongFromPlaylists(song.ID)...)
+	delete(s.songs, song.ID)
+	song.DeletedAt = gorm.DeletedAt{Time: at, Valid: true}
+	s.trashedSongs[song.ID] = song
+}
+
+// artistNameTaken проверяет, занято ли имя исполнителем не из корзины. Вызывается под блокировкой.
+func (s *memoryStore) artistNameTaken(name string) bool {
+	for _, artist := range s.artists {
+		if artist.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// restoreArtist возвращает исполнителя из корзины вместе с релизами, удалёнными вместе с ним.
+// Вызывается под блокировкой.
+func (s *memoryStore) restoreArtist(artist models.Artist) {
+	for id, album := range s.trashedAlbums {
+		if album.ArtistID == artist.ID && album.DeletedAt.Time.Equal(artist.DeletedAt.Time) {
+			delete(s.trashedAlbums, id)
+			album.DeletedAt = gorm.DeletedAt{}
+			s.albums[id] = album
+		}
+	}
+	delete(s.trashedArtists, artist.ID)
+	artist.DeletedAt = gorm.DeletedAt{}
+	s.artists[artist.ID] = artist
+}
+
+// restoreSong возвращает песню из корзины. Вызывается под блокировкой.
+func (s *memoryStore) restoreSong(song models.SongDetail) models.SongDetail {
+	delete(s.trashedSongs, song.ID)
+	song.DeletedAt = gorm.DeletedAt{}
+	s.songs[song.ID] = song
+	return song
+}
+
+// restoreSongMemberships возвращает песням не из корзины их треки в релизах и позиции в плейлистах.
+// Треки релизов из корзины ждут восстановления релиза; треки и позиции удалённых релизов и плейлистов
+// отбрасываются. Позиции восстанавливаются по возрастанию, поэтому песни, удалённые вместе,
+// встают на прежние места. Вызывается под блокировкой.
+func (s *memoryStore) restoreSongMemberships() {
+	var items []models.PlaylistItem
+	for songID, tracks := range s.trashedTracks {
+		if _, ok := s.songs[songID]; !ok {
+			continue
+		}
+		var waiting []models.AlbumTrack
+		for _, track := range tracks {
+			if _, ok := s.albums[track.AlbumID]; ok {
+				s.addAlbumTrack(track)
+			} else if _, ok := s.trashedAlbums[track.AlbumID]; ok {
+				waiting = append(waiting, track)
+			}
+		}
+		if len(waiting) > 0 {
+			s.trashedTracks[songID] = waiting
+		} else {
+			delete(s.trashedTracks, songID)
+		}
+	}
+	for songID, songItems := range s.trashedItems {
+		if _, ok := s.songs[songID]; ok {
+			items = append(items, songItems...)
+			delete(s.trashedItems, songID)
+		}
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].PlaylistID != items[j].PlaylistID {
+			return items[i].PlaylistID < items[j].PlaylistID
+		}
+		return items[i].Position < items[j].Position
+	})
+	for _, item := range items {
+		if _, ok := s.playlists[item.PlaylistID]; ok {
+			s.insertPlaylistItem(item)
+		}
+	}
+}
+
+// purgeSong окончательно удаляет песню из корзины вместе с историей правок и сохранёнными треками
+// и позициями. Вызывается под блокировкой.
+func (s *memoryStore) purgeSong(id uint) {
+	delete(s.trashedSongs, id)
+	delete(s.revisions, id)
+	delete(s.trashedTracks, id)
+	delete(s.trashedItems, id)
+	for albumID, album := range s.trashedAlbums {
+		tracks := album.Tracks[:0:0]
+		for _, track := range album.Tracks {
+			if track.SongID != id {
+				tracks = append(tracks, track)
+			}
+		}
+		album.Tracks = tracks
+		s.trashedAlbums[albumID] = album
+	}
+}
+
+// purgeArtist окончательно удаляет исполнителя из корзины вместе с его релизами. Вызывается под блокировкой.
+func (s *memoryStore) purgeArtist(id uint) {
+	delete(s.trashedArtists, id)
+	for albumID, album := range s.trashedAlbums {
+		if album.ArtistID == id {
+			delete(s.trashedAlbums, albumID)
+		}
+	}
+	for songID, tracks := range s.trashedTracks {
+		kept := tracks[:0:0]
+		for _, track := range tracks {
+			_, live := s.albums[track.AlbumID]
+			if _, trashed := s.trashedAlbums[track.AlbumID]; live || trashed {
+				kept = append(kept, track)
+			}
+		}
+		s.trashedTracks[songID] = kept
+	}
+}
+
+func (m *memoryTrash) List(_ context.Context, filter TrashFilter) ([]models.TrashItem, int64, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	items := make([]models.TrashItem, 0, len(m.store.trashedSongs)+len(m.store.trashedArtists))
+	if filter.Type == "" || filter.Type == models.TrashSong {
+		for _, song := range m.store.trashedSongs {
+			items = append(items, models.TrashItem{
+				Type:      models.TrashSong,
+				ID:        song.ID,
+				Name:      song.SongName,
+				ArtistID:  song.ArtistID,
+				Artist:    song.GroupName,
+				DeletedAt: song.DeletedAt.Time,
+			})
+		}
+	}
+	if filter.Type == "" || filter.Type == models.TrashArtist {
+		for _, artist := range m.store.trashedArtists {
+			items = append(items, models.TrashItem{
+				Type:      models.TrashArtist,
+				ID:        artist.ID,
+				Name:      artist.Name,
+				DeletedAt: artist.DeletedAt.Time,
+			})
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if result := items[j].DeletedAt.Compare(items[i].DeletedAt); result != 0 {
+			return result < 0
+		}
+		if items[i].Type != items[j].Type {
+			return items[i].Type < items[j].Type
+		}
+		return cmp.Less(items[i].ID, items[j].ID)
+	})
+
+	return paginate(items, filter.Limit, filter.Offset), int64(len(items)), nil
+}
+
+func (m *memoryTrash) RestoreSong(_ context.Context, id uint) (*models.SongDetail, error) {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	song, ok := m.store.trashedSongs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	artist, artistTrashed := m.store.trashedArtists[song.ArtistID]
+	if (artistTrashed && m.store.artistNameTaken(artist.Name)) || m.store.songExists(&song) {
+		return nil, ErrAlreadyExists
+	}
+
+	if artistTrashed {
+		m.store.restoreArtist(artist)
+	}
+	song = m.store.restoreSong(song)
+	m.store.restoreSongMemberships()
+	return &song, nil
+}
+
+func (m *memoryTrash) RestoreArtist(_ context.Context, id uint) (*models.Artist, error) {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	artist, ok := m.store.trashedArtists[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if m.store.artistNameTaken(artist.Name) {
+		return nil, ErrAlreadyExists
+	}
+
+	// Песни, удалённые раньше исполнителя по отдельности, остаются в корзине
+	for _, song := range m.store.trashedSongs {
+		if song.ArtistID == id && song.DeletedAt.Time.Equal(artist.DeletedAt.Time) {
+			m.store.restoreSong(song)
+		}
+	}
+	m.store.restoreArtist(artist)
+	m.store.restoreSongMemberships()
+	artist.DeletedAt = gorm.DeletedAt{}
+	return &artist, nil
+}
+
+func (m *memoryTrash) PurgeSong(_ context.Context, id uint) error {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	if _, ok := m.store.trashedSongs[id]; !ok {
+		return ErrNotFound
+	}
+	m.store.purgeSong(id)
+	return nil
+}
+
+func (m *memoryTrash) PurgeArtist(_ context.Context, id uint) error {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	if _, ok := m.store.trashedArtists[id]; !ok {
+		return ErrNotFound
+	}
+	for songID, song := range m.store.trashedSongs {
+		if song.ArtistID == id {
+			m.store.purgeSong(songID)
+		}
+	}
+	m.store.purgeArtist(id)
+	return nil
+}
+
+func (m *memoryTrash) Purge(_ context.Context, before time.Time) (models.TrashPurgeResult, error) {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	var result models.TrashPurgeResult
+	for id, song := range m.store.trashedSongs {
+		if song.DeletedAt.Time.Before(before) {
+			m.store.purgeSong(id)
+			result.Songs++
+		}
+	}
+	for id, artist := range m.store.trashedArtists {
+		if artist.DeletedAt.Time.Before(before) {
+			m.store.purgeArtist(id)
+			result.Artists++
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+
+	"music/internal/models"
+)
+
+type memoryTx struct {
+	store *memoryStore
+}
+
+// InTx выполняет fn над копией хранилища и при успехе подменяет данные копией.
+// Хранилище заблокировано на всё время транзакции, поэтому транзакции выполняются по очереди.
+func (m *memoryTx) InTx(_ context.Context, fn func(repos Repositories) error) error {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	snapshot := m.store.clone()
+	if err := fn(snapshot.repositories()); err != nil {
+		return err
+	}
+	m.store.restore(snapshot)
+	return nil
+}
+
+// clone возвращает независимую копию данных хранилища. Вызывается под блокировкой.
+func (s *memoryStore) clone() *memoryStore {
+	copied := &memoryStore{
+		artists:        make(map[uint]models.Artist, len(s.artists)),
+		songs:          make(map[uint]models.SongDetail, len(s.songs)),
+		albums:         make(map[uint]models.Album, len(s.albums)),
+		playlists:      make(map[uint]models.Playlist, len(s.playlists)),
+		playlistItems:  make(map[uint][]models.PlaylistItem, len(s.playlistItems)),
+		trashedArtists: make(map[uint]models.Artist, len(s.trashedArtists)),
+		trashedSongs:   make(map[uint]models.SongDetail, len(s.trashedSongs)),
+		trashedAlbums:  make(map[uint]models.Album, len(s.trashedAlbums)),
+		trashedTracks:  make(map[uint][]models.AlbumTrack, len(s.trashedTracks)),
+		trashedItems:   make(map[uint][]models.PlaylistItem, len(s.trashedItems)),
+		revisions:      make(map[uint][]models.SongRevision, len(s.revisions)),
+		nextArtistID:   s.nextArtistID,
+		nextSongID:     s.nextSongID,
+		nextAlbumID:    s.nextAlbumID,
+		nextPlaylistID: s.nextPlaylistID,
+	}
+	for id, artist := range s.artists {
+		copied.artists[id] = artist
+	}
+	for id, song := range s.songs {
+		copied.songs[id] = song
+	}
+	for id, album := range s.albums {
+		album.Tracks = append([]models.AlbumTrack(nil), album.Tracks...)
+		copied.albums[id] = album
+	}
+	for id, playlist := range s.playlists {
+		copied.playlists[id] = playlist
+	}
+	for id, items := range s.playlistItems {
+		copied.playlistItems[id] = append([]models.PlaylistItem(nil), items...)
+	}
+	for id, artist := range s.trashedArtists {
+		copied.trashedArtists[id] = artist
+	}
+	for id, song := range s.trashedSongs {
+		copied.trashedSongs[id] = song
+	}
+	for id, album := range s.trashedAlbums {
+		copied.trashedAlbums[id] = cloneAlbum(album)
+	}
+	for id, tracks := range s.trashedTracks {
+		copied.trashedTracks[id] = append([]models.AlbumTrack(nil), tracks...)
+	}
+	for id, items := range s.trashedItems {
+		copied.trashedItems[id] = append([]models.PlaylistItem(nil), items...)
+	}
+	for id, revisions := range s.revisions {
+		copied.revisions[id] = append([]models.SongRevision(nil), revisions...)
+	}
+	return copied
+}
+
+// restore заменяет данные хранилища данными копии. Вызывается под блокировкой.
+func (s *memoryStore) restore(snapshot *memoryStore) {
+	s.artists = snapshot.artists
+	s.songs = snapshot.songs
+	s.albums = snapshot.albums
+	s.playlists = snapshot.playlists
+	s.playlistItems = snapshot.playlistItems
+	s.trashedArtists = snapshot.trashedArtists
+	s.trashedSongs = snapshot.trashedSongs
+	s.trashedAlbums = snapshot.trashedAlbums
+	s.trashedTracks = snapshot.trashedTracks
+	s.trashedItems = snapshot.trashedItems
+	s.revisions = snapshot.revisions
+	s.nextArtistID = snapshot.nextArtistID
+	s.nextSongID = snapshot.nextSongID
+	s.nextAlbumID = snapshot.nextAlbumID
+	s.nextPlaylistID = snapshot.nextPlaylistID
+}
@@ -0,0 +1,495 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"music/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// NewPostgres создаёт хранилища поверх подключения GORM к PostgreSQL
+func NewPostgres(db *gorm.DB) Repositories {
+	return Repositories{
+		Songs:     &postgresSongs{db: db},
+		Artists:   &postgresArtists{db: db},
+		Albums:    &postgresAlbums{db: db},
+		Playlists: &postgresPlaylists{db: db},
+		Trash:     &postgresTrash{db: db},
+		Revisions: &postgresRevisions{db: db},
+		Tx:        &postgresTx{db: db},
+	}
+}
+
+type postgresTx struct {
+	db *gorm.DB
+}
+
+// InTx открывает транзакцию; внутри уже открытой транзакции GORM использует точку сохранения
+func (p *postgresTx) InTx(ctx context.Context, fn func(repos Repositories) error) error {
+	return p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(NewPostgres(tx))
+	})
+}
+
+// translateError приводит ошибки GORM к ошибкам пакета repository
+func translateError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ErrNotFound
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return ErrAlreadyExists
+	default:
+		return err
+	}
+}
+
+type postgresSongs struct {
+	db *gorm.DB
+}
+
+func (p *postgresSongs) List(ctx context.Context, filter SongFilter) ([]models.SongDetail, int64, error) {
+	query := p.db.WithContext(ctx).Model(&models.SongDetail{})
+
+	if filter.ArtistID != 0 {
+		query = query.Where("song_details.artist_id = ?", filter.ArtistID)
+	}
+	if filter.SongName != "" {
+		// Используем ILIKE для точного соответствия, игнорируя регистр
+		query = query.Where("song_name ILIKE ?", filter.SongName)
+	}
+	if filter.Title != "" {
+		query = query.Where("song_name ILIKE ?", "%"+escapeLike(filter.Title)+"%")
+	}
+	if filter.ArtistName != "" {
+		query = query.Joins("JOIN artists ON artists.id = song_details.artist_id").
+			Where("artists.name ILIKE ?", "%"+escapeLike(filter.ArtistName)+"%")
+	}
+	if filter.ReleaseDate != nil {
+		query = query.Where("release_date = ?", *filter.ReleaseDate)
+	}
+	if filter.ReleasedFrom != nil {
+		query = query.Where("release_date >= ?", *filter.ReleasedFrom)
+	}
+	if filter.ReleasedTo != nil {
+		query = query.Where("release_date <= ?", *filter.ReleasedTo)
+	}
+	if filter.CreatedFrom != nil {
+		query = query.Where("song_details.created_at >= ?", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		query = query.Where("song_details.created_at <= ?", *filter.CreatedTo)
+	}
+	if filter.HasLyrics != nil {
+		hasVerses := "EXISTS (SELECT 1 FROM song_verses WHERE song_verses.song_id = song_details.id)"
+		if !*filter.HasLyrics {
+			hasVerses = "NOT " + hasVerses
+		}
+		query = query.Where(hasVerses)
+	}
+	if filter.AlbumID != 0 {
+		query = query.Joins("JOIN album_tracks ON album_tracks.song_id = song_details.id AND album_tracks.album_id = ?", filter.AlbumID).
+			Joins("JOIN albums ON albums.id = album_tracks.album_id AND albums.deleted_at IS NULL")
+	}
+
+	var total int64
+	if !filter.SkipTotal {
+		if err := query.Count(&total).Error; err != nil {
+			return nil, 0, translateError(err)
+		}
+	}
+
+	if filter.After != nil {
+		if filter.AlbumID != 0 && len(filter.Sort) == 0 {
+			return nil, 0, ErrInvalidCursor
+		}
+		query = query.Where(songKeysetCondition(*filter.After, filter.Sort))
+	}
+	if filter.AlbumID != 0 && len(filter.Sort) == 0 {
+		query = query.Order("album_tracks.disc_number, album_tracks.track_number")
+	}
+	for _, sort := range filter.Sort {
+		query = query.Order(clause.OrderByColumn{Column: clause.Column{Name: songSortColumns[sort.Field], Raw: true}, Desc: sort.Desc})
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	var songs []models.SongDetail
+	err := query.Offset(filter.Offset).Order("song_details.id").Find(&songs).Error
+	return songs, total, translateError(err)
+}
+
+// songKeysetCondition отбирает песни, идущие в порядке сортировки строго после курсора:
+// (a > ?) OR (a = ? AND b > ?) OR ... OR (a = ? AND b = ? AND id > ?)
+func songKeysetCondition(cursor SongCursor, sorts []SongSort) clause.Expression {
+	values := map[string]interface{}{
+		SongSortReleaseDate: cursor.ReleaseDate,
+		SongSortTitle:       cursor.Title,
+		SongSortArtist:      cursor.Artist,
+		SongSortCreatedAt:   cursor.CreatedAt,
+	}
+
+	var branches []string
+	var args []interface{}
+	var equal []string
+	var equalArgs []interface{}
+	addBranch := func(column, placeholder string, desc bool, value interface{}) {
+		op := ">"
+		if desc {
+			op = "<"
+		}
+		conditions := append(append([]string{}, equal...), column+" "+op+" "+placeholder)
+		branches = append(branches, "("+strings.Join(conditions, " AND ")+")")
+		args = append(append(args, equalArgs...), value)
+		equal = append(equal, column+" = "+placeholder)
+		equalArgs = append(equalArgs, value)
+	}
+	for _, sort := range sorts {
+		placeholder := "?"
+		if sort.Field == SongSortReleaseDate {
+			// Дата курсора сравнивается как дата, а не как момент времени в часовом поясе сессии
+			placeholder = "CAST(? AS date)"
+		}
+		addBranch(songSortColumns[sort.Field], placeholder, sort.Desc, values[sort.Field])
+	}
+	addBranch("song_details.id", "?", false, cursor.ID)
+
+	return clause.Expr{SQL: "(" + strings.Join(branches, " OR ") + ")", Vars: args}
+}
+
+// songSortColumns сопоставляет поля сортировки с выражениями над song_details. Дата релиза бывает NULL
+// у песен, сохранённых до миграций; сравнения с NULL не выполняются, и курсор пропускал бы такие песни,
+// поэтому NULL сортируется как нулевая дата - самая ранняя, как и в курсоре и хранилище в памяти.
+var songSortColumns = map[string]string{
+	SongSortReleaseDate: "COALESCE(song_details.release_date, DATE '0001-01-01')",
+	SongSortTitle:       "song_details.song_name",
+	SongSortArtist:      "song_details.group_name",
+	SongSortCreatedAt:   "song_details.created_at",
+}
+
+// escapeLike экранирует спецсимволы шаблона LIKE, чтобы подстрока искалась буквально
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
+
+func (p *postgresSongs) GetByID(ctx context.Context, id uint) (*models.SongDetail, error) {
+	var song models.SongDetail
+	if err := p.db.WithContext(ctx).First(&song, id).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &song, nil
+}
+
+func (p *postgresSongs) FindByName(ctx context.Context, name string) ([]models.SongDetail, error) {
+	var songs []models.SongDetail
+	err := p.db.WithContext(ctx).Where("song_name = ?", name).Order("id").Find(&songs).Error
+	return songs, translateError(err)
+}
+
+func (p *postgresSongs) FindByArtistAndName(ctx context.Context, artistID uint, name string) (*models.SongDetail, error) {
+	var song models.SongDetail
+	if err := p.db.WithContext(ctx).Where("song_name = ? AND artist_id = ?", name, artistID).First(&song).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &song, nil
+}
+
+func (p *postgresSongs) Create(ctx context.Context, song *models.SongDetail) error {
+	song.Version = 1
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(song).Error; err != nil {
+			return err
+		}
+		if err := syncSongVerses(tx, song); err != nil {
+			return err
+		}
+		revision := songRevision(ctx, song)
+		revision.Number = 1
+		return tx.Create(&revision).Error
+	})
+	return translateError(err)
+}
+
+// songKey - пара (исполнитель, название), уникальная для песни
+type songKey struct {
+	artistID uint
+	name     string
+}
+
+func (p *postgresSongs) CreateMany(ctx context.Context, songs []*models.SongDetail) ([]bool, error) {
+	created := make([]bool, len(songs))
+	if len(songs) == 0 {
+		return created, nil
+	}
+
+	placeholders := make([]string, 0, len(songs))
+	args := make([]interface{}, 0, len(songs)*7)
+	index := make(map[songKey]int, len(songs))
+	for i, song := range songs {
+		if song.EnrichmentStatus == "" {
+			song.EnrichmentStatus = models.EnrichmentPending
+		}
+		placeholders = append(placeholders, "(?, ?, ?, ?, ?, ?, ?)")
+		args = append(args, song.ArtistID, song.GroupName, song.SongName, song.ReleaseDate, song.Text, song.SongURL, song.EnrichmentStatus)
+		key := songKey{artistID: song.ArtistID, name: song.SongName}
+		if _, ok := index[key]; !ok {
+			index[key] = i
+		}
+	}
+
+	var inserted []struct {
+		ID        uint
+		ArtistID  uint
+		SongName  string
+		CreatedAt time.Time
+	}
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Существующие песни пропускаются без ошибки; RETURNING возвращает только вставленные строки.
+		// Условие WHERE выбирает частичный уникальный индекс, не учитывающий песни в корзине.
+		err := tx.Raw(`INSERT INTO song_details (artist_id, group_name, song_name, release_date, text, song_url, enrichment_status)
+VALUES `+strings.Join(placeholders, ", ")+`
+ON CONFLICT (song_name, artist_id) WHERE deleted_at IS NULL DO NOTHING
+RETURNING id, artist_id, song_name, created_at`, args...).Scan(&inserted).Error
+		if err != nil {
+			return err
+		}
+		revisions := make([]models.SongRevision, 0, len(inserted))
+		for _, row := range inserted {
+			i := index[songKey{artistID: row.ArtistID, name: row.SongName}]
+			songs[i].ID, songs[i].CreatedAt, songs[i].Version = row.ID, row.CreatedAt, 1
+			created[i] = true
+			if err := syncSongVerses(tx, songs[i]); err != nil {
+				return err
+			}
+			revision := songRevision(ctx, songs[i])
+			revision.Number = 1
+			revisions = append(revisions, revision)
+		}
+		if len(revisions) == 0 {
+			return nil
+		}
+		return tx.Create(&revisions).Error
+	})
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return created, nil
+}
+
+func (p *postgresSongs) Update(ctx context.Context, song *models.SongDetail) error {
+	expected := song.Version
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Блокировка строки упорядочивает одновременные правки песни, проверку версии и нумерацию ревизий
+		var previous models.SongDetail
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&previous, song.ID).Error; err != nil {
+			return err
+		}
+		if previous.Version != expected {
+			return ErrVersionConflict
+		}
+		song.Version = expected + 1
+		if err := tx.Save(song).Error; err != nil {
+			return err
+		}
+		if err := syncSongVerses(tx, song); err != nil {
+			return err
+		}
+		return recordRevisions(ctx, tx, &previous, song)
+	})
+	if err != nil {
+		song.Version = expected
+	}
+	return translateError(err)
+}
+
+func (p *postgresSongs) Delete(ctx context.Context, id uint, version int64) error {
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if version != 0 {
+			var song models.SongDetail
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&song, id).Error; err != nil {
+				return err
+			}
+			if song.Version != version {
+				return ErrVersionConflict
+			}
+		}
+		// Песня убирается из поискового индекса и переносится в корзину вместе с треками и позициями в плейлистах
+		if err := trashSongMemberships(tx, []uint{id}); err != nil {
+			return err
+		}
+		if err := tx.Where("song_id = ?", id).Delete(&models.SongVerse{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&models.SongDetail{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+	return translateError(err)
+}
+
+type postgresArtists struct {
+	db *gorm.DB
+}
+
+func (p *postgresArtists) List(ctx context.Context, limit, offset int) ([]models.Artist, int64, error) {
+	var total int64
+	if err := p.db.WithContext(ctx).Model(&models.Artist{}).Count(&total).Error; err != nil {
+		return nil, 0, translateError(err)
+	}
+
+	query := p.db.WithContext(ctx).Order("id").Offset(offset)
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	var artists []models.Artist
+	if err := query.Find(&artists).Error; err != nil {
+		return nil, 0, translateError(err)
+	}
+	return artists, total, nil
+}
+
+func (p *postgresArtists) GetByID(ctx context.Context, id uint) (*models.Artist, error) {
+	var artist models.Artist
+	if err := p.db.WithContext(ctx).First(&artist, id).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &artist, nil
+}
+
+func (p *postgresArtists) GetByName(ctx context.Context, name string) (*models.Artist, error) {
+	var artist models.Artist
+	if err := p.db.WithContext(ctx).Where("name = ?", name).First(&artist).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &artist, nil
+}
+
+func (p *postgresArtists) Create(ctx context.Context, artist *models.Artist) error {
+	artist.Version = 1
+	return translateError(p.db.WithContext(ctx).Create(artist).Error)
+}
+
+func (p *postgresArtists) EnsureNames(ctx context.Context, names []string) (map[string]models.Artist, error) {
+	result := make(map[string]models.Artist, len(names))
+	if len(names) == 0 {
+		return result, nil
+	}
+
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		missing := make([]models.Artist, 0, len(names))
+		for _, name := range names {
+			missing = append(missing, models.Artist{Name: name})
+		}
+		// Существующие и одновременно добавленные исполнители пропускаются, поэтому все затем читаются заново
+		onConflict := clause.OnConflict{
+			Columns:     []clause.Column{{Name: "name"}},
+			TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "deleted_at IS NULL"}}},
+			DoNothing:   true,
+		}
+		if err := tx.Clauses(onConflict).Create(&missing).Error; err != nil {
+			return err
+		}
+		var artists []models.Artist
+		if err := tx.Where("name IN ?", names).Find(&artists).Error; err != nil {
+			return err
+		}
+		for _, artist := range artists {
+			result[artist.Name] = artist
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return result, nil
+}
+
+func (p *postgresArtists) Rename(ctx context.Context, id uint, version int64, name string) (*models.Artist, error) {
+	var artist models.Artist
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&artist, id).Error; err != nil {
+			return err
+		}
+		if version != 0 && artist.Version != version {
+			return ErrVersionConflict
+		}
+		artist.Name, artist.Version = name, artist.Version+1
+		if err := tx.Model(&artist).Updates(map[string]interface{}{"name": name, "version": artist.Version}).Error; err != nil {
+			return err
+		}
+		// Поддерживаем денормализованное имя исполнителя в песнях, в том числе в песнях из корзины
+		return tx.Unscoped().Model(&models.SongDetail{}).Where("artist_id = ?", id).
+			Updates(map[string]interface{}{"group_name": name, "version": gorm.Expr("version + 1")}).Error
+	})
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &artist, nil
+}
+
+func (p *postgresArtists) Delete(ctx context.Context, id uint, version int64, cascade bool) error {
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var artist models.Artist
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&artist, id).Error; err != nil {
+			return err
+		}
+		if version != 0 && artist.Version != version {
+			return ErrVersionConflict
+		}
+
+		var songCount, albumCount int64
+		if err := tx.Model(&models.SongDetail{}).Where("artist_id = ?", id).Count(&songCount).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Album{}).Where("artist_id = ?", id).Count(&albumCount).Error; err != nil {
+			return err
+		}
+		if !cascade {
+			switch {
+			case songCount > 0:
+				return ErrArtistHasSongs
+			case albumCount > 0:
+				return ErrArtistHasAlbums
+			}
+		}
+
+		// Каскадное удаление: релизы исполнителя вместе с треклистами, его песни, их треки в чужих релизах
+		// и позиции в плейлистах переносятся в корзину с тем же моментом удаления, что и исполнитель,
+		// чтобы восстановиться вместе с ним
+		now := time.Now()
+		if err := tx.Model(&models.Album{}).Where("artist_id = ?", id).Update("deleted_at", now).Error; err != nil {
+			return err
+		}
+		var songIDs []uint
+		if err := tx.Model(&models.SongDetail{}).Where("artist_id = ?", id).Pluck("id", &songIDs).Error; err != nil {
+			return err
+		}
+		if len(songIDs) > 0 {
+			if err := trashSongMemberships(tx, songIDs); err != nil {
+				return err
+			}
+		}
+		artistSongs := tx.Model(&models.SongDetail{}).Select("id").Where("artist_id = ?", id)
+		if err := tx.Where("song_id IN (?)", artistSongs).Delete(&models.SongVerse{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.SongDetail{}).Where("artist_id = ?", id).Update("deleted_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Model(&artist).Update("deleted_at", now).Error
+	})
+	return translateError(err)
+}
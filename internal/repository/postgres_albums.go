@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+
+	"music/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type postgresAlbums struct {
+	db *gorm.DB
+}
+
+// orderedTracks подгружает треки релиза в порядке диска и номера трека
+func orderedTracks(db *gorm.DB) *gorm.DB {
+	return db.Order("disc_number, track_number")
+}
+
+func (p *postgresAlbums) List(ctx context.Context, filter AlbumFilter) ([]models.Album, int64, error) {
+	query := p.db.WithContext(ctx).Model(&models.Album{})
+	if filter.ArtistID != 0 {
+		query = query.Where("artist_id = ?", filter.ArtistID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, translateError(err)
+	}
+
+	query = query.Preload("Tracks", orderedTracks).Order("id").Offset(filter.Offset)
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	var albums []models.Album
+	if err := query.Find(&albums).Error; err != nil {
+		return nil, 0, translateError(err)
+	}
+	return albums, total, nil
+}
+
+func (p *postgresAlbums) GetByID(ctx context.Context, id uint) (*models.Album, error) {
+	var album models.Album
+	if err := p.db.WithContext(ctx).Preload("Tracks", orderedTracks).First(&album, id).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &album, nil
+}
+
+func (p *postgresAlbums) Create(ctx context.Context, album *models.Album) error {
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkAlbumReferences(tx, album); err != nil {
+			return err
+		}
+		return tx.Create(album).Error
+	})
+	return translateError(err)
+}
+
+func (p *postgresAlbums) Update(ctx context.Context, album *models.Album) error {
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing models.Album
+		if err := tx.First(&existing, album.ID).Error; err != nil {
+			return err
+		}
+		if err := checkAlbumReferences(tx, album); err != nil {
+			return err
+		}
+
+		album.CreatedAt = existing.CreatedAt
+		if err := tx.Omit("Tracks").Save(album).Error; err != nil {
+			return err
+		}
+
+		// Треклист заменяется целиком
+		if err := tx.Where("album_id = ?", album.ID).Delete(&models.AlbumTrack{}).Error; err != nil {
+			return err
+		}
+		for i := range album.Tracks {
+			album.Tracks[i].AlbumID = album.ID
+		}
+		if len(album.Tracks) == 0 {
+			return nil
+		}
+		return tx.Create(&album.Tracks).Error
+	})
+	return translateError(err)
+}
+
+func (p *postgresAlbums) Delete(ctx context.Context, id uint) error {
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("album_id = ?", id).Delete(&models.AlbumTrack{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&models.Album{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+	return translateError(err)
+}
+
+// checkAlbumReferences проверяет, что исполнитель и все песни треклиста существуют
+func checkAlbumReferences(tx *gorm.DB, album *models.Album) error {
+	var artistCount int64
+	if err := tx.Model(&models.Artist{}).Where("id = ?", album.ArtistID).Count(&artistCount).Error; err != nil {
+		return err
+	}
+	if artistCount == 0 {
+		return ErrInvalidReference
+	}
+
+	songIDs := albumSongIDs(album)
+	if len(songIDs) == 0 {
+		return nil
+	}
+
+	var songCount int64
+	if err := tx.Model(&models.SongDetail{}).Where("id IN ?", songIDs).Count(&songCount).Error; err != nil {
+		return err
+	}
+	if int(songCount) != len(songIDs) {
+		return ErrInvalidReference
+	}
+	return nil
+}
+
+// albumSongIDs возвращает уникальные ID песен треклиста
+func albumSongIDs(album *models.Album) []uint {
+	seen := make(map[uint]bool, len(album.Tracks))
+	ids := make([]uint, 0, len(album.Tracks))
+	for _, track := range album.Tracks {
+		if !seen[track.SongID] {
+			seen[track.SongID] = true
+			ids = append(ids, track.SongID)
+		}
+	}
+	return ids
+}
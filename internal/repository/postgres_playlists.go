@@ -0,0 +1,304 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"music/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type postgresPlaylists struct {
+	db *gorm.DB
+}
+
+func (p *postgresPlaylists) List(ctx context.Context, filter PlaylistFilter) ([]models.Playlist, int64, error) {
+	query := p.db.WithContext(ctx).Model(&models.Playlist{})
+	if filter.Owner != "" {
+		query = query.Where("owner = ?", filter.Owner)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, translateError(err)
+	}
+
+	query = query.Order("id").Offset(filter.Offset)
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	var playlists []models.Playlist
+	if err := query.Find(&playlists).Error; err != nil {
+		return nil, 0, translateError(err)
+	}
+	return playlists, total, nil
+}
+
+func (p *postgresPlaylists) GetByID(ctx context.Context, id uint) (*models.Playlist, error) {
+	var playlist models.Playlist
+	if err := p.db.WithContext(ctx).First(&playlist, id).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &playlist, nil
+}
+
+func (p *postgresPlaylists) Create(ctx context.Context, playlist *models.Playlist) error {
+	return translateError(p.db.WithContext(ctx).Create(playlist).Error)
+}
+
+func (p *postgresPlaylists) Rename(ctx context.Context, id uint, name string) (*models.Playlist, error) {
+	var playlist models.Playlist
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockPlaylist(tx, id, &playlist); err != nil {
+			return err
+		}
+		return tx.Model(&playlist).Update("name", name).Error
+	})
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &playlist, nil
+}
+
+func (p *postgresPlaylists) Delete(ctx context.Context, id uint) error {
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("playlist_id = ?", id).Delete(&models.PlaylistItem{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&models.Playlist{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+	return translateError(err)
+}
+
+func (p *postgresPlaylists) Entries(ctx context.Context, id uint, limit, offset int) ([]models.PlaylistEntry, int64, error) {
+	db := p.db.WithContext(ctx)
+	if err := db.First(&models.Playlist{}, id).Error; err != nil {
+		return nil, 0, translateError(err)
+	}
+
+	var total int64
+	if err := db.Model(&models.PlaylistItem{}).Where("playlist_id = ?", id).Count(&total).Error; err != nil {
+		return nil, 0, translateError(err)
+	}
+
+	query := db.Where("playlist_id = ?", id).Order("position").Offset(offset)
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	var items []models.PlaylistItem
+	if err := query.Find(&items).Error; err != nil {
+		return nil, 0, translateError(err)
+	}
+
+	songIDs := make([]uint, 0, len(items))
+	for _, item := range items {
+		songIDs = append(songIDs, item.SongID)
+	}
+	var songs []models.SongDetail
+	if len(songIDs) > 0 {
+		if err := db.Where("id IN ?", songIDs).Find(&songs).Error; err != nil {
+			return nil, 0, translateError(err)
+		}
+	}
+	songsByID := make(map[uint]models.SongDetail, len(songs))
+	for _, song := range songs {
+		songsByID[song.ID] = song
+	}
+
+	entries := make([]models.PlaylistEntry, 0, len(items))
+	for _, item := range items {
+		entries = append(entries, models.PlaylistEntry{Position: item.Position, AddedAt: item.AddedAt, Song: songsByID[item.SongID]})
+	}
+	return entries, total, nil
+}
+
+func (p *postgresPlaylists) AddSong(ctx context.Context, playlistID, songID uint, position int) (*models.PlaylistItem, error) {
+	item := models.PlaylistItem{PlaylistID: playlistID, SongID: songID}
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockPlaylist(tx, playlistID, &models.Playlist{}); err != nil {
+			return err
+		}
+
+		var songCount int64
+		if err := tx.Model(&models.SongDetail{}).Where("id = ?", songID).Count(&songCount).Error; err != nil {
+			return err
+		}
+		if songCount == 0 {
+			return ErrInvalidReference
+		}
+
+		var existing int64
+		if err := tx.Model(&models.PlaylistItem{}).Where("playlist_id = ? AND song_id = ?", playlistID, songID).Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return ErrAlreadyExists
+		}
+
+		item.Position = position
+		if err := insertPlaylistItem(tx, &item); err != nil {
+			return err
+		}
+		return touchPlaylist(tx, playlistID)
+	})
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &item, nil
+}
+
+func (p *postgresPlaylists) RemoveSong(ctx context.Context, playlistID, songID uint) error {
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockPlaylist(tx, playlistID, &models.Playlist{}); err != nil {
+			return err
+		}
+
+		var item models.PlaylistItem
+		if err := tx.Where("playlist_id = ? AND song_id = ?", playlistID, songID).First(&item).Error; err != nil {
+			return err
+		}
+		if err := removePlaylistItem(tx, item); err != nil {
+			return err
+		}
+		return touchPlaylist(tx, playlistID)
+	})
+	return translateError(err)
+}
+
+func (p *postgresPlaylists) MoveSong(ctx context.Context, playlistID, songID uint, position int) (*models.PlaylistItem, error) {
+	var item models.PlaylistItem
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockPlaylist(tx, playlistID, &models.Playlist{}); err != nil {
+			return err
+		}
+
+		if err := tx.Where("playlist_id = ? AND song_id = ?", playlistID, songID).First(&item).Error; err != nil {
+			return err
+		}
+		var size int64
+		if err := tx.Model(&models.PlaylistItem{}).Where("playlist_id = ?", playlistID).Count(&size).Error; err != nil {
+			return err
+		}
+
+		target := movePosition(position, int(size))
+		if target == item.Position {
+			return nil
+		}
+
+		// Песни между старой и новой позицией сдвигаются на одну в сторону освободившегося места
+		shift := tx.Model(&models.PlaylistItem{}).Where("playlist_id = ?", playlistID)
+		if target < item.Position {
+			shift = shift.Where("position >= ? AND position < ?", target, item.Position).Update("position", gorm.Expr("position + 1"))
+		} else {
+			shift = shift.Where("position > ? AND position <= ?", item.Position, target).Update("position", gorm.Expr("position - 1"))
+		}
+		if shift.Error != nil {
+			return shift.Error
+		}
+
+		item.Position = target
+		if err := tx.Model(&item).Update("position", target).Error; err != nil {
+			return err
+		}
+		return touchPlaylist(tx, playlistID)
+	})
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &item, nil
+}
+
+// lockPlaylist загружает плейлист с блокировкой строки до конца транзакции
+func lockPlaylist(tx *gorm.DB, id uint, playlist *models.Playlist) error {
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(playlist, id).Error
+}
+
+// touchPlaylist обновляет время изменения плейлиста
+func touchPlaylist(tx *gorm.DB, id uint) error {
+	return tx.Model(&models.Playlist{}).Where("id = ?", id).Update("updated_at", time.Now()).Error
+}
+
+// insertPlaylistItem вставляет песню на позицию item.Position, приведённую к размеру плейлиста,
+// и сдвигает следующие песни
+func insertPlaylistItem(tx *gorm.DB, item *models.PlaylistItem) error {
+	var size int64
+	if err := tx.Model(&models.PlaylistItem{}).Where("playlist_id = ?", item.PlaylistID).Count(&size).Error; err != nil {
+		return err
+	}
+	item.Position = insertPosition(item.Position, int(size))
+	if err := tx.Model(&models.PlaylistItem{}).
+		Where("playlist_id = ? AND position >= ?", item.PlaylistID, item.Position).
+		Update("position", gorm.Expr("position + 1")).Error; err != nil {
+		return err
+	}
+	return tx.Create(item).Error
+}
+
+// removePlaylistItem удаляет позицию и закрывает образовавшийся пропуск
+func removePlaylistItem(tx *gorm.DB, item models.PlaylistItem) error {
+	if err := tx.Where("playlist_id = ? AND song_id = ?", item.PlaylistID, item.SongID).Delete(&models.PlaylistItem{}).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.PlaylistItem{}).
+		Where("playlist_id = ? AND position > ?", item.PlaylistID, item.Position).
+		Update("position", gorm.Expr("position - 1")).Error
+}
+
+// removeSongsFromPlaylists убирает песни из всех плейлистов без пропусков в нумерации.
+// Плейлисты блокируются в порядке ID, чтобы параллельные удаления не взаимоблокировались.
+func removeSongsFromPlaylists(tx *gorm.DB, songIDs []uint) error {
+	var playlistIDs []uint
+	if err := tx.Model(&models.PlaylistItem{}).Distinct("playlist_id").Where("song_id IN ?", songIDs).
+		Order("playlist_id").Pluck("playlist_id", &playlistIDs).Error; err != nil {
+		return err
+	}
+	if len(playlistIDs) == 0 {
+		return nil
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id IN ?", playlistIDs).Order("id").
+		Find(&[]models.Playlist{}).Error; err != nil {
+		return err
+	}
+
+	// Удаление с конца плейлиста не сдвигает позиции ещё не обработанных песен
+	var items []models.PlaylistItem
+	if err := tx.Where("song_id IN ?", songIDs).Order("playlist_id, position DESC").Find(&items).Error; err != nil {
+		return err
+	}
+	for _, item := range items {
+		if err := removePlaylistItem(tx, item); err != nil {
+			return err
+		}
+	}
+	return tx.Model(&models.Playlist{}).Where("id IN ?", playlistIDs).Update("updated_at", time.Now()).Error
+}
+
+// insertPosition приводит позицию вставки к диапазону 1..size+1
+func insertPosition(position, size int) int {
+	if position < 1 || position > size+1 {
+		return size + 1
+	}
+	return position
+}
+
+// movePosition приводит новую позицию песни к диапазону 1..size
+func movePosition(position, size int) int {
+	switch {
+	case position < 1:
+		return 1
+	case position > size:
+		return size
+	default:
+		return position
+	}
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+
+	"music/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type postgresRevisions struct {
+	db *gorm.DB
+}
+
+// recordRevisions записывает правки после изменения песни. Строка песни должна быть заблокирована,
+// иначе одновременные правки получат одинаковые номера.
+func recordRevisions(ctx context.Context, tx *gorm.DB, before, after *models.SongDetail) error {
+	if !revisionChanged(before, after) {
+		return nil
+	}
+	var last int
+	err := tx.Model(&models.SongRevision{}).Where("song_id = ?", after.ID).
+		Select("COALESCE(MAX(number), 0)").Scan(&last).Error
+	if err != nil {
+		return err
+	}
+	revisions := updateRevisions(ctx, before, after, last)
+	return tx.Create(&revisions).Error
+}
+
+func (p *postgresRevisions) List(ctx context.Context, songID uint, limit, offset int) ([]models.SongRevision, int64, error) {
+	var total int64
+	err := p.db.WithContext(ctx).Model(&models.SongRevision{}).Where("song_id = ?", songID).Count(&total).Error
+	if err != nil {
+		return nil, 0, translateError(err)
+	}
+
+	query := p.db.WithContext(ctx).Where("song_id = ?", songID).Order("number DESC").Offset(offset)
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	var revisions []models.SongRevision
+	if err := query.Find(&revisions).Error; err != nil {
+		return nil, 0, translateError(err)
+	}
+	return revisions, total, nil
+}
+
+func (p *postgresRevisions) Get(ctx context.Context, songID uint, number int) (*models.SongRevision, error) {
+	var revision models.SongRevision
+	err := p.db.WithContext(ctx).Where("song_id = ? AND number = ?", songID, number).First(&revision).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &revision, nil
+}
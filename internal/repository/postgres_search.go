@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"music/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Поисковые документы строятся из двух конфигураций, чтобы находились и русские, и английские словоформы.
+// Выражения должны совпадать с выражениями GIN-индексов из миграции 20261017130000_song_search.sql,
+// иначе Postgres не сможет их использовать.
+const (
+	songSearchDocument = `setweight(to_tsvector('russian', coalesce(song_name, '')) || to_tsvector('english', coalesce(song_name, '')), 'A') || ` +
+		`setweight(to_tsvector('russian', coalesce(group_name, '')) || to_tsvector('english', coalesce(group_name, '')), 'B')`
+	verseSearchDocument = `to_tsvector('russian', text) || to_tsvector('english', text)`
+)
+
+// searchQuery находит песни, у которых совпали название или исполнитель, либо хотя бы один куплет.
+// Для каждой песни берётся самый релевантный куплет.
+var searchQuery = strings.Join([]string{
+	`WITH q AS (SELECT websearch_to_tsquery('russian', @query) || websearch_to_tsquery('english', @query) AS query),`,
+	`title_hits AS (`,
+	`	SELECT id AS song_id, ts_rank(` + songSearchDocument + `, q.query) AS rank`,
+	`	FROM song_details, q WHERE deleted_at IS NULL AND ` + songSearchDocument + ` @@ q.query),`,
+	`verse_hits AS (`,
+	`	SELECT DISTINCT ON (song_id) song_id, verse_index, text, ts_rank(` + verseSearchDocument + `, q.query) AS rank`,
+	`	FROM song_verses, q WHERE ` + verseSearchDocument + ` @@ q.query`,
+	`	ORDER BY song_id, rank DESC, verse_index)`,
+	`SELECT song_details.*, coalesce(t.rank, 0) + coalesce(v.rank, 0) AS search_rank, v.verse_index,`,
+	`	coalesce(ts_headline('russian', v.text, q.query, 'StartSel=<mark>, StopSel=</mark>, MaxFragments=2'), '') AS snippet,`,
+	`	count(*) OVER () AS total`,
+	`FROM song_details CROSS JOIN q`,
+	`LEFT JOIN title_hits t ON t.song_id = song_details.id`,
+	`LEFT JOIN verse_hits v ON v.song_id = song_details.id`,
+	`WHERE t.song_id IS NOT NULL OR v.song_id IS NOT NULL`,
+	`ORDER BY search_rank DESC, song_details.id`,
+	`LIMIT NULLIF(@limit, 0) OFFSET @offset`,
+}, "\n")
+
+type searchRow struct {
+	models.SongDetail `gorm:"embedded"`
+	SearchRank        float64
+	VerseIndex        *int
+	Snippet           string
+	Total             int64
+}
+
+func (p *postgresSongs) Search(ctx context.Context, query string, limit, offset int) ([]models.SearchHit, int64, error) {
+	var rows []searchRow
+	err := p.db.WithContext(ctx).Raw(searchQuery, map[string]interface{}{
+		"query":  query,
+		"limit":  limit,
+		"offset": offset,
+	}).Scan(&rows).Error
+	if err != nil {
+		return nil, 0, translateError(err)
+	}
+
+	hits := make([]models.SearchHit, 0, len(rows))
+	var total int64
+	for _, row := range rows {
+		total = row.Total
+		hits = append(hits, models.SearchHit{
+			Song:       row.SongDetail,
+			Rank:       row.SearchRank,
+			VerseIndex: row.VerseIndex,
+			Snippet:    row.Snippet,
+		})
+	}
+	return hits, total, nil
+}
+
+// syncSongVerses пересобирает поисковый индекс куплетов песни
+func syncSongVerses(tx *gorm.DB, song *models.SongDetail) error {
+	if err := tx.Where("song_id = ?", song.ID).Delete(&models.SongVerse{}).Error; err != nil {
+		return err
+	}
+
+	verses := songVerses(song.Text)
+	if len(verses) == 0 {
+		return nil
+	}
+	rows := make([]models.SongVerse, 0, len(verses))
+	for i, verse := range verses {
+		rows = append(rows, models.SongVerse{SongID: song.ID, VerseIndex: i, Text: verse})
+	}
+	return tx.Create(&rows).Error
+}
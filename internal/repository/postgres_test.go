@@ -0,0 +1,50 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"music/internal/models"
+	"music/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// sqlRecorder запоминает запросы, которые GORM строит в режиме DryRun
+type sqlRecorder struct {
+	gormlogger.Interface
+	queries []string
+}
+
+func (r *sqlRecorder) Trace(_ context.Context, _ time.Time, fc func() (string, int64), _ error) {
+	query, _ := fc()
+	r.queries = append(r.queries, query)
+}
+
+func TestPostgres_SongKeysetByReleaseDate(t *testing.T) {
+	recorder := &sqlRecorder{Interface: gormlogger.Discard}
+	db, err := gorm.Open(postgres.Open("host=127.0.0.1 port=1"), &gorm.Config{
+		DryRun: true, DisableAutomaticPing: true, Logger: recorder,
+	})
+	require.NoError(t, err)
+
+	sorts := []repository.SongSort{{Field: repository.SongSortReleaseDate, Desc: true}}
+	releaseDate := time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)
+	cursor := repository.NewSongCursor(models.SongDetail{ID: 7, ReleaseDate: &releaseDate}, sorts)
+	_, _, err = repository.NewPostgres(db).Songs.List(context.Background(), repository.SongFilter{
+		Sort: sorts, After: &cursor, Limit: 10, SkipTotal: true,
+	})
+	require.NoError(t, err)
+	require.Len(t, recorder.queries, 1)
+
+	// Песни без даты релиза сравниваются и сортируются как песни с нулевой датой, а не выпадают из обхода
+	query := recorder.queries[0]
+	assert.Contains(t, query, "(COALESCE(song_details.release_date, DATE '0001-01-01') < CAST('1990-01-01 00:00:00' AS date))")
+	assert.Contains(t, query, "(COALESCE(song_details.release_date, DATE '0001-01-01') = CAST('1990-01-01 00:00:00' AS date) AND song_details.id > 7)")
+	assert.Contains(t, query, "ORDER BY COALESCE(song_details.release_date, DATE '0001-01-01') DESC,song_details.id")
+}
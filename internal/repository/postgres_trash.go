@@ -0,0 +1,287 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"music/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Таблицы с треками и позициями в плейлистах песен из корзины
+const (
+	trashedAlbumTracksTable   = "trashed_album_tracks"
+	trashedPlaylistItemsTable = "trashed_playlist_items"
+)
+
+type postgresTrash struct {
+	db *gorm.DB
+}
+
+// trashQuery собирает удалённые песни и исполнителей в один список
+var trashQuery = strings.Join([]string{
+	`SELECT *, count(*) OVER () AS total FROM (`,
+	`	SELECT 'song' AS type, id, song_name AS name, artist_id, group_name AS artist, deleted_at`,
+	`	FROM song_details WHERE deleted_at IS NOT NULL AND @type IN ('', 'song')`,
+	`	UNION ALL`,
+	`	SELECT 'artist', id, name, 0, '', deleted_at`,
+	`	FROM artists WHERE deleted_at IS NOT NULL AND @type IN ('', 'artist')`,
+	`) trash`,
+	`ORDER BY deleted_at DESC, type, id`,
+	`LIMIT NULLIF(@limit, 0) OFFSET @offset`,
+}, "\n")
+
+type trashRow struct {
+	models.TrashItem `gorm:"embedded"`
+	Total            int64
+}
+
+func (p *postgresTrash) List(ctx context.Context, filter TrashFilter) ([]models.TrashItem, int64, error) {
+	var rows []trashRow
+	err := p.db.WithContext(ctx).Raw(trashQuery, map[string]interface{}{
+		"type":   filter.Type,
+		"limit":  filter.Limit,
+		"offset": filter.Offset,
+	}).Scan(&rows).Error
+	if err != nil {
+		return nil, 0, translateError(err)
+	}
+
+	items := make([]models.TrashItem, 0, len(rows))
+	var total int64
+	for _, row := range rows {
+		total = row.Total
+		items = append(items, row.TrashItem)
+	}
+	return items, total, nil
+}
+
+func (p *postgresTrash) RestoreSong(ctx context.Context, id uint) (*models.SongDetail, error) {
+	var song models.SongDetail
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("deleted_at IS NOT NULL").First(&song, id).Error; err != nil {
+			return err
+		}
+		// Удалённый исполнитель возвращается вместе с песней
+		var artist models.Artist
+		err := tx.Unscoped().Where("deleted_at IS NOT NULL").First(&artist, song.ArtistID).Error
+		if err == nil {
+			err = restoreArtistRecord(tx, &artist)
+		} else if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&song).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		if err := syncSongVerses(tx, &song); err != nil {
+			return err
+		}
+		return restoreSongMemberships(tx)
+	})
+	if err != nil {
+		return nil, translateError(err)
+	}
+	song.DeletedAt = gorm.DeletedAt{}
+	return &song, nil
+}
+
+func (p *postgresTrash) RestoreArtist(ctx context.Context, id uint) (*models.Artist, error) {
+	var artist models.Artist
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("deleted_at IS NOT NULL").First(&artist, id).Error; err != nil {
+			return err
+		}
+
+		// Песни, удалённые раньше исполнителя по отдельности, остаются в корзине.
+		// Момент удаления запоминается до восстановления: Update обнуляет его и в artist.
+		var songs []models.SongDetail
+		deletedTogether := "artist_id = ? AND deleted_at = ?"
+		deletedAt := artist.DeletedAt.Time
+		if err := tx.Unscoped().Where(deletedTogether, id, deletedAt).Find(&songs).Error; err != nil {
+			return err
+		}
+		if err := restoreArtistRecord(tx, &artist); err != nil {
+			return err
+		}
+		if len(songs) > 0 {
+			err := tx.Unscoped().Model(&models.SongDetail{}).Where(deletedTogether, id, deletedAt).Update("deleted_at", nil).Error
+			if err != nil {
+				return err
+			}
+		}
+		for i := range songs {
+			if err := syncSongVerses(tx, &songs[i]); err != nil {
+				return err
+			}
+		}
+		return restoreSongMemberships(tx)
+	})
+	if err != nil {
+		return nil, translateError(err)
+	}
+	artist.DeletedAt = gorm.DeletedAt{}
+	return &artist, nil
+}
+
+func (p *postgresTrash) PurgeSong(ctx context.Context, id uint) error {
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Where("deleted_at IS NOT NULL").Delete(&models.SongDetail{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		return tx.Where("song_id = ?", id).Delete(&models.SongRevision{}).Error
+	})
+	return translateError(err)
+}
+
+func (p *postgresTrash) PurgeArtist(ctx context.Context, id uint) error {
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var artist models.Artist
+		if err := tx.Unscoped().Where("deleted_at IS NOT NULL").First(&artist, id).Error; err != nil {
+			return err
+		}
+		artistSongs := tx.Unscoped().Model(&models.SongDetail{}).Select("id").Where("artist_id = ?", id)
+		if err := tx.Where("song_id IN (?)", artistSongs).Delete(&models.SongRevision{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("artist_id = ?", id).Delete(&models.SongDetail{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&artist).Error
+	})
+	return translateError(err)
+}
+
+func (p *postgresTrash) Purge(ctx context.Context, before time.Time) (models.TrashPurgeResult, error) {
+	var result models.TrashPurgeResult
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Песни исполнителя удаляются не позже него самого, поэтому к удалению исполнителей песен у них уже нет
+		expired := tx.Unscoped().Model(&models.SongDetail{}).Select("id").Where("deleted_at < ?", before)
+		if err := tx.Where("song_id IN (?)", expired).Delete(&models.SongRevision{}).Error; err != nil {
+			return err
+		}
+		songs := tx.Unscoped().Where("deleted_at < ?", before).Delete(&models.SongDetail{})
+		if songs.Error != nil {
+			return songs.Error
+		}
+		artists := tx.Unscoped().Where("deleted_at < ?", before).Delete(&models.Artist{})
+		if artists.Error != nil {
+			return artists.Error
+		}
+		result = models.TrashPurgeResult{Songs: songs.RowsAffected, Artists: artists.RowsAffected}
+		return nil
+	})
+	return result, translateError(err)
+}
+
+// restoreArtistRecord возвращает исполнителя из корзины вместе с релизами, удалёнными вместе с ним
+func restoreArtistRecord(tx *gorm.DB, artist *models.Artist) error {
+	err := tx.Unscoped().Model(&models.Album{}).Where("artist_id = ? AND deleted_at = ?", artist.ID, artist.DeletedAt.Time).
+		Update("deleted_at", nil).Error
+	if err != nil {
+		return err
+	}
+	return tx.Unscoped().Model(artist).Update("deleted_at", nil).Error
+}
+
+// trashSongMemberships переносит в корзину треки песен в релизах не из корзины и позиции песен в плейлистах.
+// Треки в релизах, которые уже в корзине, остаются в их треклистах.
+func trashSongMemberships(tx *gorm.DB, songIDs []uint) error {
+	liveAlbums := tx.Model(&models.Album{}).Select("id")
+	err := tx.Exec(`INSERT INTO `+trashedAlbumTracksTable+` (album_id, disc_number, track_number, song_id)
+		SELECT album_id, disc_number, track_number, song_id FROM album_tracks WHERE song_id IN ? AND album_id IN (?)
+		ON CONFLICT DO NOTHING`, songIDs, liveAlbums).Error
+	if err != nil {
+		return err
+	}
+	if err := tx.Where("song_id IN ? AND album_id IN (?)", songIDs, liveAlbums).Delete(&models.AlbumTrack{}).Error; err != nil {
+		return err
+	}
+	err = tx.Exec(`INSERT INTO `+trashedPlaylistItemsTable+` (playlist_id, song_id, position, added_at)
+		SELECT playlist_id, song_id, position, added_at FROM playlist_items WHERE song_id IN ?
+		ON CONFLICT DO NOTHING`, songIDs).Error
+	if err != nil {
+		return err
+	}
+	return removeSongsFromPlaylists(tx, songIDs)
+}
+
+// restoreSongMemberships возвращает песням не из корзины их треки в релизах не из корзины и позиции
+// в плейлистах. Если место трека за это время заняли, трек ставится в конец диска; позиция в плейлисте
+// ограничивается его текущей длиной. Треки в релизах из корзины ждут восстановления релиза.
+func restoreSongMemberships(tx *gorm.DB) error {
+	liveSongs := tx.Model(&models.SongDetail{}).Select("id")
+	liveAlbums := tx.Model(&models.Album{}).Select("id")
+
+	var tracks []models.AlbumTrack
+	err := tx.Table(trashedAlbumTracksTable).Where("song_id IN (?) AND album_id IN (?)", liveSongs, liveAlbums).
+		Order("album_id, disc_number, track_number").Find(&tracks).Error
+	if err != nil {
+		return err
+	}
+	for _, track := range tracks {
+		var taken int64
+		err := tx.Model(&models.AlbumTrack{}).
+			Where("album_id = ? AND disc_number = ? AND track_number = ?", track.AlbumID, track.DiscNumber, track.TrackNumber).
+			Count(&taken).Error
+		if err != nil {
+			return err
+		}
+		if taken > 0 {
+			var last int
+			err := tx.Model(&models.AlbumTrack{}).Where("album_id = ? AND disc_number = ?", track.AlbumID, track.DiscNumber).
+				Select("COALESCE(MAX(track_number), 0)").Scan(&last).Error
+			if err != nil {
+				return err
+			}
+			track.TrackNumber = last + 1
+		}
+		if err := tx.Create(&track).Error; err != nil {
+			return err
+		}
+	}
+	if len(tracks) > 0 {
+		err := tx.Table(trashedAlbumTracksTable).Where("song_id IN (?) AND album_id IN (?)", liveSongs, liveAlbums).
+			Delete(&models.AlbumTrack{}).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	var items []models.PlaylistItem
+	err = tx.Table(trashedPlaylistItemsTable).Where("song_id IN (?)", liveSongs).Order("playlist_id, position").Find(&items).Error
+	if err != nil || len(items) == 0 {
+		return err
+	}
+	// Плейлисты блокируются в порядке ID, как при удалении песен из них
+	playlistIDs := make([]uint, 0, len(items))
+	for _, item := range items {
+		if len(playlistIDs) == 0 || playlistIDs[len(playlistIDs)-1] != item.PlaylistID {
+			playlistIDs = append(playlistIDs, item.PlaylistID)
+		}
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id IN ?", playlistIDs).Order("id").
+		Find(&[]models.Playlist{}).Error; err != nil {
+		return err
+	}
+	// Позиции восстанавливаются по возрастанию, поэтому песни, удалённые вместе, встают на прежние места
+	for i := range items {
+		if err := insertPlaylistItem(tx, &items[i]); err != nil {
+			return err
+		}
+	}
+	if err := tx.Table(trashedPlaylistItemsTable).Where("song_id IN (?)", liveSongs).Delete(&models.PlaylistItem{}).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.Playlist{}).Where("id IN ?", playlistIDs).Update("updated_at", time.Now()).Error
+}
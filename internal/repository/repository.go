@@ -0,0 +1,273 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"music/internal/actor"
+	"music/internal/models"
+)
+
+var (
+	// ErrNotFound возвращается, если запись не найдена
+	ErrNotFound = errors.New("record not found")
+	// ErrAlreadyExists возвращается при нарушении уникальности
+	ErrAlreadyExists = errors.New("record already exists")
+	// ErrArtistHasSongs возвращается при удалении исполнителя с песнями без каскадного удаления
+	ErrArtistHasSongs = errors.New("artist has songs")
+	// ErrArtistHasAlbums возвращается при удалении исполнителя с релизами без каскадного удаления
+	ErrArtistHasAlbums = errors.New("artist has albums")
+	// ErrVersionConflict возвращается, если запись изменилась после того, как её прочитали:
+	// версия в хранилище не совпала с ожидаемой
+	ErrVersionConflict = errors.New("record version mismatch")
+	// ErrInvalidReference возвращается, если запись ссылается на несуществующего исполнителя или песню
+	ErrInvalidReference = errors.New("referenced record not found")
+)
+
+// SongFilter описывает фильтрацию, сортировку и пагинацию списка песен. Все условия объединяются через AND.
+type SongFilter struct {
+	ArtistID     uint        // Песни конкретного исполнителя
+	AlbumID      uint        // Песни релиза; без сортировки - в порядке треклиста
+	SongName     string      // Точное совпадение названия без учёта регистра
+	Title        string      // Подстрока названия без учёта регистра
+	ArtistName   string      // Подстрока имени исполнителя без учёта регистра
+	ReleaseDate  *time.Time  // Точная дата релиза
+	ReleasedFrom *time.Time  // Дата релиза не раньше указанной
+	ReleasedTo   *time.Time  // Дата релиза не позже указанной
+	CreatedFrom  *time.Time  // Добавлена не раньше указанного момента
+	CreatedTo    *time.Time  // Добавлена не позже указанного момента
+	HasLyrics    *bool       // Есть ли у песни текст
+	Sort         []SongSort  // Порядок сортировки; при равенстве песни упорядочиваются по ID
+	After        *SongCursor // Постраничный обход по ключу: только песни после курсора
+	SkipTotal    bool        // Не считать общее число песен, List вернёт 0
+	Limit        int
+	Offset       int
+}
+
+// Поля сортировки списка песен
+const (
+	SongSortReleaseDate = "release_date"
+	SongSortTitle       = "title"
+	SongSortArtist      = "artist"
+	SongSortCreatedAt   = "created_at"
+)
+
+// SongSort - одно поле сортировки списка песен
+type SongSort struct {
+	Field string
+	Desc  bool
+}
+
+// ParseSongSort разбирает список полей через запятую; минус перед полем означает сортировку по убыванию
+func ParseSongSort(raw string) ([]SongSort, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	var sorts []SongSort
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		sort := SongSort{Field: strings.TrimPrefix(part, "-"), Desc: strings.HasPrefix(part, "-")}
+		switch sort.Field {
+		case SongSortReleaseDate, SongSortTitle, SongSortArtist, SongSortCreatedAt:
+		default:
+			return nil, fmt.Errorf("unknown sort field %q", sort.Field)
+		}
+		sorts = append(sorts, sort)
+	}
+	return sorts, nil
+}
+
+// SongRepository - хранилище песен
+type SongRepository interface {
+	// List возвращает страницу песен и общее число песен, подходящих под фильтр без учёта курсора и пагинации
+	List(ctx context.Context, filter SongFilter) ([]models.SongDetail, int64, error)
+	GetByID(ctx context.Context, id uint) (*models.SongDetail, error)
+	FindByName(ctx context.Context, name string) ([]models.SongDetail, error)
+	FindByArtistAndName(ctx context.Context, artistID uint, name string) (*models.SongDetail, error)
+	// Create добавляет песню и записывает её первую правку
+	Create(ctx context.Context, song *models.SongDetail) error
+	// CreateMany добавляет песни одним запросом. Песни, которые уже есть у исполнителя, пропускаются:
+	// для них created[i] равен false, а у добавленных заполняются ID и CreatedAt.
+	CreateMany(ctx context.Context, songs []*models.SongDetail) (created []bool, err error)
+	// Update сохраняет песню, если её версия в хранилище равна song.Version, и увеличивает версию;
+	// иначе возвращает ErrVersionConflict. Если изменились название, исполнитель, дата релиза, ссылка или текст,
+	// записывает новую правку от имени клиента из контекста (см. пакет actor).
+	Update(ctx context.Context, song *models.SongDetail) error
+	// Delete переносит песню в корзину. Если version не 0, а версия песни другая, возвращает ErrVersionConflict.
+	Delete(ctx context.Context, id uint, version int64) error
+	// Search ищет песни по названию, исполнителю и куплетам и возвращает страницу результатов
+	// в порядке убывания релевантности вместе с общим числом найденных песен
+	Search(ctx context.Context, query string, limit, offset int) ([]models.SearchHit, int64, error)
+}
+
+// ArtistRepository - хранилище исполнителей. Методы, принимающие version, возвращают ErrVersionConflict,
+// если version не 0 и не равна текущей версии исполнителя.
+type ArtistRepository interface {
+	List(ctx context.Context, limit, offset int) ([]models.Artist, int64, error)
+	GetByID(ctx context.Context, id uint) (*models.Artist, error)
+	GetByName(ctx context.Context, name string) (*models.Artist, error)
+	Create(ctx context.Context, artist *models.Artist) error
+	// EnsureNames возвращает исполнителей с указанными именами, создавая недостающих
+	EnsureNames(ctx context.Context, names []string) (map[string]models.Artist, error)
+	// Rename переименовывает исполнителя и обновляет GroupName у всех его песен, увеличивая версии исполнителя и песен
+	Rename(ctx context.Context, id uint, version int64, name string) (*models.Artist, error)
+	// Delete переносит исполнителя в корзину; при cascade=false исполнитель с песнями или релизами не удаляется.
+	// При cascade=true песни исполнителя переносятся в корзину вместе с ним, а релизы удаляются.
+	Delete(ctx context.Context, id uint, version int64, cascade bool) error
+}
+
+// AlbumFilter описывает фильтрацию и пагинацию списка релизов
+type AlbumFilter struct {
+	ArtistID uint
+	Limit    int
+	Offset   int
+}
+
+// AlbumRepository - хранилище релизов. Треки возвращаются упорядоченными по диску и номеру.
+type AlbumRepository interface {
+	List(ctx context.Context, filter AlbumFilter) ([]models.Album, int64, error)
+	GetByID(ctx context.Context, id uint) (*models.Album, error)
+	Create(ctx context.Context, album *models.Album) error
+	// Update заменяет поля и треклист релиза
+	Update(ctx context.Context, album *models.Album) error
+	Delete(ctx context.Context, id uint) error
+}
+
+// PlaylistFilter описывает фильтрацию и пагинацию списка плейлистов
+type PlaylistFilter struct {
+	Owner  string
+	Limit  int
+	Offset int
+}
+
+// PlaylistRepository - хранилище плейлистов. Все изменения состава выполняются под блокировкой
+// плейлиста, поэтому одновременные правки не нарушают сплошную нумерацию позиций.
+type PlaylistRepository interface {
+	List(ctx context.Context, filter PlaylistFilter) ([]models.Playlist, int64, error)
+	GetByID(ctx context.Context, id uint) (*models.Playlist, error)
+	Create(ctx context.Context, playlist *models.Playlist) error
+	Rename(ctx context.Context, id uint, name string) (*models.Playlist, error)
+	Delete(ctx context.Context, id uint) error
+	// Entries возвращает песни плейлиста в порядке позиций и их общее количество
+	Entries(ctx context.Context, id uint, limit, offset int) ([]models.PlaylistEntry, int64, error)
+	// AddSong вставляет песню на позицию (0 или позиция за концом - в конец), сдвигая остальные
+	AddSong(ctx context.Context, playlistID, songID uint, position int) (*models.PlaylistItem, error)
+	// RemoveSong убирает песню и сдвигает последующие позиции без пропусков
+	RemoveSong(ctx context.Context, playlistID, songID uint) error
+	// MoveSong переносит песню на новую позицию (позиция за концом - в конец)
+	MoveSong(ctx context.Context, playlistID, songID uint, position int) (*models.PlaylistItem, error)
+}
+
+// TrashFilter описывает фильтрацию и пагинацию корзины
+type TrashFilter struct {
+	Type   string // models.TrashSong или models.TrashArtist; пусто - все записи
+	Limit  int
+	Offset int
+}
+
+// TrashRepository - корзина удалённых песен и исполнителей. Остальные хранилища удалённых записей не видят.
+// Песня в корзине сохраняет текст и данные, но убрана из релизов, плейлистов и поискового индекса.
+// Для записей не из корзины методы возвращают ErrNotFound.
+type TrashRepository interface {
+	// List возвращает страницу корзины от недавно удалённых записей к давним и общее число записей
+	List(ctx context.Context, filter TrashFilter) ([]models.TrashItem, int64, error)
+	// RestoreSong возвращает песню из корзины, а вместе с ней и её исполнителя, если он тоже удалён.
+	// Если название песни или имя исполнителя уже заняты, возвращает ErrAlreadyExists.
+	RestoreSong(ctx context.Context, id uint) (*models.SongDetail, error)
+	// RestoreArtist возвращает из корзины исполнителя и песни, удалённые вместе с ним
+	RestoreArtist(ctx context.Context, id uint) (*models.Artist, error)
+	PurgeSong(ctx context.Context, id uint) error
+	// PurgeArtist удаляет навсегда исполнителя и все его песни
+	PurgeArtist(ctx context.Context, id uint) error
+	// Purge удаляет навсегда записи, перенесённые в корзину раньше before
+	Purge(ctx context.Context, before time.Time) (models.TrashPurgeResult, error)
+}
+
+// RevisionRepository - история правок песен. Правки пишет SongRepository при добавлении и изменении песни;
+// у песен, добавленных до появления истории, первой правкой становится состояние до первого изменения.
+// История удаляется вместе с песней при очистке корзины.
+type RevisionRepository interface {
+	// List возвращает страницу правок песни от новых к старым и их общее число
+	List(ctx context.Context, songID uint, limit, offset int) ([]models.SongRevision, int64, error)
+	// Get возвращает правку песни по номеру
+	Get(ctx context.Context, songID uint, number int) (*models.SongRevision, error)
+}
+
+// Transactor выполняет несколько операций с хранилищами атомарно
+type Transactor interface {
+	// InTx передаёт fn хранилища, работающие в одной транзакции. Если fn вернула ошибку,
+	// все изменения откатываются и InTx возвращает эту ошибку. Вложенный вызов InTx откатывает только свои изменения.
+	InTx(ctx context.Context, fn func(repos Repositories) error) error
+}
+
+// Repositories объединяет все хранилища приложения
+type Repositories struct {
+	Songs     SongRepository
+	Artists   ArtistRepository
+	Albums    AlbumRepository
+	Playlists PlaylistRepository
+	Trash     TrashRepository
+	Revisions RevisionRepository
+	Tx        Transactor
+}
+
+// songVerses извлекает куплеты из текста песни, сохранённого в формате models.SongText.
+// Каждый раздел текста индексируется как отдельный куплет.
+func songVerses(text string) []string {
+	if text == "" {
+		return nil
+	}
+	var lyrics models.SongText
+	if err := json.Unmarshal([]byte(text), &lyrics); err != nil {
+		return nil
+	}
+	return lyrics.FlatVerses()
+}
+
+// songRevision снимает с песни отслеживаемые поля для истории правок
+func songRevision(ctx context.Context, song *models.SongDetail) models.SongRevision {
+	return models.SongRevision{
+		SongID:      song.ID,
+		ClientID:    actor.FromContext(ctx),
+		ArtistID:    song.ArtistID,
+		GroupName:   song.GroupName,
+		SongName:    song.SongName,
+		ReleaseDate: song.ReleaseDate,
+		SongURL:     song.SongURL,
+		Text:        song.Text,
+	}
+}
+
+// revisionChanged сообщает, отличаются ли песни отслеживаемыми полями
+func revisionChanged(before, after *models.SongDetail) bool {
+	return before.ArtistID != after.ArtistID ||
+		before.GroupName != after.GroupName ||
+		before.SongName != after.SongName ||
+		!models.SameDate(before.ReleaseDate, after.ReleaseDate) ||
+		before.SongURL != after.SongURL ||
+		before.Text != after.Text
+}
+
+// updateRevisions возвращает правки, которые нужно записать после изменения песни, если до сих пор
+// у неё было last правок. У песни без истории первой правкой записывается её прежнее состояние.
+func updateRevisions(ctx context.Context, before, after *models.SongDetail, last int) []models.SongRevision {
+	if !revisionChanged(before, after) {
+		return nil
+	}
+	var revisions []models.SongRevision
+	if last == 0 {
+		// Кто добавил песню, неизвестно
+		baseline := songRevision(context.Background(), before)
+		baseline.Number, baseline.CreatedAt = 1, before.CreatedAt
+		revisions = append(revisions, baseline)
+		last = 1
+	}
+	revision := songRevision(ctx, after)
+	revision.Number, revision.CreatedAt = last+1, time.Now()
+	return append(revisions, revision)
+}
@@ -1,29 +1,115 @@
 package router
 
 import (
+	"database/sql"
 	"net/http"
 
 	_ "music/docs" // Импортируйте сгенерированные файлы Swagger
+	"music/internal/actor"
 	"music/internal/handlers"
+	"music/internal/health"
+	"music/internal/middleware"
+	"music/internal/repository"
 
 	"github.com/go-chi/chi"
 	httpSwagger "github.com/swaggo/http-swagger"
-	"gorm.io/gorm"
 )
 
-func NewRouter(db *gorm.DB) http.Handler {
+// NewRouter собирает маршруты API поверх переданных хранилищ.
+// checker выполняет проверки готовности для /readyz; nil - приложение всегда готово.
+// dbStats отдаёт состояние пула соединений для /debug/db; nil - маршрут не регистрируется.
+func NewRouter(repos repository.Repositories, details handlers.SongDetailsFetcher, checker *health.Checker, dbStats func() sql.DBStats) http.Handler {
 	r := chi.NewRouter()
+	// Идентификатор запроса и логгер с ним нужны всем остальным обработчикам, поэтому они идут первыми
+	r.Use(middleware.RequestID, middleware.AccessLog)
+	// Клиент из X-Client-ID записывается в историю правок песен
+	r.Use(actor.Middleware)
 
-	// Роуты для API
-	r.Get("/info", handlers.GetInfoHandler)
-	r.Get("/songs", handlers.GetSongsHandler(db))
-	r.Post("/songs", handlers.AddSongHandler(db))
-	r.Delete("/songs/{songName}", handlers.DeleteSongHandler(db))
-	r.Put("/songs/{songName}", handlers.UpdateSongHandler(db))
-	r.Get("/songs/{songName}/lyrics", handlers.GetSongLyricsHandler(db))
+	// Внутри группы маршрут уже найден, поэтому логгер запроса дополняется шаблоном маршрута
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.RouteLogger)
 
-	// Роут для Swagger UI
-	r.Get("/swagger/*", httpSwagger.WrapHandler) // Доступ к Swagger документации
+		// Проверки живости и готовности для оркестратора
+		r.Get("/healthz", handlers.GetHealthzHandler)
+		r.Get("/readyz", handlers.GetReadyzHandler(checker))
+		if dbStats != nil {
+			r.Get("/debug/db", handlers.GetDBStatsHandler(dbStats))
+		}
+
+		// Роуты для API
+		r.Get("/info", handlers.GetInfoHandler)
+		r.Get("/songs", handlers.GetSongsHandler(repos.Songs))
+		r.Post("/songs", handlers.AddSongHandler(repos.Songs, repos.Artists, details))
+		r.Post("/songs/batch", handlers.AddSongsBatchHandler(repos))
+		r.Get("/search", handlers.SearchHandler(repos.Songs))
+		r.Get("/export", handlers.ExportHandler(repos))
+		r.Post("/import", handlers.ImportHandler(repos))
+
+		// Песня по ID
+		byID := handlers.SongByID(repos.Songs)
+		r.Get("/songs/{id:[0-9]+}", handlers.GetSongHandler(byID))
+		r.Delete("/songs/{id:[0-9]+}", handlers.DeleteSongHandler(byID, repos.Songs))
+		r.Put("/songs/{id:[0-9]+}", handlers.UpdateSongHandler(byID, repos.Songs, repos.Artists))
+		r.Patch("/songs/{id:[0-9]+}", handlers.PatchSongHandler(byID, repos.Songs, repos.Artists))
+		r.Get("/songs/{id:[0-9]+}/lyrics", handlers.GetSongLyricsHandler(byID))
+		r.Post("/songs/{id:[0-9]+}/lyrics", handlers.UploadSongLyricsHandler(byID, repos.Songs))
+		r.Get("/songs/{id:[0-9]+}/lyrics/lrc", handlers.ExportLRCHandler(byID))
+		r.Post("/songs/{id:[0-9]+}/lyrics/lrc", handlers.ImportLRCHandler(byID, repos.Songs))
+		r.Get("/songs/{id:[0-9]+}/lyrics/at", handlers.GetLyricsAtHandler(byID))
+		r.Get("/songs/{id:[0-9]+}/revisions", handlers.GetSongRevisionsHandler(byID, repos.Revisions))
+		r.Get("/songs/{id:[0-9]+}/revisions/diff", handlers.DiffSongRevisionsHandler(byID, repos.Revisions))
+		r.Get("/songs/{id:[0-9]+}/revisions/{rev:[0-9]+}", handlers.GetSongRevisionHandler(byID, repos.Revisions))
+		r.Post("/songs/{id:[0-9]+}/revisions/{rev:[0-9]+}/revert", handlers.RevertSongHandler(byID, repos))
+
+		// Песня по названию: при неоднозначном названии возвращается 409 со списком ID.
+		// Название из одних цифр, например "1979", короткий маршрут /songs/{songName} принимает за ID,
+		// поэтому такие песни ищутся только через /songs/by-name/{songName}
+		byName := handlers.SongByName(repos.Songs)
+		r.Get("/songs/by-name/{songName}", handlers.GetSongHandler(byName))
+		r.Delete("/songs/by-name/{songName}", handlers.DeleteSongHandler(byName, repos.Songs))
+		r.Put("/songs/by-name/{songName}", handlers.UpdateSongHandler(byName, repos.Songs, repos.Artists))
+		r.Get("/songs/by-name/{songName}/lyrics", handlers.GetSongLyricsHandler(byName))
+		r.Get("/songs/{songName}", handlers.GetSongHandler(byName))
+		r.Delete("/songs/{songName}", handlers.DeleteSongHandler(byName, repos.Songs))
+		r.Put("/songs/{songName}", handlers.UpdateSongHandler(byName, repos.Songs, repos.Artists))
+		r.Get("/songs/{songName}/lyrics", handlers.GetSongLyricsHandler(byName))
+
+		// Исполнители
+		r.Get("/artists", handlers.GetArtistsHandler(repos.Artists))
+		r.Post("/artists", handlers.AddArtistHandler(repos.Artists))
+		r.Get("/artists/{id:[0-9]+}", handlers.GetArtistHandler(repos.Artists))
+		r.Put("/artists/{id:[0-9]+}", handlers.RenameArtistHandler(repos.Artists))
+		r.Delete("/artists/{id:[0-9]+}", handlers.DeleteArtistHandler(repos.Artists))
+		r.Get("/artists/{id:[0-9]+}/songs", handlers.GetArtistSongsHandler(repos.Artists, repos.Songs))
+
+		// Релизы
+		r.Get("/albums", handlers.GetAlbumsHandler(repos.Albums))
+		r.Post("/albums", handlers.AddAlbumHandler(repos.Albums))
+		r.Get("/albums/{id:[0-9]+}", handlers.GetAlbumHandler(repos.Albums))
+		r.Put("/albums/{id:[0-9]+}", handlers.UpdateAlbumHandler(repos.Albums))
+		r.Delete("/albums/{id:[0-9]+}", handlers.DeleteAlbumHandler(repos.Albums))
+
+		// Плейлисты
+		r.Get("/playlists", handlers.GetPlaylistsHandler(repos.Playlists))
+		r.Post("/playlists", handlers.AddPlaylistHandler(repos.Playlists))
+		r.Get("/playlists/{id:[0-9]+}", handlers.GetPlaylistHandler(repos.Playlists))
+		r.Put("/playlists/{id:[0-9]+}", handlers.RenamePlaylistHandler(repos.Playlists))
+		r.Delete("/playlists/{id:[0-9]+}", handlers.DeletePlaylistHandler(repos.Playlists))
+		r.Post("/playlists/{id:[0-9]+}/songs", handlers.AddPlaylistSongHandler(repos.Playlists))
+		r.Delete("/playlists/{id:[0-9]+}/songs/{songID:[0-9]+}", handlers.RemovePlaylistSongHandler(repos.Playlists))
+		r.Put("/playlists/{id:[0-9]+}/songs/{songID:[0-9]+}/position", handlers.MovePlaylistSongHandler(repos.Playlists))
+
+		// Корзина удалённых песен и исполнителей
+		r.Get("/trash", handlers.GetTrashHandler(repos.Trash))
+		r.Delete("/trash", handlers.EmptyTrashHandler(repos.Trash))
+		r.Post("/trash/songs/{id:[0-9]+}/restore", handlers.RestoreSongHandler(repos.Trash))
+		r.Delete("/trash/songs/{id:[0-9]+}", handlers.PurgeSongHandler(repos.Trash))
+		r.Post("/trash/artists/{id:[0-9]+}/restore", handlers.RestoreArtistHandler(repos.Trash))
+		r.Delete("/trash/artists/{id:[0-9]+}", handlers.PurgeArtistHandler(repos.Trash))
+
+		// Роут для Swagger UI
+		r.Get("/swagger/*", httpSwagger.WrapHandler) // Доступ к Swagger документации
+	})
 
 	return r
 }
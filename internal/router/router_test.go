@@ -0,0 +1,1174 @@
+package router_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"music/internal/health"
+	"music/internal/models"
+	"music/internal/repository"
+	"music/internal/router"
+	"music/internal/songdetails"
+	"music/pkg/lyrics"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubDetails struct {
+	details *songdetails.Details
+	err     error
+}
+
+func (s stubDetails) Fetch(context.Context, string, string) (*songdetails.Details, error) {
+	return s.details, s.err
+}
+
+// doRequest выполняет запрос к маршрутизатору и возвращает записанный ответ
+func doRequest(t *testing.T, handler http.Handler, method, target string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var reader *bytes.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		require.NoError(t, err)
+		reader = bytes.NewReader(payload)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req := httptest.NewRequest(method, target, reader)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+// doTextRequest отправляет тело запроса как есть с указанным типом содержимого
+func doTextRequest(handler http.Handler, method, target, contentType, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestAddSong_Enrichment(t *testing.T) {
+	tests := []struct {
+		name       string
+		details    stubDetails
+		wantStatus string
+		wantURL    string
+	}{
+		{
+			name: "Upstream succeeds",
+			details: stubDetails{details: &songdetails.Details{
+				ReleaseDate: "16.07.2006",
+				Text:        "Куплет один\n\nКуплет два",
+				Link:        "http://example.com",
+			}},
+			wantStatus: models.EnrichmentDone,
+			wantURL:    "http://example.com",
+		},
+		{
+			name:       "Upstream fails",
+			details:    stubDetails{err: errors.New("unavailable")},
+			wantStatus: models.EnrichmentPending,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := repository.NewMemory()
+			handler := router.NewRouter(repos, tt.details, nil, nil)
+
+			w := doRequest(t, handler, http.MethodPost, "/songs", models.SongInput{Group: " Muse ", Song: "Supermassive  Black Hole"})
+			require.Equal(t, http.StatusOK, w.Code)
+
+			var created models.SongDetail
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+			assert.Equal(t, "Muse", created.GroupName)
+			assert.Equal(t, "Supermassive Black Hole", created.SongName)
+			assert.Equal(t, tt.wantStatus, created.EnrichmentStatus)
+
+			stored, err := repos.Songs.GetByID(context.Background(), created.ID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, stored.EnrichmentStatus)
+			assert.Equal(t, tt.wantURL, stored.SongURL)
+		})
+	}
+}
+
+func TestSongsAPI(t *testing.T) {
+	handler := router.NewRouter(repository.NewMemory(), nil, nil, nil)
+
+	for _, input := range []models.SongInput{
+		{Group: "Любэ", Song: "Конь"},
+		{Group: "Любэ", Song: "Комбат"},
+		{Group: "Muse", Song: "Uprising"},
+	} {
+		require.Equal(t, http.StatusOK, doRequest(t, handler, http.MethodPost, "/songs", input).Code)
+	}
+
+	w := doRequest(t, handler, http.MethodPost, "/songs", models.SongInput{Group: "Любэ", Song: "Конь"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = doRequest(t, handler, http.MethodGet, "/songs?field=artist_name&value=любэ", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var list models.SongsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Len(t, list.Songs, 2)
+
+	w = doRequest(t, handler, http.MethodGet, "/songs?field=unknown&value=x", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	update := models.SongUpdateResponse{
+		ReleaseDate: "1994.01.01",
+		Text:        models.SongText{Verses: []string{"Выйду ночью в поле с конём", "Ночью в поле звёзд благодать"}},
+	}
+	w = doRequest(t, handler, http.MethodPut, "/songs/"+url.PathEscape("Конь"), update)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = doRequest(t, handler, http.MethodGet, "/songs/"+url.PathEscape("Конь")+"/lyrics?verse_page=2&verse_limit=1&format=legacy", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var lyrics models.PaginatedLyricsRespons
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &lyrics))
+	assert.Equal(t, 2, lyrics.TotalVerses)
+	assert.Equal(t, []string{"Ночью в поле звёзд благодать"}, lyrics.Verses)
+
+	w = doRequest(t, handler, http.MethodDelete, "/songs/"+url.PathEscape("Конь"), nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = doRequest(t, handler, http.MethodDelete, "/songs/"+url.PathEscape("Конь"), nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestSongsByID(t *testing.T) {
+	handler := router.NewRouter(repository.NewMemory(), nil, nil, nil)
+
+	for _, input := range []models.SongInput{
+		{Group: "Любэ", Song: "Комбат"},
+		{Group: "Ляпис Трубецкой", Song: "Комбат"},
+	} {
+		require.Equal(t, http.StatusOK, doRequest(t, handler, http.MethodPost, "/songs", input).Code)
+	}
+
+	w := doRequest(t, handler, http.MethodGet, "/songs/"+url.PathEscape("Комбат"), nil)
+	require.Equal(t, http.StatusConflict, w.Code)
+	var ambiguous models.AmbiguousSongResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &ambiguous))
+	assert.Equal(t, []uint{1, 2}, ambiguous.CandidateIDs)
+
+	w = doRequest(t, handler, http.MethodDelete, "/songs/"+url.PathEscape("Комбат"), nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = doRequest(t, handler, http.MethodGet, "/songs/2", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var song models.SongDetail
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &song))
+	assert.Equal(t, "Ляпис Трубецкой", song.GroupName)
+
+	update := models.SongUpdateResponse{Text: models.SongText{Verses: []string{"Батяня комбат"}}}
+	require.Equal(t, http.StatusOK, doRequest(t, handler, http.MethodPut, "/songs/1", update).Code)
+
+	w = doRequest(t, handler, http.MethodGet, "/songs/1/lyrics?format=legacy", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var lyrics models.PaginatedLyricsRespons
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &lyrics))
+	assert.Equal(t, []string{"Батяня комбат"}, lyrics.Verses)
+
+	assert.Equal(t, http.StatusNoContent, doRequest(t, handler, http.MethodDelete, "/songs/1", nil).Code)
+	assert.Equal(t, http.StatusNotFound, doRequest(t, handler, http.MethodGet, "/songs/1", nil).Code)
+
+	// После удаления название снова однозначно
+	assert.Equal(t, http.StatusOK, doRequest(t, handler, http.MethodGet, "/songs/"+url.PathEscape("Комбат"), nil).Code)
+}
+
+func TestSongsByName_DigitTitle(t *testing.T) {
+	handler := router.NewRouter(repository.NewMemory(), nil, nil, nil)
+
+	for _, input := range []models.SongInput{
+		{Group: "Кино", Song: "Кукушка"},
+		{Group: "The Smashing Pumpkins", Song: "1979"},
+	} {
+		require.Equal(t, http.StatusOK, doRequest(t, handler, http.MethodPost, "/songs", input).Code)
+	}
+
+	// Короткий маршрут принимает название из цифр за ID
+	assert.Equal(t, http.StatusNotFound, doRequest(t, handler, http.MethodGet, "/songs/1979", nil).Code)
+
+	w := doRequest(t, handler, http.MethodGet, "/songs/by-name/1979", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var song models.SongDetail
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &song))
+	assert.Equal(t, uint(2), song.ID)
+	assert.Equal(t, "The Smashing Pumpkins", song.GroupName)
+
+	update := models.SongUpdateResponse{Text: models.SongText{Verses: []string{"Shakedown 1979"}}}
+	require.Equal(t, http.StatusOK, doRequest(t, handler, http.MethodPut, "/songs/by-name/1979", update).Code)
+
+	w = doRequest(t, handler, http.MethodGet, "/songs/by-name/1979/lyrics?format=legacy", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var lyrics models.PaginatedLyricsRespons
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &lyrics))
+	assert.Equal(t, []string{"Shakedown 1979"}, lyrics.Verses)
+
+	// Прежние названия доступны и через новый маршрут
+	assert.Equal(t, http.StatusOK, doRequest(t, handler, http.MethodGet, "/songs/by-name/"+url.PathEscape("Кукушка"), nil).Code)
+
+	assert.Equal(t, http.StatusNoContent, doRequest(t, handler, http.MethodDelete, "/songs/by-name/1979", nil).Code)
+	assert.Equal(t, http.StatusNotFound, doRequest(t, handler, http.MethodGet, "/songs/2", nil).Code)
+}
+
+func TestArtistsAPI(t *testing.T) {
+	handler := router.NewRouter(repository.NewMemory(), nil, nil, nil)
+
+	w := doRequest(t, handler, http.MethodPost, "/artists", models.ArtistInput{Name: " Любэ "})
+	require.Equal(t, http.StatusCreated, w.Code)
+	var artist models.Artist
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &artist))
+	assert.Equal(t, "Любэ", artist.Name)
+
+	assert.Equal(t, http.StatusConflict, doRequest(t, handler, http.MethodPost, "/artists", models.ArtistInput{Name: "Любэ"}).Code)
+	assert.Equal(t, http.StatusBadRequest, doRequest(t, handler, http.MethodPost, "/artists", models.ArtistInput{}).Code)
+	require.Equal(t, http.StatusCreated, doRequest(t, handler, http.MethodPost, "/artists", models.ArtistInput{Name: "Muse"}).Code)
+
+	// Песни добавляются к уже существующему исполнителю
+	require.Equal(t, http.StatusOK, doRequest(t, handler, http.MethodPost, "/songs", models.SongInput{Group: "Любэ", Song: "Конь"}).Code)
+	require.Equal(t, http.StatusOK, doRequest(t, handler, http.MethodPost, "/songs", models.SongInput{Group: "Любэ", Song: "Комбат"}).Code)
+
+	w = doRequest(t, handler, http.MethodGet, "/artists?limit=1&page=2", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var artists models.ArtistsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &artists))
+	assert.Equal(t, int64(2), artists.TotalItems)
+	require.Len(t, artists.Artists, 1)
+	assert.Equal(t, "Muse", artists.Artists[0].Name)
+
+	w = doRequest(t, handler, http.MethodPut, "/artists/1", models.ArtistInput{Name: "Группа Любэ"})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, http.StatusConflict, doRequest(t, handler, http.MethodPut, "/artists/1", models.ArtistInput{Name: "Muse"}).Code)
+
+	w = doRequest(t, handler, http.MethodGet, "/artists/1/songs", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var songs models.SongsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &songs))
+	require.Len(t, songs.Songs, 2)
+	for _, song := range songs.Songs {
+		assert.Equal(t, "Группа Любэ", song.GroupName)
+	}
+
+	assert.Equal(t, http.StatusConflict, doRequest(t, handler, http.MethodDelete, "/artists/1", nil).Code)
+	assert.Equal(t, http.StatusNoContent, doRequest(t, handler, http.MethodDelete, "/artists/1?cascade=true", nil).Code)
+	assert.Equal(t, http.StatusNotFound, doRequest(t, handler, http.MethodGet, "/artists/1", nil).Code)
+	assert.Equal(t, http.StatusNotFound, doRequest(t, handler, http.MethodGet, "/songs/1", nil).Code)
+	assert.Equal(t, http.StatusNoContent, doRequest(t, handler, http.MethodDelete, "/artists/2", nil).Code)
+}
+
+func TestAlbumsAPI(t *testing.T) {
+	handler := router.NewRouter(repository.NewMemory(), nil, nil, nil)
+
+	for _, input := range []models.SongInput{
+		{Group: "Muse", Song: "Take A Bow"},
+		{Group: "Muse", Song: "Starlight"},
+		{Group: "Muse", Song: "Supermassive Black Hole"},
+	} {
+		require.Equal(t, http.StatusOK, doRequest(t, handler, http.MethodPost, "/songs", input).Code)
+	}
+
+	album := models.AlbumInput{
+		Title:       "Black Holes and Revelations",
+		ReleaseDate: "2006-07-03",
+		ArtistID:    1,
+		Tracks: []models.AlbumTrackInput{
+			{SongID: 3, TrackNumber: 3},
+			{SongID: 1, TrackNumber: 1},
+			{SongID: 2, TrackNumber: 2},
+		},
+	}
+	w := doRequest(t, handler, http.MethodPost, "/albums", album)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	single := models.AlbumInput{Title: "Starlight", Type: models.AlbumTypeSingle, ArtistID: 1, Tracks: []models.AlbumTrackInput{{SongID: 2, TrackNumber: 1}}}
+	require.Equal(t, http.StatusCreated, doRequest(t, handler, http.MethodPost, "/albums", single).Code)
+
+	invalid := models.AlbumInput{Title: "Unknown", ArtistID: 1, Tracks: []models.AlbumTrackInput{{SongID: 42, TrackNumber: 1}}}
+	assert.Equal(t, http.StatusBadRequest, doRequest(t, handler, http.MethodPost, "/albums", invalid).Code)
+
+	w = doRequest(t, handler, http.MethodGet, "/albums/1", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var stored models.Album
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stored))
+	require.Len(t, stored.Tracks, 3)
+	assert.Equal(t, uint(1), stored.Tracks[0].SongID)
+	assert.Equal(t, models.AlbumTypeAlbum, stored.Type)
+
+	w = doRequest(t, handler, http.MethodGet, "/songs?album_id=1", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var songs models.SongsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &songs))
+	require.Len(t, songs.Songs, 3)
+	assert.Equal(t, "Take A Bow", songs.Songs[0].SongName)
+	assert.Equal(t, "Supermassive Black Hole", songs.Songs[2].SongName)
+
+	// Удалённая песня пропадает из обоих релизов
+	require.Equal(t, http.StatusNoContent, doRequest(t, handler, http.MethodDelete, "/songs/2", nil).Code)
+	w = doRequest(t, handler, http.MethodGet, "/albums?artist_id=1", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var albums models.AlbumsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &albums))
+	require.Len(t, albums.Albums, 2)
+	assert.Len(t, albums.Albums[0].Tracks, 2)
+	assert.Empty(t, albums.Albums[1].Tracks)
+
+	album.Tracks = []models.AlbumTrackInput{{SongID: 1, TrackNumber: 1}}
+	require.Equal(t, http.StatusOK, doRequest(t, handler, http.MethodPut, "/albums/1", album).Code)
+	assert.Equal(t, http.StatusNotFound, doRequest(t, handler, http.MethodPut, "/albums/9", album).Code)
+
+	assert.Equal(t, http.StatusNoContent, doRequest(t, handler, http.MethodDelete, "/albums/2", nil).Code)
+	assert.Equal(t, http.StatusNotFound, doRequest(t, handler, http.MethodGet, "/albums/2", nil).Code)
+	assert.Equal(t, http.StatusBadRequest, doRequest(t, handler, http.MethodGet, "/songs?album_id=x", nil).Code)
+}
+
+func TestPlaylistsAPI(t *testing.T) {
+	handler := router.NewRouter(repository.NewMemory(), nil, nil, nil)
+
+	for _, input := range []models.SongInput{
+		{Group: "Кино", Song: "Группа крови"},
+		{Group: "Кино", Song: "Кукушка"},
+		{Group: "Кино", Song: "Звезда по имени Солнце"},
+	} {
+		require.Equal(t, http.StatusOK, doRequest(t, handler, http.MethodPost, "/songs", input).Code)
+	}
+
+	require.Equal(t, http.StatusCreated, doRequest(t, handler, http.MethodPost, "/playlists", models.PlaylistInput{Name: "Дорога", Owner: "anna"}).Code)
+	assert.Equal(t, http.StatusBadRequest, doRequest(t, handler, http.MethodPost, "/playlists", models.PlaylistInput{}).Code)
+
+	// Песня без позиции добавляется в конец, с позицией — сдвигает остальные
+	require.Equal(t, http.StatusCreated, doRequest(t, handler, http.MethodPost, "/playlists/1/songs", models.PlaylistSongInput{SongID: 1}).Code)
+	require.Equal(t, http.StatusCreated, doRequest(t, handler, http.MethodPost, "/playlists/1/songs", models.PlaylistSongInput{SongID: 2}).Code)
+	w := doRequest(t, handler, http.MethodPost, "/playlists/1/songs", models.PlaylistSongInput{SongID: 3, Position: 1})
+	require.Equal(t, http.StatusCreated, w.Code)
+	var item models.PlaylistItem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &item))
+	assert.Equal(t, 1, item.Position)
+
+	assert.Equal(t, http.StatusConflict, doRequest(t, handler, http.MethodPost, "/playlists/1/songs", models.PlaylistSongInput{SongID: 1}).Code)
+	assert.Equal(t, http.StatusBadRequest, doRequest(t, handler, http.MethodPost, "/playlists/1/songs", models.PlaylistSongInput{SongID: 42}).Code)
+	assert.Equal(t, http.StatusNotFound, doRequest(t, handler, http.MethodPost, "/playlists/9/songs", models.PlaylistSongInput{SongID: 1}).Code)
+
+	w = doRequest(t, handler, http.MethodPut, "/playlists/1/songs/3/position", models.PlaylistSongInput{Position: 3})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, http.StatusBadRequest, doRequest(t, handler, http.MethodPut, "/playlists/1/songs/3/position", models.PlaylistSongInput{}).Code)
+
+	positions := func() []uint {
+		w := doRequest(t, handler, http.MethodGet, "/playlists/1", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var playlist models.PlaylistResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &playlist))
+		ids := make([]uint, 0, len(playlist.Items))
+		for i, entry := range playlist.Items {
+			assert.Equal(t, i+1, entry.Position)
+			ids = append(ids, entry.Song.ID)
+		}
+		return ids
+	}
+	assert.Equal(t, []uint{1, 2, 3}, positions())
+
+	// Удалённая песня пропадает из плейлиста без пропуска в нумерации
+	require.Equal(t, http.StatusNoContent, doRequest(t, handler, http.MethodDelete, "/songs/1", nil).Code)
+	assert.Equal(t, []uint{2, 3}, positions())
+
+	assert.Equal(t, http.StatusNoContent, doRequest(t, handler, http.MethodDelete, "/playlists/1/songs/2", nil).Code)
+	assert.Equal(t, http.StatusNotFound, doRequest(t, handler, http.MethodDelete, "/playlists/1/songs/2", nil).Code)
+	assert.Equal(t, []uint{3}, positions())
+
+	w = doRequest(t, handler, http.MethodGet, "/playlists?owner=anna", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var playlists models.PlaylistsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &playlists))
+	assert.EqualValues(t, 1, playlists.TotalItems)
+
+	require.Equal(t, http.StatusOK, doRequest(t, handler, http.MethodPut, "/playlists/1", models.PlaylistInput{Name: "Ночь"}).Code)
+	assert.Equal(t, http.StatusNoContent, doRequest(t, handler, http.MethodDelete, "/playlists/1", nil).Code)
+	assert.Equal(t, http.StatusNotFound, doRequest(t, handler, http.MethodGet, "/playlists/1", nil).Code)
+}
+
+func TestSearchAPI(t *testing.T) {
+	handler := router.NewRouter(repository.NewMemory(), nil, nil, nil)
+
+	for _, input := range []models.SongInput{
+		{Group: "Кино", Song: "Группа крови"},
+		{Group: "Muse", Song: "Starlight"},
+		{Group: "Звери", Song: "Районы-кварталы"},
+	} {
+		require.Equal(t, http.StatusOK, doRequest(t, handler, http.MethodPost, "/songs", input).Code)
+	}
+
+	update := models.SongUpdateResponse{Text: models.SongText{Verses: []string{
+		"Тёплое место, но улицы ждут",
+		"Группа крови на рукаве,\nМой порядковый номер на рукаве",
+	}}}
+	require.Equal(t, http.StatusOK, doRequest(t, handler, http.MethodPut, "/songs/1", update).Code)
+	update = models.SongUpdateResponse{Text: models.SongText{Verses: []string{"Far away, this ship is taking me far away"}}}
+	require.Equal(t, http.StatusOK, doRequest(t, handler, http.MethodPut, "/songs/2", update).Code)
+
+	search := func(query string) models.SearchResponse {
+		w := doRequest(t, handler, http.MethodGet, "/search?q="+url.QueryEscape(query), nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var response models.SearchResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+
+	// Найденный куплет возвращается с номером и выделенным фрагментом
+	response := search("рукаве")
+	require.EqualValues(t, 1, response.TotalItems)
+	require.NotNil(t, response.Results[0].VerseIndex)
+	assert.Equal(t, 1, *response.Results[0].VerseIndex)
+	assert.Contains(t, response.Results[0].Snippet, "<mark>рукаве</mark>")
+
+	response = search("SHIP")
+	require.Len(t, response.Results, 1)
+	assert.Equal(t, "Starlight", response.Results[0].Song.SongName)
+	assert.Contains(t, response.Results[0].Snippet, "<mark>ship</mark>")
+
+	// Совпадение только по исполнителю — без куплета
+	response = search("звери")
+	require.Len(t, response.Results, 1)
+	assert.Nil(t, response.Results[0].VerseIndex)
+	assert.Empty(t, response.Results[0].Snippet)
+
+	// Обновление текста сразу попадает в индекс
+	update = models.SongUpdateResponse{Text: models.SongText{Verses: []string{"Закрытые окна"}}}
+	require.Equal(t, http.StatusOK, doRequest(t, handler, http.MethodPut, "/songs/1", update).Code)
+	assert.Empty(t, search("рукаве").Results)
+
+	assert.Equal(t, http.StatusBadRequest, doRequest(t, handler, http.MethodGet, "/search?q=+", nil).Code)
+}
+
+func TestGetSongs_InvalidParams(t *testing.T) {
+	handler := router.NewRouter(repository.NewMemory(), nil, nil, nil)
+
+	for _, target := range []string{
+		"/songs?release_from=2020.01.01",
+		"/songs?created_to=yesterday",
+		"/songs?has_lyrics=maybe",
+		"/songs?sort=-rating",
+		"/songs?artist_id=abc",
+		"/songs?field=release_date&value=01.01.2020",
+		"/songs?release_from=2021-01-01&release_to=2020-01-01",
+	} {
+		w := doRequest(t, handler, http.MethodGet, target, nil)
+		require.Equal(t, http.StatusBadRequest, w.Code, target)
+
+		param := strings.SplitN(strings.TrimPrefix(target, "/songs?"), "=", 2)[0]
+		if param == "field" {
+			param = "value"
+		}
+		assert.Contains(t, w.Body.String(), "invalid "+param, target)
+	}
+
+	w := doRequest(t, handler, http.MethodGet, "/songs?title=a&has_lyrics=false&sort=-release_date,title&created_to=2030-01-01", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestGetSongs_Pagination(t *testing.T) {
+	handler := router.NewRouter(repository.NewMemory(), nil, nil, nil)
+
+	for i := 1; i <= 5; i++ {
+		input := models.SongInput{Group: "Кино", Song: fmt.Sprintf("Песня %d", i)}
+		require.Equal(t, http.StatusOK, doRequest(t, handler, http.MethodPost, "/songs", input).Code)
+	}
+
+	list := func(target string) models.SongsResponse {
+		w := doRequest(t, handler, http.MethodGet, target, nil)
+		require.Equal(t, http.StatusOK, w.Code, target)
+		var response models.SongsResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+
+	response := list("/songs?limit=2&page=2")
+	require.NotNil(t, response.TotalItems)
+	assert.EqualValues(t, 5, *response.TotalItems)
+	assert.Equal(t, 3, *response.TotalPages)
+	assert.True(t, response.HasNext)
+	assert.Len(t, response.Songs, 2)
+
+	response = list("/songs?limit=2&page=3&count=false")
+	assert.Nil(t, response.TotalItems)
+	assert.Nil(t, response.TotalPages)
+	assert.False(t, response.HasNext)
+	assert.Len(t, response.Songs, 1)
+
+	// Слишком большие limit и page ограничиваются, а не переполняются
+	response = list("/songs?limit=" + strconv.Itoa(math.MaxInt) + "&page=" + strconv.Itoa(math.MaxInt))
+	assert.Equal(t, 100, response.Limit)
+	assert.Equal(t, 1, *response.TotalPages)
+	assert.Empty(t, response.Songs)
+	response = list("/songs?limit=9223372036854775807")
+	assert.Equal(t, 100, response.Limit)
+	assert.Len(t, response.Songs, 5)
+
+	// Обход по курсору не пропускает песни, даже если перед текущей позицией удалили песню
+	var titles []string
+	target := "/songs?limit=2&sort=-title"
+	for {
+		response = list(target)
+		for _, song := range response.Songs {
+			titles = append(titles, song.SongName)
+		}
+		if !response.HasNext {
+			break
+		}
+		require.NotEmpty(t, response.NextCursor)
+		if len(titles) == 2 {
+			require.Equal(t, http.StatusNoContent, doRequest(t, handler, http.MethodDelete, "/songs/5", nil).Code)
+		}
+		target = "/songs?limit=2&sort=-title&after=" + url.QueryEscape(response.NextCursor)
+	}
+	assert.Equal(t, []string{"Песня 5", "Песня 4", "Песня 3", "Песня 2", "Песня 1"}, titles)
+
+	// Курсор привязан к сортировке
+	response = list("/songs?limit=2&sort=title")
+	w := doRequest(t, handler, http.MethodGet, "/songs?limit=2&after="+url.QueryEscape(response.NextCursor), nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid after")
+	assert.Equal(t, http.StatusBadRequest, doRequest(t, handler, http.MethodGet, "/songs?after=garbage", nil).Code)
+	assert.Equal(t, http.StatusBadRequest, doRequest(t, handler, http.MethodGet, "/songs?count=maybe", nil).Code)
+}
+
+func TestUploadSongLyrics(t *testing.T) {
+	handler := router.NewRouter(repository.NewMemory(), nil, nil, nil)
+	require.Equal(t, http.StatusOK, doRequest(t, handler, http.MethodPost, "/songs", models.SongInput{Group: "Любэ", Song: "Конь"}).Code)
+
+	text := "Выйду ночью в поле с конём,\nНочкой тёмной тихо пойдём.\n\nМы пойдём с конём по полю вдвоём(х3)\n"
+
+	w := doTextRequest(handler, http.MethodPost, "/songs/1/lyrics?keep_line_breaks=true&trim_repeats=true", "text/plain; charset=utf-8", text)
+	require.Equal(t, http.StatusOK, w.Code)
+	var stored models.SongText
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stored))
+	assert.Equal(t, []string{"Выйду ночью в поле с конём,\nНочкой тёмной тихо пойдём.", "Мы пойдём с конём по полю вдвоём"}, stored.FlatVerses())
+
+	w = doRequest(t, handler, http.MethodGet, "/songs/1/lyrics?format=legacy", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var lyrics models.PaginatedLyricsRespons
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &lyrics))
+	assert.Equal(t, stored.FlatVerses(), lyrics.Verses)
+
+	// По умолчанию строки разделов сохраняются, пометки о повторе сохраняются числом повторов
+	w = doTextRequest(handler, http.MethodPost, "/songs/1/lyrics", "text/plain", text)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stored))
+	require.Len(t, stored.Sections, 2)
+	assert.Len(t, stored.Sections[0].Lines, 2)
+	assert.Equal(t, 3, stored.Sections[1].Lines[0].Repeat)
+
+	// keep_line_breaks=false склеивает строки раздела через пробел
+	w = doTextRequest(handler, http.MethodPost, "/songs/1/lyrics?keep_line_breaks=false", "text/plain", text)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stored))
+	assert.Equal(t, []string{"Выйду ночью в поле с конём, Ночкой тёмной тихо пойдём.", "Мы пойдём с конём по полю вдвоём"}, stored.FlatVerses())
+
+	assert.Equal(t, http.StatusUnsupportedMediaType, doTextRequest(handler, http.MethodPost, "/songs/1/lyrics", "application/json", `"text"`).Code)
+	assert.Equal(t, http.StatusBadRequest, doTextRequest(handler, http.MethodPost, "/songs/1/lyrics", "text/plain", "\n\n").Code)
+	assert.Equal(t, http.StatusBadRequest, doTextRequest(handler, http.MethodPost, "/songs/1/lyrics?trim_repeats=maybe", "text/plain", text).Code)
+	assert.Equal(t, http.StatusNotFound, doTextRequest(handler, http.MethodPost, "/songs/9/lyrics", "text/plain", text).Code)
+}
+
+func TestGetSongLyrics_Repeats(t *testing.T) {
+	handler := router.NewRouter(repository.NewMemory(), nil, nil, nil)
+	require.Equal(t, http.StatusOK, doRequest(t, handler, http.MethodPost, "/songs", models.SongInput{Group: "Любэ", Song: "Конь"}).Code)
+
+	text := "Ночью в поле звёзд благодать,\nПо полю идём (x2)\n\nСяду я верхом на коня\n(х2)\n"
+	require.Equal(t, http.StatusOK, doTextRequest(handler, http.MethodPost, "/songs/1/lyrics?keep_line_breaks=true", "text/plain", text).Code)
+
+	get := func(target string) models.PaginatedLyricsRespons {
+		w := doRequest(t, handler, http.MethodGet, target, nil)
+		require.Equal(t, http.StatusOK, w.Code, target)
+		var response models.PaginatedLyricsRespons
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+
+	compact := get("/songs/1/lyrics")
+	assert.False(t, compact.Expanded)
+	assert.Empty(t, compact.Verses)
+	require.Len(t, compact.Sections, 2)
+	assert.Equal(t, 2, compact.Sections[0].Lines[1].Repeat)
+	assert.Equal(t, 2, compact.Sections[1].Repeat)
+
+	legacy := get("/songs/1/lyrics?format=legacy")
+	assert.Equal(t, models.LyricsFormatLegacy, legacy.Format)
+	assert.Empty(t, legacy.Sections)
+	assert.Equal(t, []string{"Ночью в поле звёзд благодать,\nПо полю идём", "Сяду я верхом на коня"}, legacy.Verses)
+
+	expanded := get("/songs/1/lyrics?expand=true&verse_limit=5")
+	assert.True(t, expanded.Expanded)
+	require.Len(t, expanded.Sections, 3)
+	assert.Zero(t, expanded.Sections[2].Repeat)
+	assert.Len(t, expanded.Sections[0].Lines, 3)
+
+	expanded = get("/songs/1/lyrics?expand=true&verse_limit=5&format=legacy")
+	assert.Equal(t, 3, expanded.TotalVerses)
+	assert.Equal(t, []string{
+		"Ночью в поле звёзд благодать,\nПо полю идём\nПо полю идём",
+		"Сяду я верхом на коня",
+		"Сяду я верхом на коня",
+	}, expanded.Verses)
+
+	// Текст, сохранённый до появления структуры, разбирается при чтении
+	update := models.SongUpdateResponse{Text: models.SongText{Verses: []string{"Мы пойдём с конём по полю вдвоём(х3)"}}}
+	require.Equal(t, http.StatusOK, doRequest(t, handler, http.MethodPut, "/songs/1", update).Code)
+	compact = get("/songs/1/lyrics")
+	assert.Equal(t, 3, compact.Sections[0].Lines[0].Repeat)
+	legacy = get("/songs/1/lyrics?format=legacy")
+	assert.Equal(t, []string{"Мы пойдём с конём по полю вдвоём"}, legacy.Verses)
+
+	assert.Equal(t, http.StatusBadRequest, doRequest(t, handler, http.MethodGet, "/songs/1/lyrics?expand=maybe", nil).Code)
+	assert.Equal(t, http.StatusBadRequest, doRequest(t, handler, http.MethodGet, "/songs/1/lyrics?format=flat", nil).Code)
+}
+
+func TestGetSongLyrics_Sections(t *testing.T) {
+	handler := router.NewRouter(repository.NewMemory(), nil, nil, nil)
+	require.Equal(t, http.StatusOK, doRequest(t, handler, http.MethodPost, "/songs", models.SongInput{Group: "Кино", Song: "Кукушка"}).Code)
+
+	text := "[Куплет 1]\nПесен ещё ненаписанных сколько?\nСкажи, кукушка, пропой\n\n[Припев]\nСолнце моё, взгляни на меня\n\n[Bridge]\nА-а-а"
+	require.Equal(t, http.StatusOK, doTextRequest(handler, http.MethodPost, "/songs/1/lyrics", "text/plain", text).Code)
+
+	w := doRequest(t, handler, http.MethodGet, "/songs/1/lyrics?verse_page=2&verse_limit=2", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var response models.PaginatedLyricsRespons
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, models.LyricsFormatSections, response.Format)
+	assert.Equal(t, 3, response.TotalVerses)
+	assert.Equal(t, []lyrics.Section{
+		{Type: lyrics.SectionBridge, Label: "Bridge", Lines: []lyrics.Line{{Text: "А-а-а"}}},
+	}, response.Sections)
+
+	// Разделы можно прислать целиком; тип по умолчанию - куплет
+	update := models.SongUpdateResponse{Text: models.SongText{Sections: []lyrics.Section{
+		{Lines: []lyrics.Line{{Text: "Песен ещё ненаписанных сколько?"}}},
+		{Type: lyrics.SectionChorus, Label: "Припев", Lines: []lyrics.Line{{Text: "Солнце моё, взгляни на меня"}}, Repeat: 2},
+	}}}
+	w = doRequest(t, handler, http.MethodPut, "/songs/1", update)
+	require.Equal(t, http.StatusOK, w.Code)
+	var updated models.SongUpdateResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &updated))
+	assert.Equal(t, lyrics.SectionVerse, updated.Text.Sections[0].Type)
+	assert.Empty(t, updated.Text.Verses)
+
+	invalid := models.SongUpdateResponse{Text: models.SongText{Sections: []lyrics.Section{
+		{Type: "solo", Lines: []lyrics.Line{{Text: "Соло"}}},
+	}}}
+	assert.Equal(t, http.StatusBadRequest, doRequest(t, handler, http.MethodPut, "/songs/1", invalid).Code)
+}
+
+func TestSongLyricsLRC(t *testing.T) {
+	handler := router.NewRouter(repository.NewMemory(), nil, nil, nil)
+	require.Equal(t, http.StatusOK, doRequest(t, handler, http.MethodPost, "/songs", models.SongInput{Group: "Любэ", Song: "Конь"}).Code)
+
+	lrc := "[ar:Любэ]\n[ti:Конь]\n[00:10.00]Выйду ночью в поле с конём\n[00:15.50]Ночкой тёмной тихо пойдём\n\n" +
+		"[Припев]\n[00:80.00]Мы пойдём с конём\n"
+	assert.Equal(t, http.StatusBadRequest, doTextRequest(handler, http.MethodPost, "/songs/1/lyrics/lrc", "text/plain", lrc).Code)
+
+	// Без меток времени синхронизированного текста нет
+	assert.Equal(t, http.StatusNotFound, doRequest(t, handler, http.MethodGet, "/songs/1/lyrics/at?position=1s", nil).Code)
+	assert.Equal(t, http.StatusBadRequest, doTextRequest(handler, http.MethodPost, "/songs/1/lyrics/lrc", "text/plain", "Просто текст").Code)
+
+	lrc = strings.Replace(lrc, "[00:80.00]", "[01:20.00][02:40.00]", 1)
+	w := doTextRequest(handler, http.MethodPost, "/songs/1/lyrics/lrc", "text/plain; charset=utf-8", lrc)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var stored models.SongText
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stored))
+	require.Len(t, stored.Sections, 2)
+	assert.Equal(t, []int64{80000, 160000}, stored.Sections[1].Lines[0].Times)
+
+	at := func(query string) models.LyricsAtResponse {
+		w := doRequest(t, handler, http.MethodGet, "/songs/1/lyrics/at?"+query, nil)
+		require.Equal(t, http.StatusOK, w.Code, query)
+		var response models.LyricsAtResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+
+	response := at("position=12.5s&next=1")
+	assert.EqualValues(t, 12500, response.Position)
+	require.NotNil(t, response.Current)
+	assert.Equal(t, "Выйду ночью в поле с конём", response.Current.Text)
+	assert.Equal(t, []lyrics.TimedLine{{Time: 15500, Text: "Ночкой тёмной тихо пойдём"}}, response.Next)
+
+	response = at("position=1:25")
+	assert.Equal(t, "Мы пойдём с конём", response.Current.Text)
+	assert.Equal(t, 1, response.Current.Section)
+	require.Len(t, response.Next, 1)
+	assert.EqualValues(t, 160000, response.Next[0].Time)
+
+	assert.Nil(t, at("position=3").Current)
+
+	w = doRequest(t, handler, http.MethodGet, "/songs/1/lyrics/lrc", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "attachment")
+	assert.Equal(t, "[ar:Любэ]\n[ti:Конь]\n\n[00:10.00]Выйду ночью в поле с конём\n[00:15.50]Ночкой тёмной тихо пойдём\n\n"+
+		"[01:20.00][02:40.00]Мы пойдём с конём\n", w.Body.String())
+
+	for _, query := range []string{"", "position=soon", "position=-5s", "position=1:75", "position=4000000000:00", "position=1440:00.5", "position=1s&next=-1"} {
+		assert.Equal(t, http.StatusBadRequest, doRequest(t, handler, http.MethodGet, "/songs/1/lyrics/at?"+query, nil).Code, query)
+	}
+	assert.Equal(t, http.StatusUnsupportedMediaType, doTextRequest(handler, http.MethodPost, "/songs/1/lyrics/lrc", "application/json", lrc).Code)
+	assert.Equal(t, http.StatusNotFound, doRequest(t, handler, http.MethodGet, "/songs/9/lyrics/lrc", nil).Code)
+}
+
+func TestCatalogExportImport(t *testing.T) {
+	source := router.NewRouter(repository.NewMemory(), nil, nil, nil)
+	for _, input := range []models.SongInput{{Group: "Любэ", Song: "Конь"}, {Group: "Кино", Song: "Кукушка"}} {
+		require.Equal(t, http.StatusOK, doRequest(t, source, http.MethodPost, "/songs", input).Code)
+	}
+	require.Equal(t, http.StatusCreated, doRequest(t, source, http.MethodPost, "/artists", models.Artist{Name: "Звери"}).Code)
+	update := models.SongUpdateResponse{ReleaseDate: "1994.01.01", GroupLink: "http://example.com/kon", Text: models.SongText{Verses: []string{"Выйду ночью в поле с конём,\nНочкой тёмной тихо пойдём"}}}
+	require.Equal(t, http.StatusOK, doRequest(t, source, http.MethodPut, "/songs/1", update).Code)
+
+	for format, contentType := range map[string]string{"json": "application/json", "csv": "text/csv", "ndjson": "application/x-ndjson"} {
+		t.Run(format, func(t *testing.T) {
+			w := doRequest(t, source, http.MethodGet, "/export?format="+format, nil)
+			require.Equal(t, http.StatusOK, w.Code)
+			assert.True(t, strings.HasPrefix(w.Header().Get("Content-Type"), contentType))
+			exported := w.Body.String()
+
+			target := router.NewRouter(repository.NewMemory(), nil, nil, nil)
+			importCatalog := func(query string) models.ImportReport {
+				w := doTextRequest(target, http.MethodPost, "/import"+query, contentType, exported)
+				require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+				var report models.ImportReport
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+				return report
+			}
+
+			// Пробный импорт ничего не сохраняет
+			report := importCatalog("?dry_run=true")
+			assert.True(t, report.DryRun)
+			assert.Equal(t, 5, report.Created)
+			assert.Equal(t, http.StatusNotFound, doRequest(t, target, http.MethodGet, "/songs/1", nil).Code)
+
+			report = importCatalog("")
+			assert.Equal(t, 5, report.Created)
+			assert.Len(t, report.Rows, 5)
+
+			w = doRequest(t, target, http.MethodGet, "/songs/"+url.PathEscape("Конь")+"/lyrics?format=legacy", nil)
+			require.Equal(t, http.StatusOK, w.Code)
+			var lyrics models.PaginatedLyricsRespons
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &lyrics))
+			assert.Equal(t, update.Text.Verses, lyrics.Verses)
+			assert.Equal(t, exported, doRequest(t, target, http.MethodGet, "/export?format="+format, nil).Body.String())
+
+			// Повторный импорт того же каталога ничего не меняет
+			report = importCatalog("")
+			assert.Equal(t, 5, report.Skipped)
+		})
+	}
+
+	ndjson := `{"artist":"  Любэ ","song":" Конь","release_date":"1995-02-02"}
+{"artist":"Любэ","song":"Комбат","release_date":"02.02.1995"}
+{"artist":"","song":"Без исполнителя"}
+{"artist":42}
+{"artist":"Любэ","song":"Атас","text":{"sections":[{"type":"solo","lines":[{"text":"Атас"}]}]}}
+{"artist":"Любэ","song":"Позови меня тихо по имени"}
+{"artist":"` + strings.Repeat("Я", 256) + `","song":"Длинное имя"}
+{"artist":"Любэ","song":"Длинная ссылка","link":"http://example.com/` + strings.Repeat("a", 256) + `"}
+`
+	w := doTextRequest(source, http.MethodPost, "/import?format=ndjson", "text/plain", ndjson)
+	require.Equal(t, http.StatusOK, w.Code)
+	var report models.ImportReport
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.Equal(t, 1, report.Updated)
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 6, report.Failed)
+	assert.Equal(t, "Любэ", report.Rows[0].Artist)
+	assert.Equal(t, models.ImportFailed, report.Rows[1].Status)
+	assert.Contains(t, report.Rows[1].Error, "release_date")
+	// Слишком длинные значения не доходят до хранилища и не прерывают импорт
+	assert.Equal(t, models.ImportFailed, report.Rows[6].Status)
+	assert.Contains(t, report.Rows[6].Error, "255")
+	assert.Equal(t, models.ImportFailed, report.Rows[7].Status)
+	assert.Contains(t, report.Rows[7].Error, "link")
+
+	assert.Equal(t, http.StatusBadRequest, doTextRequest(source, http.MethodPost, "/import", "application/json", `{"artist":"Любэ"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, doTextRequest(source, http.MethodPost, "/import", "text/csv", "name\nЛюбэ\n").Code)
+	assert.Equal(t, http.StatusUnsupportedMediaType, doTextRequest(source, http.MethodPost, "/import", "text/plain", "").Code)
+	assert.Equal(t, http.StatusBadRequest, doRequest(t, source, http.MethodGet, "/export?format=xml", nil).Code)
+}
+
+func TestAddSongsBatch(t *testing.T) {
+	handler := router.NewRouter(repository.NewMemory(), nil, nil, nil)
+	require.Equal(t, http.StatusOK, doRequest(t, handler, http.MethodPost, "/songs", models.SongInput{Group: "Кино", Song: "Кукушка"}).Code)
+
+	batch := []models.SongInput{
+		{Group: "Кино", Song: "Группа крови", ReleaseDate: "1988-01-04"},
+		{Group: "Кино", Song: "Кукушка"},
+		{Group: " Сплин ", Song: "Выхода нет"},
+		{Group: "Сплин", Song: "Выхода  нет"},
+		{Group: "", Song: "Без исполнителя"},
+		{Group: "Сплин", Song: "Романс", ReleaseDate: "01.01.2000"},
+	}
+	decode := func(w *httptest.ResponseRecorder) models.BatchSongsResponse {
+		var response models.BatchSongsResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+
+	// В атомарном режиме пакет с ошибками не сохраняется целиком
+	w := doRequest(t, handler, http.MethodPost, "/songs/batch?atomic=true", batch)
+	require.Equal(t, http.StatusUnprocessableEntity, w.Code, w.Body.String())
+	response := decode(w)
+	assert.True(t, response.Atomic)
+	assert.Equal(t, 0, response.Created)
+	assert.Equal(t, models.BatchAborted, response.Results[0].Status)
+	assert.Nil(t, response.Results[0].Song)
+	assert.Equal(t, http.StatusNotFound, doRequest(t, handler, http.MethodGet, "/songs/2", nil).Code)
+	assert.Equal(t, http.StatusNotFound, doRequest(t, handler, http.MethodGet, "/artists/2", nil).Code)
+
+	w = doRequest(t, handler, http.MethodPost, "/songs/batch", batch)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	response = decode(w)
+	assert.Equal(t, 2, response.Created)
+	assert.Equal(t, 2, response.Conflicts)
+	assert.Equal(t, 2, response.Invalid)
+	statuses := make([]string, 0, len(response.Results))
+	for i, result := range response.Results {
+		assert.Equal(t, i, result.Index)
+		statuses = append(statuses, result.Status)
+	}
+	assert.Equal(t, []string{models.BatchCreated, models.BatchConflict, models.BatchCreated, models.BatchConflict, models.BatchInvalid, models.BatchInvalid}, statuses)
+	assert.Equal(t, "Сплин", response.Results[2].Song.GroupName)
+	assert.Contains(t, response.Results[3].Error, "duplicate")
+	assert.Contains(t, response.Results[5].Error, "release_date")
+
+	w = doRequest(t, handler, http.MethodGet, "/songs/"+url.PathEscape("Группа крови"), nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = doRequest(t, handler, http.MethodPost, "/songs/batch?atomic=true", []models.SongInput{{Group: "Сплин", Song: "Романс"}, {Group: "Ария", Song: "Беспечный ангел"}})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, 2, decode(w).Created)
+
+	assert.Equal(t, http.StatusBadRequest, doRequest(t, handler, http.MethodPost, "/songs/batch", []models.SongInput{}).Code)
+	assert.Equal(t, http.StatusBadRequest, doRequest(t, handler, http.MethodPost, "/songs/batch", models.SongInput{Group: "Кино", Song: "Звезда"}).Code)
+	assert.Equal(t, http.StatusBadRequest, doRequest(t, handler, http.MethodPost, "/songs/batch?atomic=maybe", batch).Code)
+}
+
+func TestTrashAPI(t *testing.T) {
+	handler := router.NewRouter(repository.NewMemory(), nil, nil, nil)
+	for _, input := range []models.SongInput{{Group: "Кино", Song: "Кукушка"}, {Group: "Кино", Song: "Звезда"}, {Group: "Сплин", Song: "Романс"}} {
+		require.Equal(t, http.StatusOK, doRequest(t, handler, http.MethodPost, "/songs", input).Code)
+	}
+	update := models.SongUpdateResponse{Text: models.SongText{Verses: []string{"Песен, ещё ненаписанных, сколько?"}}}
+	require.Equal(t, http.StatusOK, doRequest(t, handler, http.MethodPut, "/songs/1", update).Code)
+
+	// Удалённая песня пропадает из поиска по названию, списков и поиска по тексту
+	require.Equal(t, http.StatusNoContent, doRequest(t, handler, http.MethodDelete, "/songs/"+url.PathEscape("Кукушка"), nil).Code)
+	assert.Equal(t, http.StatusNotFound, doRequest(t, handler, http.MethodGet, "/songs/1", nil).Code)
+	assert.Equal(t, http.StatusNotFound, doRequest(t, handler, http.MethodDelete, "/songs/1", nil).Code)
+	var search models.SearchResponse
+	require.NoError(t, json.Unmarshal(doRequest(t, handler, http.MethodGet, "/search?q="+url.QueryEscape("ненаписанных"), nil).Body.Bytes(), &search))
+	assert.Empty(t, search.Results)
+
+	require.Equal(t, http.StatusNoContent, doRequest(t, handler, http.MethodDelete, "/artists/2?cascade=true", nil).Code)
+	listTrash := func(query string) models.TrashResponse {
+		w := doRequest(t, handler, http.MethodGet, "/trash"+query, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var trash models.TrashResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &trash))
+		return trash
+	}
+	trash := listTrash("")
+	assert.Equal(t, int64(3), trash.TotalItems)
+	trash = listTrash("?type=song")
+	require.Len(t, trash.Items, 2)
+	assert.Equal(t, "Романс", trash.Items[0].Name)
+	assert.Equal(t, "Сплин", trash.Items[0].Artist)
+	assert.Equal(t, http.StatusBadRequest, doRequest(t, handler, http.MethodGet, "/trash?type=album", nil).Code)
+
+	// Песня восстанавливается вместе с текстом
+	w := doRequest(t, handler, http.MethodPost, "/trash/songs/1/restore", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	w = doRequest(t, handler, http.MethodGet, "/songs/1/lyrics?format=legacy", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var lyrics models.PaginatedLyricsRespons
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &lyrics))
+	assert.Equal(t, update.Text.Verses, lyrics.Verses)
+	assert.Equal(t, http.StatusNotFound, doRequest(t, handler, http.MethodPost, "/trash/songs/1/restore", nil).Code)
+
+	// Восстановление песни возвращает и её исполнителя, если имя не занято
+	require.Equal(t, http.StatusOK, doRequest(t, handler, http.MethodPost, "/songs", models.SongInput{Group: "Сплин", Song: "Выхода нет"}).Code)
+	assert.Equal(t, http.StatusConflict, doRequest(t, handler, http.MethodPost, "/trash/songs/3/restore", nil).Code)
+	assert.Equal(t, http.StatusConflict, doRequest(t, handler, http.MethodPost, "/trash/artists/2/restore", nil).Code)
+
+	assert.Equal(t, http.StatusNoContent, doRequest(t, handler, http.MethodDelete, "/trash/artists/2", nil).Code)
+	assert.Equal(t, http.StatusNotFound, doRequest(t, handler, http.MethodDelete, "/trash/songs/3", nil).Code)
+	assert.Equal(t, http.StatusNotFound, doRequest(t, handler, http.MethodDelete, "/trash/artists/1", nil).Code)
+
+	require.Equal(t, http.StatusNoContent, doRequest(t, handler, http.MethodDelete, "/songs/2", nil).Code)
+	w = doRequest(t, handler, http.MethodDelete, "/trash", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var purged models.TrashPurgeResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &purged))
+	assert.Equal(t, models.TrashPurgeResult{Songs: 1}, purged)
+	assert.Empty(t, listTrash("").Items)
+}
+
+func TestSongRevisionsAPI(t *testing.T) {
+	handler := router.NewRouter(repository.NewMemory(), nil, nil, nil)
+	require.Equal(t, http.StatusOK, doRequest(t, handler, http.MethodPost, "/songs", models.SongInput{Group: "Кино", Song: "Кукушка"}).Code)
+	require.Equal(t, http.StatusCreated, doRequest(t, handler, http.MethodPost, "/artists", models.ArtistInput{Name: "Сплин"}).Code)
+
+	// Правки подписываются клиентом из заголовка X-Client-ID
+	update := func(body models.SongUpdateResponse, client string) {
+		payload, err := json.Marshal(body)
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPut, "/songs/1", bytes.NewReader(payload))
+		req.Header.Set("X-Client-ID", client)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+	update(models.SongUpdateResponse{Text: models.SongText{Verses: []string{"Песен, ещё ненаписанных, сколько?", "Скажи, кукушка"}}}, "editor")
+	update(models.SongUpdateResponse{
+		ArtistName:  "Сплин",
+		ReleaseDate: "1990.01.01",
+		Text:        models.SongText{Verses: []string{"Песен, ещё ненаписанных, сколько?", "Пропой, кукушка"}},
+	}, "moderator")
+
+	w := doRequest(t, handler, http.MethodGet, "/songs/1/revisions", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var history models.RevisionsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &history))
+	assert.Equal(t, int64(3), history.TotalItems)
+	require.Len(t, history.Revisions, 3)
+	assert.Equal(t, []int{3, 2, 1}, []int{history.Revisions[0].Number, history.Revisions[1].Number, history.Revisions[2].Number})
+	assert.Equal(t, "moderator", history.Revisions[0].ClientID)
+	assert.Equal(t, "editor", history.Revisions[1].ClientID)
+
+	w = doRequest(t, handler, http.MethodGet, "/songs/1/revisions/2", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var revision models.SongRevisionResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &revision))
+	assert.Equal(t, "Кино", revision.GroupName)
+	assert.Equal(t, []string{"Песен, ещё ненаписанных, сколько?", "Скажи, кукушка"}, revision.Text.FlatVerses())
+	assert.Equal(t, http.StatusNotFound, doRequest(t, handler, http.MethodGet, "/songs/1/revisions/9", nil).Code)
+
+	// По умолчанию последняя правка сравнивается с предыдущей
+	w = doRequest(t, handler, http.MethodGet, "/songs/1/revisions/diff", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var diff models.RevisionDiff
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &diff))
+	assert.Equal(t, 2, diff.From)
+	assert.Equal(t, 3, diff.To)
+	assert.Equal(t, []models.FieldChange{
+		{Field: "artist", From: "Кино", To: "Сплин"},
+		{Field: "release_date", From: "", To: "1990.01.01"},
+	}, diff.Fields)
+	ops := make([]string, len(diff.Lyrics))
+	for i, change := range diff.Lyrics {
+		ops[i] = change.Op
+	}
+	assert.Equal(t, []string{lyrics.DiffEqual, lyrics.DiffDelete, lyrics.DiffInsert}, ops)
+	assert.Equal(t, http.StatusBadRequest, doRequest(t, handler, http.MethodGet, "/songs/1/revisions/diff?from=0", nil).Code)
+	assert.Equal(t, http.StatusNotFound, doRequest(t, handler, http.MethodGet, "/songs/1/revisions/diff?from=1&to=7", nil).Code)
+
+	// Откат записывается новой правкой; удалённый исполнитель создаётся заново
+	require.Equal(t, http.StatusOK, doRequest(t, handler, http.MethodPost, "/songs", models.SongInput{Group: "Кино", Song: "Звезда"}).Code)
+	require.Equal(t, http.StatusNoContent, doRequest(t, handler, http.MethodDelete, "/artists/1?cascade=true", nil).Code)
+	w = doRequest(t, handler, http.MethodPost, "/songs/1/revisions/1/revert", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var song models.SongDetail
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &song))
+	assert.Equal(t, "Кино", song.GroupName)
+	assert.NotEqual(t, uint(1), song.ArtistID)
+	assert.Nil(t, song.ReleaseDate)
+
+	w = doRequest(t, handler, http.MethodGet, "/songs/1/revisions/diff?from=1", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	diff = models.RevisionDiff{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &diff))
+	assert.Equal(t, 4, diff.To)
+	assert.Empty(t, diff.Fields)
+	assert.Empty(t, diff.Lyrics)
+
+	assert.Equal(t, http.StatusNotFound, doRequest(t, handler, http.MethodPost, "/songs/1/revisions/5/revert", nil).Code)
+	assert.Equal(t, http.StatusNotFound, doRequest(t, handler, http.MethodGet, "/songs/2/revisions", nil).Code)
+}
+
+func TestOptimisticConcurrency(t *testing.T) {
+	handler := router.NewRouter(repository.NewMemory(), nil, nil, nil)
+	w := doRequest(t, handler, http.MethodPost, "/songs", models.SongInput{Group: "Кино", Song: "Кукушка"})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"1"`, w.Header().Get("ETag"))
+
+	withIfMatch := func(method, target, ifMatch string, body interface{}) *httptest.ResponseRecorder {
+		payload, err := json.Marshal(body)
+		require.NoError(t, err)
+		req := httptest.NewRequest(method, target, bytes.NewReader(payload))
+		req.Header.Set("If-Match", ifMatch)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	// Первый редактор сохраняет правку, второй с устаревшим ETag получает 412
+	w = withIfMatch(http.MethodPut, "/songs/"+url.PathEscape("Кукушка"), `"1"`, models.SongUpdateResponse{GroupLink: "https://example.com/1"})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+	w = withIfMatch(http.MethodPut, "/songs/1", `"1"`, models.SongUpdateResponse{GroupLink: "https://example.com/2"})
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+	assert.Equal(t, http.StatusPreconditionFailed, withIfMatch(http.MethodDelete, "/songs/1", `W/"2"`, nil).Code)
+
+	w = doRequest(t, handler, http.MethodGet, "/songs/1", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+	var song models.SongDetail
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &song))
+	assert.Equal(t, "https://example.com/1", song.SongURL)
+	assert.Equal(t, int64(2), song.Version)
+
+	// Переименование исполнителя меняет и песни, поэтому их версии тоже растут
+	w = doRequest(t, handler, http.MethodGet, "/artists/1", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"1"`, w.Header().Get("ETag"))
+	w = withIfMatch(http.MethodPut, "/artists/1", `"0", "1"`, models.ArtistInput{Name: "КИНО"})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+	assert.Equal(t, http.StatusPreconditionFailed, withIfMatch(http.MethodDelete, "/artists/1?cascade=true", `"1"`, nil).Code)
+	assert.Equal(t, `"3"`, doRequest(t, handler, http.MethodGet, "/songs/1", nil).Header().Get("ETag"))
+
+	assert.Equal(t, http.StatusNoContent, withIfMatch(http.MethodDelete, "/songs/1", `"3"`, nil).Code)
+	assert.Equal(t, http.StatusNoContent, withIfMatch(http.MethodDelete, "/artists/1", "*", nil).Code)
+}
+
+func TestPatchSong(t *testing.T) {
+	handler := router.NewRouter(repository.NewMemory(), nil, nil, nil)
+	require.Equal(t, http.StatusOK, doRequest(t, handler, http.MethodPost, "/songs", models.SongInput{Group: "Кино", Song: "Кукушка"}).Code)
+	require.Equal(t, http.StatusOK, doRequest(t, handler, http.MethodPost, "/songs", models.SongInput{Group: "Кино", Song: "Звезда"}).Code)
+	require.Equal(t, http.StatusCreated, doRequest(t, handler, http.MethodPost, "/artists", models.ArtistInput{Name: "Сплин"}).Code)
+
+	patch := func(body string) (*httptest.ResponseRecorder, models.SongDetail) {
+		w := doTextRequest(handler, http.MethodPatch, "/songs/1", "application/merge-patch+json", body)
+		var song models.SongDetail
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &song))
+		}
+		return w, song
+	}
+
+	w, song := patch(`{"release_date": "1990.01.01", "group_link": "https://example.com", "text": {"verses": ["Песен, ещё ненаписанных, сколько?"]}}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+	assert.Equal(t, "Кукушка", song.SongName)
+	assert.Equal(t, 1990, song.ReleaseDate.Year())
+	assert.Equal(t, "https://example.com", song.SongURL)
+	assert.NotEmpty(t, song.Text)
+
+	// null очищает поле, отсутствующие ключи не меняются
+	w, song = patch(`{"text": null, "artist_name": "Сплин"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1990, song.ReleaseDate.Year())
+	assert.Empty(t, song.Text)
+	assert.Equal(t, "https://example.com", song.SongURL)
+	assert.Equal(t, "Сплин", song.GroupName)
+	w = doRequest(t, handler, http.MethodGet, "/songs/1/lyrics", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "Song has no lyrics")
+
+	// Пустой документ ничего не меняет и не создаёт правку
+	w, song = patch(`{}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, int64(3), song.Version)
+
+	// null очищает и неверную дату релиза
+	w, song = patch(`{"release_date": null}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Nil(t, song.ReleaseDate)
+	assert.Equal(t, int64(4), song.Version)
+	w = doRequest(t, handler, http.MethodGet, "/songs/1", nil)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &song))
+	assert.Nil(t, song.ReleaseDate)
+
+	for body, code := range map[string]int{
+		`{"song_name": null}`:            http.StatusBadRequest,
+		`{"release_date": "01-01-1990"}`: http.StatusBadRequest,
+		`{"genre": "rock"}`:              http.StatusBadRequest,
+		`["song_name"]`:                  http.StatusBadRequest,
+		`{"artist_name": "Аквариум"}`:    http.StatusNotFound,
+	} {
+		w, _ = patch(body)
+		assert.Equal(t, code, w.Code, body)
+	}
+	assert.Equal(t, http.StatusUnsupportedMediaType, doTextRequest(handler, http.MethodPatch, "/songs/1", "text/plain", `{}`).Code)
+
+	w, _ = patch(`{"artist_name": "Кино", "song_name": "Звезда"}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = doRequest(t, handler, http.MethodGet, "/songs/1", nil)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &song))
+	assert.Equal(t, "Сплин", song.GroupName)
+	assert.Equal(t, "Кукушка", song.SongName)
+}
+
+func TestHealthEndpoints(t *testing.T) {
+	stopping := make(chan struct{})
+	checker := health.NewChecker(time.Second, stopping)
+	checker.Add("database", func(context.Context) error { return nil })
+	handler := router.NewRouter(repository.NewMemory(), nil, checker, nil)
+
+	w := doRequest(t, handler, http.MethodGet, "/healthz", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = doRequest(t, handler, http.MethodGet, "/readyz", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var report health.Report
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.Equal(t, health.StatusOK, report.Status)
+	require.Len(t, report.Checks, 1)
+	assert.Equal(t, "database", report.Checks[0].Name)
+
+	// Во время остановки готовность пропадает, а живость остаётся
+	close(stopping)
+	w = doRequest(t, handler, http.MethodGet, "/readyz", nil)
+	require.Equal(t, http.StatusServiceUnavailable, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.Equal(t, health.StatusShuttingDown, report.Status)
+	assert.Equal(t, http.StatusOK, doRequest(t, handler, http.MethodGet, "/healthz", nil).Code)
+
+	failing := health.NewChecker(time.Second, nil)
+	failing.Add("migrations", func(context.Context) error { return errors.New("schema is behind") })
+	w = doRequest(t, router.NewRouter(repository.NewMemory(), nil, failing, nil), http.MethodGet, "/readyz", nil)
+	require.Equal(t, http.StatusServiceUnavailable, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.Equal(t, health.StatusFail, report.Status)
+	assert.Equal(t, "schema is behind", report.Checks[0].Error)
+}
+
+func TestDBStats(t *testing.T) {
+	assert.Equal(t, http.StatusNotFound, doRequest(t, router.NewRouter(repository.NewMemory(), nil, nil, nil), http.MethodGet, "/debug/db", nil).Code)
+
+	handler := router.NewRouter(repository.NewMemory(), nil, nil, func() sql.DBStats {
+		return sql.DBStats{MaxOpenConnections: 10, OpenConnections: 3, InUse: 1, Idle: 2, WaitCount: 4, WaitDuration: 1500 * time.Microsecond}
+	})
+	w := doRequest(t, handler, http.MethodGet, "/debug/db", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var stats models.DBPoolStats
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+	assert.Equal(t, models.DBPoolStats{MaxOpenConnections: 10, OpenConnections: 3, InUse: 1, Idle: 2, WaitCount: 4, WaitDurationMS: 1.5}, stats)
+}
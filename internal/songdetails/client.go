@@ -0,0 +1,130 @@
+package songdetails
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"music/config"
+	"music/internal/models"
+	"music/pkg/logger"
+	"music/pkg/lyrics"
+)
+
+const (
+	infoPath     = "/info"
+	retryBackoff = 100 * time.Millisecond
+	dateLayout   = "02.01.2006"
+)
+
+// ErrNotFound возвращается, если внешний API не знает о песне
+var ErrNotFound = errors.New("song details not found")
+
+// Details - ответ внешнего API с подробностями о песне
+type Details struct {
+	ReleaseDate string `json:"releaseDate"` // Дата релиза в формате 02.01.2006
+	Text        string `json:"text"`        // Текст песни, куплеты разделены пустой строкой
+	Link        string `json:"link"`        // Ссылка на песню
+}
+
+// Client обращается к внешнему API за подробностями о песне
+type Client struct {
+	baseURL    string
+	retries    int
+	httpClient *http.Client
+}
+
+// NewClient создаёт клиента по настройкам из config
+func NewClient(cfg config.SongDetailsConfig) *Client {
+	return &Client{
+		baseURL:    strings.TrimRight(cfg.BaseURL, "/"),
+		retries:    cfg.Retries,
+		httpClient: &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+// Fetch запрашивает подробности о песне, повторяя запрос при сетевых ошибках и ответах 5xx
+func (c *Client) Fetch(ctx context.Context, group, song string) (*Details, error) {
+	var lastErr error
+	for attempt := 0; attempt <= c.retries; attempt++ {
+		if attempt > 0 {
+			logger.DebugKV(ctx, "Retrying song details request", "attempt", attempt, "error", lastErr)
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(time.Duration(attempt) * retryBackoff):
+			}
+		}
+
+		details, retry, err := c.fetchOnce(ctx, group, song)
+		if err == nil {
+			return details, nil
+		}
+		if !retry {
+			return nil, err
+		}
+		lastErr = err
+	}
+	return nil, fmt.Errorf("song details request failed after %d attempts: %w", c.retries+1, lastErr)
+}
+
+// fetchOnce выполняет один запрос и сообщает, имеет ли смысл повторять его при ошибке
+func (c *Client) fetchOnce(ctx context.Context, group, song string) (*Details, bool, error) {
+	query := url.Values{}
+	query.Set("group", group)
+	query.Set("song", song)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+infoPath+"?"+query.Encode(), http.NoBody)
+	if err != nil {
+		return nil, false, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, ctx.Err() == nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, false, ErrNotFound
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return nil, true, fmt.Errorf("song details API returned %d", resp.StatusCode)
+	case resp.StatusCode != http.StatusOK:
+		return nil, false, fmt.Errorf("song details API returned %d", resp.StatusCode)
+	}
+
+	var details Details
+	if err := json.NewDecoder(resp.Body).Decode(&details); err != nil {
+		return nil, false, fmt.Errorf("decode song details: %w", err)
+	}
+	return &details, false, nil
+}
+
+// Apply переносит полученные подробности в песню и отмечает её как обогащённую
+func (d *Details) Apply(song *models.SongDetail) error {
+	if d.ReleaseDate != "" {
+		releaseDate, err := time.Parse(dateLayout, d.ReleaseDate)
+		if err != nil {
+			return fmt.Errorf("invalid release date %q: %w", d.ReleaseDate, err)
+		}
+		song.ReleaseDate = &releaseDate
+	}
+
+	if d.Text != "" {
+		textJSON, err := json.Marshal(models.NewSongText(lyrics.Parse(d.Text)))
+		if err != nil {
+			return err
+		}
+		song.Text = string(textJSON)
+	}
+
+	song.SongURL = d.Link
+	song.EnrichmentStatus = models.EnrichmentDone
+	return nil
+}
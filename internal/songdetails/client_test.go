@@ -0,0 +1,115 @@
+package songdetails_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"music/config"
+	"music/internal/models"
+	"music/internal/songdetails"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClient(url string, retries int) *songdetails.Client {
+	return songdetails.NewClient(config.SongDetailsConfig{
+		BaseURL: url,
+		Timeout: time.Second,
+		Retries: retries,
+	})
+}
+
+func TestClient_Fetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/info", r.URL.Path)
+		assert.Equal(t, "Muse", r.URL.Query().Get("group"))
+		assert.Equal(t, "Supermassive Black Hole", r.URL.Query().Get("song"))
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(songdetails.Details{
+			ReleaseDate: "16.07.2006",
+			Text:        "Ooh baby, don't you know I suffer?\n\nOoh, you set my soul alight",
+			Link:        "https://www.youtube.com/watch?v=Xsp3_a-PMTw",
+		})
+	}))
+	defer server.Close()
+
+	details, err := newTestClient(server.URL, 0).Fetch(context.Background(), "Muse", "Supermassive Black Hole")
+	require.NoError(t, err)
+	assert.Equal(t, "16.07.2006", details.ReleaseDate)
+	assert.Equal(t, "https://www.youtube.com/watch?v=Xsp3_a-PMTw", details.Link)
+}
+
+func TestClient_FetchRetriesServerErrors(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(songdetails.Details{Link: "http://example.com"})
+	}))
+	defer server.Close()
+
+	details, err := newTestClient(server.URL, 2).Fetch(context.Background(), "Группа", "Песня")
+	require.NoError(t, err)
+	assert.Equal(t, "http://example.com", details.Link)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestClient_FetchGivesUp(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		retries   int
+		wantCalls int32
+	}{
+		{name: "Server error exhausts retries", status: http.StatusInternalServerError, retries: 1, wantCalls: 2},
+		{name: "Not found is not retried", status: http.StatusNotFound, retries: 2, wantCalls: 1},
+		{name: "Bad request is not retried", status: http.StatusBadRequest, retries: 2, wantCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&calls, 1)
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			_, err := newTestClient(server.URL, tt.retries).Fetch(context.Background(), "Группа", "Песня")
+			assert.Error(t, err)
+			assert.Equal(t, tt.wantCalls, atomic.LoadInt32(&calls))
+		})
+	}
+}
+
+func TestDetails_Apply(t *testing.T) {
+	details := songdetails.Details{
+		ReleaseDate: "16.07.2006",
+		Text:        "Первый куплет\nвторая строка\n\n\nВторой куплет\n",
+		Link:        "http://example.com",
+	}
+
+	var song models.SongDetail
+	require.NoError(t, details.Apply(&song))
+
+	require.NotNil(t, song.ReleaseDate)
+	assert.Equal(t, time.Date(2006, time.July, 16, 0, 0, 0, 0, time.UTC), *song.ReleaseDate)
+	assert.Equal(t, "http://example.com", song.SongURL)
+	assert.Equal(t, models.EnrichmentDone, song.EnrichmentStatus)
+
+	var text models.SongText
+	require.NoError(t, json.Unmarshal([]byte(song.Text), &text))
+	assert.Equal(t, []string{"Первый куплет\nвторая строка", "Второй куплет"}, text.FlatVerses())
+
+	invalid := songdetails.Details{ReleaseDate: "2006-07-16"}
+	assert.Error(t, invalid.Apply(&song))
+}
@@ -0,0 +1,64 @@
+package trash
+
+import (
+	"context"
+	"time"
+
+	"music/config"
+	"music/internal/models"
+	"music/internal/repository"
+	"music/pkg/logger"
+)
+
+// Sweeper периодически удаляет навсегда записи, пролежавшие в корзине дольше срока хранения
+type Sweeper struct {
+	trash     repository.TrashRepository
+	retention time.Duration
+	interval  time.Duration
+	now       func() time.Time
+}
+
+// NewSweeper создаёт очистку корзины по настройкам из config
+func NewSweeper(trash repository.TrashRepository, cfg config.TrashConfig) *Sweeper {
+	return &Sweeper{
+		trash:     trash,
+		retention: cfg.Retention,
+		interval:  cfg.SweepInterval,
+		now:       time.Now,
+	}
+}
+
+// Run очищает корзину сразу и затем с заданным интервалом, пока не отменён ctx.
+// При нулевом сроке хранения записи хранятся бессрочно и Run сразу возвращается.
+func (s *Sweeper) Run(ctx context.Context) {
+	if s.retention <= 0 {
+		logger.Info(ctx, "Trash retention is disabled, deleted records are kept until purged manually")
+		return
+	}
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		// Ошибка одной очистки не останавливает следующие
+		if _, err := s.Sweep(ctx); err != nil && ctx.Err() == nil {
+			logger.Error(ctx, "Trash sweep failed", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sweep удаляет навсегда записи, перенесённые в корзину раньше, чем срок хранения назад
+func (s *Sweeper) Sweep(ctx context.Context) (models.TrashPurgeResult, error) {
+	result, err := s.trash.Purge(ctx, s.now().Add(-s.retention))
+	if err != nil {
+		return result, err
+	}
+	if result.Songs > 0 || result.Artists > 0 {
+		logger.InfoKV(ctx, "Expired trash purged", "songs", result.Songs, "artists", result.Artists, "retention", s.retention.String())
+	}
+	return result, nil
+}
@@ -0,0 +1,51 @@
+package trash_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"music/config"
+	"music/internal/models"
+	"music/internal/repository"
+	"music/internal/trash"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSweeper_Sweep(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemory()
+
+	artist := &models.Artist{Name: "Кино"}
+	require.NoError(t, repos.Artists.Create(ctx, artist))
+	song := &models.SongDetail{ArtistID: artist.ID, GroupName: artist.Name, SongName: "Кукушка"}
+	require.NoError(t, repos.Songs.Create(ctx, song))
+	require.NoError(t, repos.Songs.Delete(ctx, song.ID, 0))
+
+	// Срок хранения ещё не истёк
+	result, err := trash.NewSweeper(repos.Trash, config.TrashConfig{Retention: time.Hour, SweepInterval: time.Hour}).Sweep(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, models.TrashPurgeResult{}, result)
+
+	time.Sleep(10 * time.Millisecond)
+	result, err = trash.NewSweeper(repos.Trash, config.TrashConfig{Retention: time.Millisecond, SweepInterval: time.Hour}).Sweep(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, models.TrashPurgeResult{Songs: 1}, result)
+	_, err = repos.Trash.RestoreSong(ctx, song.ID)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
+
+func TestSweeper_RunDisabled(t *testing.T) {
+	done := make(chan struct{})
+	go func() {
+		trash.NewSweeper(repository.NewMemory().Trash, config.TrashConfig{SweepInterval: time.Hour}).Run(context.Background())
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run with zero retention must return immediately")
+	}
+}
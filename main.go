@@ -2,11 +2,22 @@ package main
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"flag"
 	"fmt"
 	"net/http"
+	"os"
 
 	"music/config"
 	"music/internal/db"
+	"music/internal/db/migrations"
+	"music/internal/handlers"
+	"music/internal/health"
+	"music/internal/lifecycle"
+	"music/internal/repository"
+	"music/internal/songdetails"
+	"music/internal/trash"
 
 	"music/internal/router"
 	"music/pkg/logger"
@@ -19,38 +30,106 @@ func main() {
 	// Загружаем переменные окружения
 	config.LoadEnv()
 
-	// Получаем конфигурацию сервера
-	port, readTimeout, writeTimeout := config.GetServerConfig()
-	config.SetLogLevel()
+	// Собираем настройки из файла, окружения и флагов; с ошибкой в настройках сервер не запускается
+	cfg, args, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		fmt.Fprintf(os.Stderr, "Usage: %s [flags] [config | migrate COMMAND]\n\nFlags:\n", os.Args[0])
+		config.Usage(os.Stderr)
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(lifecycle.ExitServerError)
+	}
+	config.SetupLogger(cfg.Log)
 
 	// Создание нового контекста с логгером
 	ctx := context.Background()
 	ctx = logger.ToContext(ctx, logger.Global())
+	logger.DebugKV(ctx, "Configuration loaded", "config", cfg.String())
+
+	// Подкоманды не запускают сервер: config выводит настройки без секретов, migrate обслуживает схему базы
+	if len(args) > 0 {
+		switch args[0] {
+		case "config":
+			fmt.Print(cfg)
+		case "migrate":
+			if err := runMigrate(ctx, cfg.Database, args[1:]); err != nil {
+				logger.Fatal(ctx, "migrate command failed", err)
+			}
+		default:
+			fmt.Fprintf(os.Stderr, "unknown command %q, expected config or migrate\n", args[0])
+			os.Exit(lifecycle.ExitServerError)
+		}
+		return
+	}
 
 	// Подключение к базе данных
-	database, err := db.Connect()
+	database, err := db.Connect(ctx, cfg.Database)
 	if err != nil {
 		logger.Fatal(ctx, "failed to connect to the database", err) // Используем ваш логгер
 	}
 
 	logger.Info(ctx, "Database connection established successfully!") // Логируем успешное подключение
 
-	db.Migrate(database)
+	sqlDB, err := database.DB()
+	if err != nil {
+		logger.Fatal(ctx, "failed to get database handle", err)
+	}
+
+	// Применяем встроенные миграции; если схема новее приложения, сервер не запускается.
+	// Миграции идут до регистрации хуков остановки: logger.Fatal завершает процесс, минуя хуки.
+	migrator, err := db.NewMigrator(sqlDB, migrations.FS)
+	if err != nil {
+		logger.Fatal(ctx, "failed to load migrations", err)
+	}
+	if err := migrator.Migrate(ctx); err != nil {
+		logger.Fatal(ctx, "failed to migrate database", err)
+	}
+
+	// Хуки остановки выполняются в обратном порядке: сначала закрывается база, последними сбрасываются логи
+	lc := lifecycle.New(cfg.Server.ShutdownTimeout, cfg.Server.ReadinessDrain)
+	lc.OnShutdown("log flush", func(context.Context) error { return logger.Sync() })
+	lc.OnShutdown("database", func(context.Context) error { return sqlDB.Close() })
+
+	// Клиент внешнего API для обогащения песен, если указан его адрес
+	var details handlers.SongDetailsFetcher
+	if cfg.SongDetails.BaseURL != "" {
+		details = songdetails.NewClient(cfg.SongDetails)
+	} else {
+		logger.Warn(ctx, "SONG_DETAILS_URL is not set, new songs will stay pending enrichment")
+	}
+
+	repos := repository.NewPostgres(database)
 
-	// Передаем соединение базы данных в маршрутизатор
-	r := router.NewRouter(database) // Обновите функцию NewRouter, чтобы принимать db
-	fmt.Printf("Server started at :%s\n", port)
+	// Фоновая очистка корзины от записей старше срока хранения
+	lc.Go(ctx, "trash sweeper", trash.NewSweeper(repos.Trash, cfg.Trash).Run)
+
+	// Готовность: база отвечает, а версия схемы совпадает со встроенными миграциями
+	checker := health.NewChecker(cfg.Server.HealthCheckTimeout, lc.Stopping())
+	checker.Add("database", sqlDB.PingContext)
+	checker.Add("migrations", migrator.CheckCurrent)
+
+	// Статистика пула открывается только явно: маршрут не требует авторизации
+	var dbStats func() sql.DBStats
+	if cfg.Server.DebugEndpoints {
+		dbStats = sqlDB.Stats
+	}
+
+	// Передаем хранилища поверх соединения с базой данных в маршрутизатор
+	r := router.NewRouter(repos, details, checker, dbStats)
+	fmt.Printf("Server started at :%s\n", cfg.Server.Port)
 
 	// Настройка сервера с таймаутами
 	srv := &http.Server{
-		Addr:         ":" + port,
-		Handler:      r,
-		ReadTimeout:  readTimeout,
-		WriteTimeout: writeTimeout,
+		Addr:              ":" + cfg.Server.Port,
+		Handler:           r,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
 	}
 
-	// Обработаем ошибку от ListenAndServe
-	if err := srv.ListenAndServe(); err != nil {
-		logger.Fatal(ctx, "Server failed to start", err)
-	}
+	// Сервер работает до SIGINT или SIGTERM, после чего завершает начатые запросы и останавливается
+	os.Exit(lc.Run(ctx, srv))
 }
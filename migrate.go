@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"music/config"
+	"music/internal/db"
+	"music/internal/db/migrations"
+
+	"github.com/pressly/goose/v3"
+)
+
+// migrateUsage описывает подкоманду migrate
+const migrateUsage = `Usage: main [flags] migrate [-dir DIR] COMMAND
+
+Commands:
+  up           применить все неприменённые миграции
+  down         откатить последнюю применённую миграцию
+  status       показать состояние миграций
+  baseline     принять базу, созданную до перехода на миграции, как базу с первой миграцией
+  create NAME  создать заготовку миграции в каталоге -dir
+`
+
+// runMigrate выполняет подкоманду migrate над встроенными миграциями
+func runMigrate(ctx context.Context, cfg config.DatabaseConfig, args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	dir := flags.String("dir", "internal/db/migrations", "Каталог, в котором create создаёт новую миграцию")
+	flags.Usage = func() { fmt.Fprint(flags.Output(), migrateUsage) }
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("migrate: command is required")
+	}
+
+	command := flags.Arg(0)
+	if command == "create" {
+		if flags.NArg() != 2 {
+			return errors.New("migrate create: migration name is required")
+		}
+		return db.Create(*dir, flags.Arg(1))
+	}
+
+	database, err := db.Connect(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	sqlDB, err := database.DB()
+	if err != nil {
+		return err
+	}
+	defer sqlDB.Close()
+
+	migrator, err := db.NewMigrator(sqlDB, migrations.FS)
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, result := range applied {
+			fmt.Println(result)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("No pending migrations")
+		}
+	case "down":
+		result, err := migrator.Down(ctx)
+		if errors.Is(err, goose.ErrNoNextVersion) {
+			fmt.Println("No applied migrations")
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Println(result)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("    %-24s   %s\n", "Applied At", "Migration")
+		fmt.Println("    =======================================")
+		for _, status := range statuses {
+			appliedAt := "Pending"
+			if status.State == goose.StateApplied {
+				appliedAt = status.AppliedAt.Format(time.ANSIC)
+			}
+			fmt.Printf("    %-24s -- %s\n", appliedAt, status.Source.Path)
+		}
+		// Версия новее встроенных миграций означает, что базу обновила более новая сборка
+		if err := migrator.Check(ctx); err != nil {
+			return err
+		}
+	case "baseline":
+		version, err := migrator.Baseline(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Recorded version %d, run \"migrate up\" or start the server to apply the rest\n", version)
+	default:
+		flags.Usage()
+		return fmt.Errorf("migrate: unknown command %q", command)
+	}
+	return nil
+}
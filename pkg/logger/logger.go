@@ -2,8 +2,10 @@ package logger
 
 import (
 	"context"
+	"errors"
 	"io"
 	"os"
+	"syscall"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -41,29 +43,36 @@ func New(level LevelEnabler, options ...zap.Option) TypeOfLogger {
 
 // NewWithSink ...
 func NewWithSink(level LevelEnabler, sink io.Writer, options ...zap.Option) TypeOfLogger {
+	return newWithEncoder(level, zapcore.NewJSONEncoder(encoderConfig), sink, options...)
+}
+
+// NewConsole creates logger writing human-readable lines instead of JSON
+func NewConsole(level LevelEnabler, sink io.Writer, options ...zap.Option) TypeOfLogger {
+	return newWithEncoder(level, zapcore.NewConsoleEncoder(encoderConfig), sink, options...)
+}
+
+var encoderConfig = zapcore.EncoderConfig{
+	TimeKey:        "ts",
+	LevelKey:       "lvl",
+	NameKey:        "log-of",
+	CallerKey:      "at",
+	MessageKey:     "msg",
+	StacktraceKey:  "stack",
+	LineEnding:     zapcore.DefaultLineEnding,
+	EncodeLevel:    zapcore.LowercaseLevelEncoder,
+	EncodeTime:     zapcore.ISO8601TimeEncoder,
+	EncodeDuration: zapcore.SecondsDurationEncoder,
+	EncodeCaller:   zapcore.ShortCallerEncoder,
+}
+
+func newWithEncoder(level LevelEnabler, encoder zapcore.Encoder, sink io.Writer, options ...zap.Option) TypeOfLogger {
 	if level == nil {
 		level = defaultLevel
 	}
 	return TypeOfLogger{
 		LevelEnabler: level,
 		SugaredLogger: zap.New(
-			zapcore.NewCore(
-				zapcore.NewJSONEncoder(zapcore.EncoderConfig{
-					TimeKey:        "ts",
-					LevelKey:       "lvl",
-					NameKey:        "log-of",
-					CallerKey:      "at",
-					MessageKey:     "msg",
-					StacktraceKey:  "stack",
-					LineEnding:     zapcore.DefaultLineEnding,
-					EncodeLevel:    zapcore.LowercaseLevelEncoder,
-					EncodeTime:     zapcore.ISO8601TimeEncoder,
-					EncodeDuration: zapcore.SecondsDurationEncoder,
-					EncodeCaller:   zapcore.ShortCallerEncoder,
-				}),
-				zapcore.AddSync(sink),
-				level,
-			),
+			zapcore.NewCore(encoder, zapcore.AddSync(sink), level),
 			options...,
 		).Sugar(),
 	}
@@ -103,6 +112,15 @@ func SetLogger(l TypeOfLogger) {
 	global = l
 }
 
+// Sync flushes buffered entries of the global logger.
+// Sinks that cannot be synced, such as terminals and pipes, are not treated as errors.
+func Sync() error {
+	if err := global.Sync(); err != nil && !errors.Is(err, syscall.EINVAL) && !errors.Is(err, syscall.ENOTTY) {
+		return err
+	}
+	return nil
+}
+
 // Below listed all logging functions
 // Suffix meaning:
 // * No suffix, e.g. Debug()   - log concatenated args
@@ -0,0 +1,95 @@
+package lyrics
+
+// Операции в сравнении двух текстов
+const (
+	DiffEqual  = "equal"
+	DiffInsert = "insert"
+	DiffDelete = "delete"
+)
+
+// VerseChange - куплет в сравнении двух текстов. OldIndex задан для куплетов старого текста
+// (equal и delete), NewIndex - для куплетов нового (equal и insert); номера начинаются с 0.
+type VerseChange struct {
+	Op       string `json:"op" enums:"equal,insert,delete"`
+	Text     string `json:"text"`
+	OldIndex *int   `json:"old_index,omitempty"`
+	NewIndex *int   `json:"new_index,omitempty"`
+}
+
+// maxDiffCells ограничивает таблицу общей подпоследовательности: она занимает по 4 байта на пару куплетов,
+// а два текста предельного размера дали бы гигабайты
+const maxDiffCells = 1 << 20
+
+// DiffVerses сравнивает два текста по куплетам через наибольшую общую подпоследовательность
+// и возвращает куплеты обоих текстов по порядку. Удалённые куплеты идут перед добавленными на их место.
+// Общие начало и конец не участвуют в сравнении; если изменённая середина слишком велика для таблицы,
+// она целиком считается заменённой.
+func DiffVerses(old, new []string) []VerseChange {
+	prefix := 0
+	for prefix < len(old) && prefix < len(new) && old[prefix] == new[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(old)-prefix && suffix < len(new)-prefix && old[len(old)-1-suffix] == new[len(new)-1-suffix] {
+		suffix++
+	}
+
+	changes := make([]VerseChange, 0, max(len(old), len(new)))
+	for i := 0; i < prefix; i++ {
+		changes = append(changes, VerseChange{Op: DiffEqual, Text: old[i], OldIndex: index(i), NewIndex: index(i)})
+	}
+	changes = diffMiddle(changes, old[:len(old)-suffix], new[:len(new)-suffix], prefix)
+	for k := suffix; k > 0; k-- {
+		i, j := len(old)-k, len(new)-k
+		changes = append(changes, VerseChange{Op: DiffEqual, Text: old[i], OldIndex: index(i), NewIndex: index(j)})
+	}
+	return changes
+}
+
+// diffMiddle дописывает в changes сравнение old[from:] и new[from:], у которых нет общих начала и конца
+func diffMiddle(changes []VerseChange, old, new []string, from int) []VerseChange {
+	n, m := len(old)-from, len(new)-from
+	if n*m > maxDiffCells {
+		for i := from; i < len(old); i++ {
+			changes = append(changes, VerseChange{Op: DiffDelete, Text: old[i], OldIndex: index(i)})
+		}
+		for j := from; j < len(new); j++ {
+			changes = append(changes, VerseChange{Op: DiffInsert, Text: new[j], NewIndex: index(j)})
+		}
+		return changes
+	}
+
+	// common[i*(m+1)+j] - длина общей подпоследовательности old[from+i:] и new[from+j:]
+	width := m + 1
+	common := make([]int32, (n+1)*width)
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if old[from+i] == new[from+j] {
+				common[i*width+j] = common[(i+1)*width+j+1] + 1
+			} else {
+				common[i*width+j] = max(common[(i+1)*width+j], common[i*width+j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && old[from+i] == new[from+j]:
+			changes = append(changes, VerseChange{Op: DiffEqual, Text: old[from+i], OldIndex: index(from + i), NewIndex: index(from + j)})
+			i++
+			j++
+		case i < n && (j == m || common[(i+1)*width+j] >= common[i*width+j+1]):
+			changes = append(changes, VerseChange{Op: DiffDelete, Text: old[from+i], OldIndex: index(from + i)})
+			i++
+		default:
+			changes = append(changes, VerseChange{Op: DiffInsert, Text: new[from+j], NewIndex: index(from + j)})
+			j++
+		}
+	}
+	return changes
+}
+
+func index(i int) *int {
+	return &i
+}
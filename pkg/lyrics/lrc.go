@@ -0,0 +1,192 @@
+package lyrics
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidLRC возвращается, если файл LRC не удалось разобрать
+var ErrInvalidLRC = errors.New("invalid LRC")
+
+// lrcTimestamp - метка времени в начале строки LRC: [01:23.45], [01:23:45] или [01:23]
+var lrcTimestamp = regexp.MustCompile(`^\[(\d{1,3}):(\d{1,2})(?:[.:](\d{1,3}))?\]`)
+
+// lrcTag - заголовок файла LRC вроде [ar:Исполнитель]
+var lrcTag = regexp.MustCompile(`^\[([a-zA-Z]+):(.*)\]$`)
+
+// lrcTagOrder - известные заголовки LRC в порядке записи. Прочие заголовки пропускаются при разборе.
+var lrcTagOrder = []string{"ar", "ti", "al", "au", "by", "length", "offset", "re", "ve"}
+
+// LRC - разобранный файл LRC: заголовки и текст по разделам с моментами начала строк
+type LRC struct {
+	Tags     map[string]string
+	Sections []Section
+}
+
+// ParseLRC разбирает файл LRC. Строка может начинаться с нескольких меток времени, если она поётся
+// несколько раз. Разделы отделяются пустыми строками и строками из одной метки времени (паузами).
+// Заголовок [offset:] учитывается в моментах начала и в Tags не попадает.
+func ParseLRC(text string) (LRC, error) {
+	result := LRC{Tags: make(map[string]string)}
+	var offset int64
+	var blocks [][]rawLine
+	var block []rawLine
+	flush := func() {
+		if len(block) > 0 {
+			blocks = append(blocks, block)
+			block = nil
+		}
+	}
+
+	for number, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(strings.TrimPrefix(line, "\ufeff"))
+		if line == "" {
+			flush()
+			continue
+		}
+
+		var times []int64
+		for {
+			match := lrcTimestamp.FindStringSubmatch(line)
+			if match == nil {
+				break
+			}
+			at, err := parseLRCTime(match[1], match[2], match[3])
+			if err != nil {
+				return LRC{}, fmt.Errorf("%w: line %d: %v", ErrInvalidLRC, number+1, err)
+			}
+			times = append(times, at)
+			line = strings.TrimSpace(line[len(match[0]):])
+		}
+
+		if len(times) == 0 {
+			if match := lrcTag.FindStringSubmatch(line); match != nil && isLRCTag(match[1]) {
+				key, value := strings.ToLower(match[1]), strings.TrimSpace(match[2])
+				if key == "offset" {
+					var err error
+					if offset, err = strconv.ParseInt(strings.TrimPrefix(value, "+"), 10, 64); err != nil {
+						return LRC{}, fmt.Errorf("%w: line %d: offset must be an integer number of milliseconds", ErrInvalidLRC, number+1)
+					}
+					continue
+				}
+				result.Tags[key] = value
+				continue
+			}
+			if strings.HasPrefix(line, "[") && len(line) > 1 && line[1] >= '0' && line[1] <= '9' {
+				return LRC{}, fmt.Errorf("%w: line %d: malformed timestamp", ErrInvalidLRC, number+1)
+			}
+		}
+
+		if line == "" {
+			flush()
+			continue
+		}
+		block = append(block, rawLine{text: line, times: times})
+	}
+	flush()
+
+	// Положительный offset означает, что текст должен появляться раньше
+	for _, block := range blocks {
+		for i := range block {
+			for j, at := range block[i].times {
+				block[i].times[j] = max(at-offset, 0)
+			}
+			sort.Slice(block[i].times, func(a, b int) bool { return block[i].times[a] < block[i].times[b] })
+		}
+		result.Sections = parseBlock(result.Sections, block)
+	}
+	return result, nil
+}
+
+func parseLRCTime(minutes, seconds, fraction string) (int64, error) {
+	mins, _ := strconv.ParseInt(minutes, 10, 64)
+	secs, _ := strconv.ParseInt(seconds, 10, 64)
+	if secs >= 60 {
+		return 0, fmt.Errorf("seconds out of range in [%s:%s]", minutes, seconds)
+	}
+	var ms int64
+	if fraction != "" {
+		// Дробная часть дополняется до миллисекунд: .5 - 500 мс, .45 - 450 мс
+		ms, _ = strconv.ParseInt((fraction + "00")[:3], 10, 64)
+	}
+	return (mins*60+secs)*1000 + ms, nil
+}
+
+func isLRCTag(key string) bool {
+	key = strings.ToLower(key)
+	for _, known := range lrcTagOrder {
+		if key == known {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatLRC записывает текст в формате LRC. Известные заголовки с непустыми значениями идут первыми
+// в привычном порядке, разделы отделяются пустой строкой. Строки без моментов начала записываются без меток.
+func FormatLRC(tags map[string]string, sections []Section) string {
+	var b strings.Builder
+	for _, key := range lrcTagOrder {
+		if value := strings.TrimSpace(tags[key]); value != "" {
+			fmt.Fprintf(&b, "[%s:%s]\n", key, value)
+		}
+	}
+
+	for i, section := range sections {
+		if i > 0 || b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		for _, line := range section.Lines {
+			for _, at := range line.Times {
+				b.WriteString(FormatLRCTime(at))
+			}
+			b.WriteString(line.Text)
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// FormatLRCTime записывает момент в миллисекундах меткой LRC с сотыми долями секунды
+func FormatLRCTime(ms int64) string {
+	return fmt.Sprintf("[%02d:%02d.%02d]", ms/60000, ms/1000%60, ms%1000/10)
+}
+
+// TimedLine - строка на временной шкале песни
+type TimedLine struct {
+	Time    int64  `json:"time_ms"` // Момент начала в миллисекундах
+	Text    string `json:"text"`
+	Section int    `json:"section"` // Номер раздела, начиная с 0
+}
+
+// Timeline раскладывает строки с моментами начала по времени. Строка с несколькими моментами
+// попадает на шкалу несколько раз, строки без моментов пропускаются.
+func Timeline(sections []Section) []TimedLine {
+	var timeline []TimedLine
+	for i, section := range sections {
+		for _, line := range section.Lines {
+			for _, at := range line.Times {
+				timeline = append(timeline, TimedLine{Time: at, Text: line.Text, Section: i})
+			}
+		}
+	}
+	sort.SliceStable(timeline, func(a, b int) bool { return timeline[a].Time < timeline[b].Time })
+	return timeline
+}
+
+// At находит строку, которая звучит в момент position, и до next следующих строк.
+// До начала первой строки текущей строки нет.
+func At(timeline []TimedLine, position int64, next int) (*TimedLine, []TimedLine) {
+	index := sort.Search(len(timeline), func(i int) bool { return timeline[i].Time > position })
+	var current *TimedLine
+	if index > 0 {
+		line := timeline[index-1]
+		current = &line
+	}
+	end := min(index+next, len(timeline))
+	return current, append([]TimedLine{}, timeline[index:end]...)
+}
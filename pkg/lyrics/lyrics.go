@@ -0,0 +1,102 @@
+// Package lyrics разбирает тексты песен: делит их на куплеты по пустым строкам,
+// нормализует строки внутри куплетов и распознаёт пометки о повторах.
+package lyrics
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Options управляет разбором текста
+type Options struct {
+	KeepLineBreaks bool // Сохранять переводы строк внутри куплета; по умолчанию строки склеиваются через пробел
+	TrimRepeats    bool // Убирать пометки о повторе вроде (х3) или (x2) в конце строк
+}
+
+// repeatMarker - пометка о повторе в конце строки: (х3), (x2), [х 4]. Буква х допускается кириллическая и латинская.
+var repeatMarker = regexp.MustCompile(`\s*[(\[]\s*[xXхХ×]\s*(\d+)\s*[)\]]\s*$`)
+
+// Split разбивает текст на куплеты. Куплеты разделяются одной или несколькими пустыми строками,
+// пробелы по краям строк и пустые куплеты отбрасываются.
+func Split(text string, opts Options) []string {
+	separator := " "
+	if opts.KeepLineBreaks {
+		separator = "\n"
+	}
+
+	var verses []string
+	for _, block := range blocks(text) {
+		lines := make([]string, 0, len(block))
+		for _, line := range block {
+			// Строка из одной пометки о повторе не попадает в куплет
+			if opts.TrimRepeats {
+				if line = TrimRepeatMarker(line); line == "" {
+					continue
+				}
+			}
+			lines = append(lines, line)
+		}
+		if len(lines) > 0 {
+			verses = append(verses, strings.Join(lines, separator))
+		}
+	}
+	return verses
+}
+
+// blocks делит текст на куплеты из непустых строк без пробелов по краям
+func blocks(text string) [][]string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+
+	var result [][]string
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			if len(lines) > 0 {
+				result = append(result, lines)
+				lines = nil
+			}
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) > 0 {
+		result = append(result, lines)
+	}
+	return result
+}
+
+// TrimRepeatMarker убирает пометку о повторе в конце строки
+func TrimRepeatMarker(line string) string {
+	text, _ := ParseRepeat(line)
+	return text
+}
+
+// ParseRepeat отделяет пометку о повторе в конце строки и возвращает строку без неё и число повторов.
+// Если пометки нет, число повторов равно 0.
+func ParseRepeat(line string) (string, int) {
+	match := repeatMarker.FindStringSubmatchIndex(line)
+	if match == nil {
+		return line, 0
+	}
+	times, err := strconv.Atoi(line[match[2]:match[3]])
+	if err != nil {
+		return line, 0
+	}
+	return line[:match[0]], times
+}
+
+// Quote записывает куплеты строками JSON через запятую, чтобы их можно было вставить в массив verses
+func Quote(verses []string) string {
+	quoted := make([]string, 0, len(verses))
+	for _, verse := range verses {
+		var buf strings.Builder
+		encoder := json.NewEncoder(&buf)
+		encoder.SetEscapeHTML(false)
+		_ = encoder.Encode(verse) // Запись строки в strings.Builder не возвращает ошибок
+		quoted = append(quoted, strings.TrimSuffix(buf.String(), "\n"))
+	}
+	return strings.Join(quoted, ", ")
+}
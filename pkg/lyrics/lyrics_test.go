@@ -0,0 +1,221 @@
+package lyrics_test
+
+import (
+	"fmt"
+	"testing"
+
+	"music/pkg/lyrics"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplit(t *testing.T) {
+	text := "  Выйду ночью в поле с конём,\r\nНочкой тёмной тихо пойдём.(х3)\r\n\r\n\r\n" +
+		"Ночью в поле звёзд благодать,\nПо полю идём (x2)\n(х2)\n\n   \n"
+
+	tests := []struct {
+		name string
+		opts lyrics.Options
+		want []string
+	}{
+		{
+			name: "Default joins lines",
+			want: []string{
+				"Выйду ночью в поле с конём, Ночкой тёмной тихо пойдём.(х3)",
+				"Ночью в поле звёзд благодать, По полю идём (x2) (х2)",
+			},
+		},
+		{
+			name: "Keep line breaks",
+			opts: lyrics.Options{KeepLineBreaks: true},
+			want: []string{
+				"Выйду ночью в поле с конём,\nНочкой тёмной тихо пойдём.(х3)",
+				"Ночью в поле звёзд благодать,\nПо полю идём (x2)\n(х2)",
+			},
+		},
+		{
+			name: "Trim repeats",
+			opts: lyrics.Options{KeepLineBreaks: true, TrimRepeats: true},
+			want: []string{
+				"Выйду ночью в поле с конём,\nНочкой тёмной тихо пойдём.",
+				"Ночью в поле звёзд благодать,\nПо полю идём",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, lyrics.Split(text, tt.opts))
+		})
+	}
+
+	assert.Empty(t, lyrics.Split("\n \n", lyrics.Options{}))
+}
+
+func TestTrimRepeatMarker(t *testing.T) {
+	assert.Equal(t, "Мы пойдём с конём", lyrics.TrimRepeatMarker("Мы пойдём с конём(х3)"))
+	assert.Equal(t, "Far away", lyrics.TrimRepeatMarker("Far away [X 2] "))
+	assert.Equal(t, "Комната (x2) номер", lyrics.TrimRepeatMarker("Комната (x2) номер"))
+	assert.Equal(t, "Глава (x)", lyrics.TrimRepeatMarker("Глава (x)"))
+}
+
+func TestQuote(t *testing.T) {
+	assert.Equal(t, `"Первый <куплет>", "Второй\n\"куплет\""`, lyrics.Quote([]string{"Первый <куплет>", "Второй\n\"куплет\""}))
+}
+
+func TestParse(t *testing.T) {
+	text := "Мы пойдём с конём,\nПо полю вдвоём(х3)\n\nТолько мы с конём\n(x2)\n"
+
+	sections := lyrics.Parse(text)
+	assert.Equal(t, []lyrics.Section{
+		{Type: lyrics.SectionVerse, Lines: []lyrics.Line{{Text: "Мы пойдём с конём,"}, {Text: "По полю вдвоём", Repeat: 3}}},
+		{Type: lyrics.SectionVerse, Lines: []lyrics.Line{{Text: "Только мы с конём"}}, Repeat: 2},
+	}, sections)
+
+	assert.Equal(t, []string{
+		"Мы пойдём с конём,\nПо полю вдвоём\nПо полю вдвоём\nПо полю вдвоём",
+		"Только мы с конём",
+		"Только мы с конём",
+	}, lyrics.Expand(sections))
+
+	assert.Equal(t, sections, lyrics.ParseVerses([]string{"Мы пойдём с конём,\nПо полю вдвоём(х3)", "Только мы с конём\n(x2)"}))
+	assert.Equal(t, "Мы пойдём с конём, По полю вдвоём", sections[0].Text(" "))
+	assert.Zero(t, lyrics.DropRepeats(sections)[1].Repeat)
+}
+
+func TestParse_Sections(t *testing.T) {
+	text := "[Intro]\nНа-на-на\n\nКуплет 1:\nВыйду ночью в поле с конём\nНочкой тёмной тихо пойдём\n" +
+		"[Припев] (х2)\nМы пойдём с конём по полю вдвоём\n\nОна сказала:\nНе ходи\n\nПрипев:\n\n[Instrumental]\nЛа-ла"
+
+	assert.Equal(t, []lyrics.Section{
+		{Type: lyrics.SectionIntro, Label: "Intro", Lines: []lyrics.Line{{Text: "На-на-на"}}},
+		{Type: lyrics.SectionVerse, Label: "Куплет 1", Lines: []lyrics.Line{{Text: "Выйду ночью в поле с конём"}, {Text: "Ночкой тёмной тихо пойдём"}}},
+		{Type: lyrics.SectionChorus, Label: "Припев", Lines: []lyrics.Line{{Text: "Мы пойдём с конём по полю вдвоём"}}, Repeat: 2},
+		{Type: lyrics.SectionVerse, Lines: []lyrics.Line{{Text: "Она сказала:"}, {Text: "Не ходи"}}},
+		{Type: lyrics.SectionChorus, Label: "Припев", Lines: []lyrics.Line{{Text: "Мы пойдём с конём по полю вдвоём"}}},
+		{Type: lyrics.SectionVerse, Label: "Instrumental", Lines: []lyrics.Line{{Text: "Ла-ла"}}},
+	}, lyrics.Parse(text))
+}
+
+func TestValidate(t *testing.T) {
+	sections, err := lyrics.Validate([]lyrics.Section{{Label: " Куплет ", Lines: []lyrics.Line{{Text: " Строка "}}}})
+	require.NoError(t, err)
+	assert.Equal(t, []lyrics.Section{{Type: lyrics.SectionVerse, Label: "Куплет", Lines: []lyrics.Line{{Text: "Строка"}}}}, sections)
+
+	for _, invalid := range [][]lyrics.Section{
+		{{Type: "solo", Lines: []lyrics.Line{{Text: "Строка"}}}},
+		{{Type: lyrics.SectionChorus}},
+		{{Lines: []lyrics.Line{{Text: "  "}}}},
+		{{Lines: []lyrics.Line{{Text: "Строка", Repeat: -1}}}},
+	} {
+		_, err := lyrics.Validate(invalid)
+		assert.ErrorIs(t, err, lyrics.ErrInvalidSection)
+	}
+}
+
+func TestParseLRC(t *testing.T) {
+	text := "[ar:Любэ]\r\n[ti:Конь]\n[offset:+500]\n" +
+		"[00:10.50]Выйду ночью в поле с конём\n[00:15.2]Ночкой тёмной тихо пойдём\n[00:20.00]\n" +
+		"[Припев]\n[01:30.00][00:25.00]Мы пойдём с конём по полю вдвоём\nБез метки"
+
+	parsed, err := lyrics.ParseLRC(text)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"ar": "Любэ", "ti": "Конь"}, parsed.Tags)
+	assert.Equal(t, []lyrics.Section{
+		{Type: lyrics.SectionVerse, Lines: []lyrics.Line{
+			{Text: "Выйду ночью в поле с конём", Times: []int64{10000}},
+			{Text: "Ночкой тёмной тихо пойдём", Times: []int64{14700}},
+		}},
+		{Type: lyrics.SectionChorus, Label: "Припев", Lines: []lyrics.Line{
+			{Text: "Мы пойдём с конём по полю вдвоём", Times: []int64{24500, 89500}},
+			{Text: "Без метки"},
+		}},
+	}, parsed.Sections)
+
+	assert.Equal(t, "[ar:Любэ]\n[ti:Конь]\n\n[00:10.00]Выйду ночью в поле с конём\n[00:14.70]Ночкой тёмной тихо пойдём\n\n"+
+		"[00:24.50][01:29.50]Мы пойдём с конём по полю вдвоём\nБез метки\n", lyrics.FormatLRC(parsed.Tags, parsed.Sections))
+
+	for _, invalid := range []string{"[00:75.00]Строка", "[0x:10]Строка", "[offset:быстро]"} {
+		_, err := lyrics.ParseLRC(invalid)
+		assert.ErrorIs(t, err, lyrics.ErrInvalidLRC, invalid)
+	}
+}
+
+func TestAt(t *testing.T) {
+	timeline := lyrics.Timeline([]lyrics.Section{
+		{Lines: []lyrics.Line{{Text: "Раз", Times: []int64{1000, 5000}}, {Text: "Два", Times: []int64{2000}}}},
+		{Lines: []lyrics.Line{{Text: "Три", Times: []int64{3000}}, {Text: "Без метки"}}},
+	})
+	require.Len(t, timeline, 4)
+
+	current, next := lyrics.At(timeline, 500, 2)
+	assert.Nil(t, current)
+	assert.Equal(t, []lyrics.TimedLine{{Time: 1000, Text: "Раз"}, {Time: 2000, Text: "Два"}}, next)
+
+	current, next = lyrics.At(timeline, 3000, 5)
+	require.NotNil(t, current)
+	assert.Equal(t, lyrics.TimedLine{Time: 3000, Text: "Три", Section: 1}, *current)
+	assert.Equal(t, []lyrics.TimedLine{{Time: 5000, Text: "Раз"}}, next)
+
+	current, next = lyrics.At(timeline, 60000, 3)
+	assert.Equal(t, "Раз", current.Text)
+	assert.Empty(t, next)
+}
+
+func TestDiffVerses(t *testing.T) {
+	changes := lyrics.DiffVerses([]string{"Раз", "Два", "Три"}, []string{"Раз", "Два!", "Три", "Четыре"})
+
+	ops := make([]string, len(changes))
+	texts := make([]string, len(changes))
+	for i, change := range changes {
+		ops[i], texts[i] = change.Op, change.Text
+	}
+	assert.Equal(t, []string{lyrics.DiffEqual, lyrics.DiffDelete, lyrics.DiffInsert, lyrics.DiffEqual, lyrics.DiffInsert}, ops)
+	assert.Equal(t, []string{"Раз", "Два", "Два!", "Три", "Четыре"}, texts)
+
+	require.NotNil(t, changes[3].OldIndex)
+	require.NotNil(t, changes[3].NewIndex)
+	assert.Equal(t, 2, *changes[3].OldIndex)
+	assert.Equal(t, 2, *changes[3].NewIndex)
+	assert.Nil(t, changes[4].OldIndex)
+	assert.Equal(t, 3, *changes[4].NewIndex)
+
+	assert.Empty(t, lyrics.DiffVerses(nil, nil))
+}
+
+func TestDiffVerses_Large(t *testing.T) {
+	// Два текста около мегабайта каждый с общими началом и концом
+	const size = 100000
+	old := make([]string, size)
+	new := make([]string, size)
+	for i := range old {
+		old[i] = fmt.Sprintf("Старый куплет %d", i)
+		new[i] = fmt.Sprintf("Новый куплет %d", i)
+	}
+	old[0], new[0] = "Припев", "Припев"
+	old[size-1], new[size-1] = "Кода", "Кода"
+
+	changes := lyrics.DiffVerses(old, new)
+	require.Len(t, changes, 2*size-2)
+	assert.Equal(t, lyrics.DiffEqual, changes[0].Op)
+	assert.Equal(t, lyrics.DiffEqual, changes[len(changes)-1].Op)
+	assert.Equal(t, size-1, *changes[len(changes)-1].OldIndex)
+	assert.Equal(t, size-1, *changes[len(changes)-1].NewIndex)
+
+	// Слишком большая изменённая середина считается заменённой целиком
+	assert.Equal(t, lyrics.DiffDelete, changes[1].Op)
+	assert.Equal(t, 1, *changes[1].OldIndex)
+	assert.Equal(t, lyrics.DiffInsert, changes[size-1].Op)
+	assert.Equal(t, 1, *changes[size-1].NewIndex)
+
+	// Короткая правка внутри длинного текста сравнивается точно
+	edited := append([]string(nil), old...)
+	edited[size/2] = "Правка"
+	changes = lyrics.DiffVerses(old, edited)
+	require.Len(t, changes, size+1)
+	assert.Equal(t, lyrics.DiffDelete, changes[size/2].Op)
+	assert.Equal(t, lyrics.DiffInsert, changes[size/2+1].Op)
+	assert.Equal(t, size/2, *changes[size/2+1].NewIndex)
+	assert.Equal(t, size/2+1, *changes[size/2+2].OldIndex)
+}
@@ -0,0 +1,283 @@
+package lyrics
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Типы разделов текста
+const (
+	SectionVerse  = "verse"
+	SectionChorus = "chorus"
+	SectionBridge = "bridge"
+	SectionIntro  = "intro"
+	SectionOutro  = "outro"
+)
+
+// ErrInvalidSection возвращается, если раздел текста заполнен неверно
+var ErrInvalidSection = errors.New("invalid lyrics section")
+
+// sectionKeywords сопоставляет первое слово заголовка раздела с его типом
+var sectionKeywords = map[string]string{
+	"verse":      SectionVerse,
+	"куплет":     SectionVerse,
+	"chorus":     SectionChorus,
+	"refrain":    SectionChorus,
+	"hook":       SectionChorus,
+	"припев":     SectionChorus,
+	"bridge":     SectionBridge,
+	"бридж":      SectionBridge,
+	"переход":    SectionBridge,
+	"intro":      SectionIntro,
+	"интро":      SectionIntro,
+	"вступление": SectionIntro,
+	"outro":      SectionOutro,
+	"аутро":      SectionOutro,
+	"концовка":   SectionOutro,
+	"кода":       SectionOutro,
+}
+
+// sectionHeader - заголовок раздела на отдельной строке: [Припев], [Verse 2] или Куплет 1:
+var sectionHeader = regexp.MustCompile(`^(?:\[\s*([^\[\]]+?)\s*\]|([^:\[\]]+?)\s*:)$`)
+
+// Line - строка текста. Repeat больше 1, если строка поётся несколько раз подряд.
+// Times хранит моменты начала строки в миллисекундах от начала песни, по одному на каждое исполнение.
+type Line struct {
+	Text   string  `json:"text"`
+	Repeat int     `json:"repeat,omitempty"`
+	Times  []int64 `json:"times_ms,omitempty"`
+}
+
+// Section - раздел текста: куплет, припев, бридж, вступление или концовка.
+// Label хранит заголовок раздела в том виде, в котором он был записан в тексте, например "Припев" или "Verse 2".
+// Repeat больше 1, если раздел поётся несколько раз подряд.
+type Section struct {
+	Type   string `json:"type" enums:"verse,chorus,bridge,intro,outro"`
+	Label  string `json:"label,omitempty"`
+	Lines  []Line `json:"lines"`
+	Repeat int    `json:"repeat,omitempty"`
+}
+
+// Parse разбивает текст на разделы и строки, переводя пометки о повторе в число повторов.
+// Разделы отделяются пустыми строками или заголовками вроде [Припев]. Пометка в конце строки
+// относится к строке, пометка на отдельной строке - ко всему разделу.
+func Parse(text string) []Section {
+	var sections []Section
+	for _, block := range blocks(text) {
+		sections = parseBlock(sections, rawLines(block))
+	}
+	return sections
+}
+
+// ParseVerses разбирает уже разделённые куплеты, например сохранённые в плоском формате
+func ParseVerses(verses []string) []Section {
+	var sections []Section
+	for _, verse := range verses {
+		var lines []string
+		for _, line := range strings.Split(strings.ReplaceAll(verse, "\r\n", "\n"), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				lines = append(lines, line)
+			}
+		}
+		sections = parseBlock(sections, rawLines(lines))
+	}
+	return sections
+}
+
+// rawLine - строка исходного текста с моментами начала, если они известны
+type rawLine struct {
+	text  string
+	times []int64
+}
+
+func rawLines(lines []string) []rawLine {
+	result := make([]rawLine, 0, len(lines))
+	for _, line := range lines {
+		result = append(result, rawLine{text: line})
+	}
+	return result
+}
+
+// parseBlock дописывает к sections разделы из блока непустых строк.
+// Заголовок без строк, например одиночный "Припев:", повторяет строки последнего раздела того же типа.
+func parseBlock(sections []Section, block []rawLine) []Section {
+	current := Section{Type: SectionVerse}
+	headed := false
+	flush := func() {
+		if len(current.Lines) == 0 && headed {
+			current.Lines = previousLines(sections, current.Type)
+		}
+		if len(current.Lines) > 0 {
+			sections = append(sections, current)
+		}
+	}
+
+	for _, raw := range block {
+		text, repeat := ParseRepeat(raw.text)
+		if text == "" {
+			current.Repeat = repeat
+			continue
+		}
+		if sectionType, label, ok := parseHeader(text); ok {
+			flush()
+			current = Section{Type: sectionType, Label: label, Repeat: repeat}
+			headed = true
+			continue
+		}
+		current.Lines = append(current.Lines, Line{Text: text, Repeat: repeat, Times: raw.times})
+	}
+	flush()
+	return sections
+}
+
+// previousLines возвращает копию строк последнего раздела заданного типа без моментов начала
+func previousLines(sections []Section, sectionType string) []Line {
+	for i := len(sections) - 1; i >= 0; i-- {
+		if sections[i].Type != sectionType {
+			continue
+		}
+		lines := make([]Line, 0, len(sections[i].Lines))
+		for _, line := range sections[i].Lines {
+			lines = append(lines, Line{Text: line.Text, Repeat: line.Repeat})
+		}
+		return lines
+	}
+	return nil
+}
+
+// parseHeader распознаёт заголовок раздела. Заголовок в квадратных скобках принимается всегда,
+// неизвестные слова в нём дают куплет с подписью; заголовок с двоеточием - только с известным словом,
+// чтобы не спутать его со строкой текста.
+func parseHeader(line string) (string, string, bool) {
+	match := sectionHeader.FindStringSubmatch(line)
+	if match == nil {
+		return "", "", false
+	}
+	label, bracketed := match[1], match[1] != ""
+	if !bracketed {
+		label = match[2]
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(label), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '-'
+	})
+	if len(words) > 0 {
+		if sectionType, ok := sectionKeywords[words[0]]; ok {
+			return sectionType, label, true
+		}
+	}
+	if bracketed {
+		return SectionVerse, label, true
+	}
+	return "", "", false
+}
+
+// Validate проверяет разделы, присланные клиентом, и подставляет тип verse там, где он не указан
+func Validate(sections []Section) ([]Section, error) {
+	result := make([]Section, 0, len(sections))
+	for i, section := range sections {
+		switch section.Type {
+		case "":
+			section.Type = SectionVerse
+		case SectionVerse, SectionChorus, SectionBridge, SectionIntro, SectionOutro:
+		default:
+			return nil, fmt.Errorf("%w: section %d has unknown type %q", ErrInvalidSection, i, section.Type)
+		}
+		if len(section.Lines) == 0 {
+			return nil, fmt.Errorf("%w: section %d has no lines", ErrInvalidSection, i)
+		}
+		if section.Repeat < 0 {
+			return nil, fmt.Errorf("%w: section %d has negative repeat", ErrInvalidSection, i)
+		}
+		lines := make([]Line, 0, len(section.Lines))
+		for j, line := range section.Lines {
+			line.Text = strings.TrimSpace(line.Text)
+			if line.Text == "" {
+				return nil, fmt.Errorf("%w: line %d of section %d is empty", ErrInvalidSection, j, i)
+			}
+			if line.Repeat < 0 {
+				return nil, fmt.Errorf("%w: line %d of section %d has negative repeat", ErrInvalidSection, j, i)
+			}
+			for _, at := range line.Times {
+				if at < 0 {
+					return nil, fmt.Errorf("%w: line %d of section %d has negative time", ErrInvalidSection, j, i)
+				}
+			}
+			lines = append(lines, line)
+		}
+		section.Label = strings.TrimSpace(section.Label)
+		section.Lines = lines
+		result = append(result, section)
+	}
+	return result, nil
+}
+
+// Text возвращает раздел без пометок о повторе, соединяя строки separator
+func (s Section) Text(separator string) string {
+	lines := make([]string, 0, len(s.Lines))
+	for _, line := range s.Lines {
+		lines = append(lines, line.Text)
+	}
+	return strings.Join(lines, separator)
+}
+
+// Verses записывает разделы в плоском формате: по строке на раздел, строки раздела соединяются переводом строки
+func Verses(sections []Section) []string {
+	verses := make([]string, 0, len(sections))
+	for _, section := range sections {
+		verses = append(verses, section.Text("\n"))
+	}
+	return verses
+}
+
+// ExpandSections выписывает повторы полностью: повторяющиеся строки и разделы записываются нужное число раз.
+// Моменты начала остаются у первой записи строки.
+func ExpandSections(sections []Section) []Section {
+	var result []Section
+	for _, section := range sections {
+		var lines []Line
+		for _, line := range section.Lines {
+			lines = append(lines, Line{Text: line.Text, Times: line.Times})
+			for i := 1; i < line.Repeat; i++ {
+				lines = append(lines, Line{Text: line.Text})
+			}
+		}
+		for i := 0; i < max(section.Repeat, 1); i++ {
+			result = append(result, Section{Type: section.Type, Label: section.Label, Lines: lines})
+		}
+	}
+	return result
+}
+
+// Expand записывает текст полностью в плоском формате
+func Expand(sections []Section) []string {
+	return Verses(ExpandSections(sections))
+}
+
+// DropRepeats возвращает разделы без информации о повторах
+func DropRepeats(sections []Section) []Section {
+	result := make([]Section, 0, len(sections))
+	for _, section := range sections {
+		lines := make([]Line, 0, len(section.Lines))
+		for _, line := range section.Lines {
+			lines = append(lines, Line{Text: line.Text, Times: line.Times})
+		}
+		result = append(result, Section{Type: section.Type, Label: section.Label, Lines: lines})
+	}
+	return result
+}
+
+// MergeLines склеивает строки каждого раздела через пробел в одну строку.
+// Повторы строк при этом теряются, моменты начала берутся у первой строки.
+func MergeLines(sections []Section) []Section {
+	result := make([]Section, 0, len(sections))
+	for _, section := range sections {
+		merged := section
+		merged.Lines = []Line{{Text: section.Text(" "), Times: section.Lines[0].Times}}
+		result = append(result, merged)
+	}
+	return result
+}